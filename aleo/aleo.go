@@ -0,0 +1,59 @@
+// Package aleo abstracts the Aleo message formatting, hashing and recovery of the aleo_utils WASM module behind a
+// narrow interface, so that the verification can be tested without the WASM runtime and backed by alternate
+// implementations.
+package aleo
+
+import (
+	aleo_utils "github.com/venture23-aleo/aleo-utils-go"
+)
+
+// Session formats, hashes and recovers the messages of the oracle program. A session isn't goroutine safe, create one
+// for every goroutine.
+type Session interface {
+	// FormatMessage formats a message as a Leo struct of targetChunks chunks of 32 u128 fields
+	FormatMessage(message []byte, targetChunks int) ([]byte, error)
+	// HashMessage returns the Poseidon8 hash of a formatted message, 16 bytes
+	HashMessage(message []byte) ([]byte, error)
+	// RecoverMessage recovers a message from the Leo struct it was formatted as
+	RecoverMessage(formattedMessage []byte) ([]byte, error)
+	Close()
+}
+
+// Wrapper creates sessions
+type Wrapper interface {
+	NewSession() (Session, error)
+	Close()
+}
+
+// wasmWrapper creates the sessions of an aleo_utils wrapper
+type wasmWrapper struct {
+	wrapper aleo_utils.Wrapper
+}
+
+// NewWrapper creates a wrapper of the aleo_utils WASM module, the returned function releases it
+func NewWrapper() (Wrapper, func(), error) {
+	wrapper, closeFn, err := aleo_utils.NewWrapper()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return FromWrapper(wrapper), closeFn, nil
+}
+
+// FromWrapper adapts an aleo_utils wrapper
+func FromWrapper(wrapper aleo_utils.Wrapper) Wrapper {
+	return &wasmWrapper{wrapper: wrapper}
+}
+
+func (w *wasmWrapper) NewSession() (Session, error) {
+	session, err := w.wrapper.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (w *wasmWrapper) Close() {
+	w.wrapper.Close()
+}
@@ -0,0 +1,117 @@
+package aleo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	aleo_utils "github.com/venture23-aleo/aleo-utils-go"
+)
+
+func TestFakeSession(t *testing.T) {
+	session := FakeSession{}
+
+	tests := []struct {
+		name         string
+		message      []byte
+		targetChunks int
+		wantErr      bool
+	}{
+		{name: "one chunk", message: []byte("message"), targetChunks: 1},
+		{name: "full chunks", message: make([]byte, 2*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE), targetChunks: 2},
+		{name: "too long", message: make([]byte, aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE+1), targetChunks: 1, wantErr: true},
+		{name: "no chunks", message: []byte("message"), targetChunks: 0, wantErr: true},
+		{name: "too many chunks", message: []byte("message"), targetChunks: aleo_utils.MAX_FORMAT_MESSAGE_CHUNKS + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := session.FormatMessage(tt.message, tt.targetChunks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(formatted) != tt.targetChunks*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE || !bytes.HasPrefix(formatted, tt.message) {
+				t.Errorf("FormatMessage() = %x", formatted)
+			}
+
+			recovered, _ := session.RecoverMessage(formatted)
+			if !bytes.Equal(recovered, formatted) {
+				t.Errorf("RecoverMessage() = %x, want %x", recovered, formatted)
+			}
+
+			hash, _ := session.HashMessage(formatted)
+			otherHash, _ := session.HashMessage(append(formatted, 0))
+			if len(hash) != 16 || bytes.Equal(hash, otherHash) {
+				t.Errorf("HashMessage() = %x, %x", hash, otherHash)
+			}
+		})
+	}
+}
+
+// failingSession fails every operation
+type failingSession struct {
+	FakeSession
+}
+
+func (failingSession) HashMessage([]byte) ([]byte, error) {
+	return nil, errors.New("hash failed")
+}
+
+type failingWrapper struct {
+	FakeWrapper
+}
+
+func (failingWrapper) NewSession() (Session, error) {
+	return failingSession{}, nil
+}
+
+func TestMeteredWrapper(t *testing.T) {
+	wrapper := NewMeteredWrapper(FakeWrapper{})
+
+	// the clock reads 0, 2, 4, 8, 12 and 18ms, so the calls take 2, 4 and 6ms
+	var calls int
+	start := time.Now()
+	wrapper.now = func() time.Time {
+		calls++
+		return start.Add(time.Duration(calls*calls/2) * time.Millisecond)
+	}
+
+	session, err := wrapper.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	formatted, err := session.FormatMessage([]byte("message"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.HashMessage(formatted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.HashMessage(formatted); err != nil {
+		t.Fatal(err)
+	}
+
+	failing := NewMeteredWrapper(failingWrapper{})
+	failingSession, _ := failing.NewSession()
+	if _, err := failingSession.HashMessage(formatted); err == nil {
+		t.Error("HashMessage() didn't return the error of the session")
+	}
+
+	stats := wrapper.Stats()
+	if stats.Sessions != 1 || stats.FormatMessage.Calls != 1 || stats.HashMessage.Calls != 2 || stats.HashMessage.Errors != 0 || stats.RecoverMessage.Calls != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	if stats.FormatMessage.MaxMs != 2 || stats.HashMessage.AverageMs != 5 || stats.HashMessage.MaxMs != 6 || stats.RecoverMessage.AverageMs != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	if stats := failing.Stats(); stats.HashMessage.Calls != 1 || stats.HashMessage.Errors != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
@@ -0,0 +1,48 @@
+package aleo
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	aleo_utils "github.com/venture23-aleo/aleo-utils-go"
+)
+
+// FakeWrapper creates fake sessions for tests, see FakeSession
+type FakeWrapper struct{}
+
+func (FakeWrapper) NewSession() (Session, error) {
+	return FakeSession{}, nil
+}
+
+func (FakeWrapper) Close() {}
+
+// FakeSession pads messages to the size of the target chunks instead of formatting them as Leo structs, and hashes
+// them with truncated SHA-256 instead of Poseidon8. Reports must be created with the hashes of a fake session.
+type FakeSession struct{}
+
+func (FakeSession) FormatMessage(message []byte, targetChunks int) ([]byte, error) {
+	if targetChunks < 1 || targetChunks > aleo_utils.MAX_FORMAT_MESSAGE_CHUNKS {
+		return nil, fmt.Errorf("aleo: invalid number of chunks %d", targetChunks)
+	}
+
+	formatted := make([]byte, targetChunks*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE)
+	if len(message) > len(formatted) {
+		return nil, fmt.Errorf("aleo: message of %d bytes doesn't fit in %d chunks", len(message), targetChunks)
+	}
+	copy(formatted, message)
+
+	return formatted, nil
+}
+
+// HashMessage returns the first 16 bytes of the SHA-256 of the message, the size of a Poseidon8 hash
+func (FakeSession) HashMessage(message []byte) ([]byte, error) {
+	hash := sha256.Sum256(message)
+	return hash[:16], nil
+}
+
+// RecoverMessage returns the padded message
+func (FakeSession) RecoverMessage(formattedMessage []byte) ([]byte, error) {
+	return append([]byte{}, formattedMessage...), nil
+}
+
+func (FakeSession) Close() {}
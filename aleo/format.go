@@ -0,0 +1,169 @@
+package aleo
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/venture23-aleo/oracle-verification-backend/plaintext"
+
+	aleo_utils "github.com/venture23-aleo/aleo-utils-go"
+)
+
+// the number of bytes in a u128 field
+const FIELD_SIZE = 16
+
+// the number of u128 fields in a chunk
+const CHUNK_FIELDS = aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE / FIELD_SIZE
+
+var (
+	ErrInvalidChunks         = errors.New("aleo: number of chunks must be between 1 and 32")
+	ErrMessageTooLong        = errors.New("aleo: message doesn't fit in the number of chunks")
+	ErrInvalidFormattedValue = errors.New("aleo: formatted message isn't a struct of chunks of u128 fields")
+)
+
+// FormatMessage formats a message as a Leo struct of targetChunks chunks c0, c1, ... of 32 u128 fields f0, f1, ...,
+// with the little-endian 16-byte groups of the message padded with zeroes. The result is the same as the one of the
+// aleo_utils WASM module: the multi-line snarkVM plaintext without the line breaks.
+func FormatMessage(message []byte, targetChunks int) ([]byte, error) {
+	if targetChunks < 1 || targetChunks > aleo_utils.MAX_FORMAT_MESSAGE_CHUNKS {
+		return nil, ErrInvalidChunks
+	}
+
+	if len(message) > targetChunks*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE {
+		return nil, fmt.Errorf("%w: %d bytes, %d chunks", ErrMessageTooLong, len(message), targetChunks)
+	}
+
+	padded := make([]byte, targetChunks*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE)
+	copy(padded, message)
+
+	var sb strings.Builder
+	sb.WriteString("{")
+
+	field := new(big.Int)
+	fieldBytes := make([]byte, FIELD_SIZE)
+
+	for chunk := 0; chunk < targetChunks; chunk++ {
+		sb.WriteString("  c")
+		sb.WriteString(strconv.Itoa(chunk))
+		sb.WriteString(": {")
+
+		for idx := 0; idx < CHUNK_FIELDS; idx++ {
+			offset := (chunk*CHUNK_FIELDS + idx) * FIELD_SIZE
+
+			// the fields are little-endian, big.Int takes big-endian bytes
+			copy(fieldBytes, padded[offset:offset+FIELD_SIZE])
+			slices.Reverse(fieldBytes)
+			field.SetBytes(fieldBytes)
+
+			sb.WriteString("    f")
+			sb.WriteString(strconv.Itoa(idx))
+			sb.WriteString(": ")
+			sb.WriteString(field.String())
+			sb.WriteString("u128")
+			if idx < CHUNK_FIELDS-1 {
+				sb.WriteString(",")
+			}
+		}
+
+		sb.WriteString("  }")
+		if chunk < targetChunks-1 {
+			sb.WriteString(",")
+		}
+	}
+
+	sb.WriteString("}")
+
+	return []byte(sb.String()), nil
+}
+
+// RecoverMessage recovers the message from a Leo struct created by FormatMessage, including the padding. Same as the
+// aleo_utils WASM module, the chunks must be named c0, c1, ... and the fields f0, f1, ... in order, but they don't
+// have to be complete.
+func RecoverMessage(formattedMessage []byte) ([]byte, error) {
+	value, err := plaintext.Parse(string(formattedMessage))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormattedValue, err)
+	}
+
+	if value.Kind != plaintext.KIND_STRUCT || len(value.Members) > aleo_utils.MAX_FORMAT_MESSAGE_CHUNKS {
+		return nil, ErrInvalidFormattedValue
+	}
+
+	message := make([]byte, 0, len(value.Members)*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE)
+
+	for chunkIdx, chunk := range value.Members {
+		if chunk.Name != "c"+strconv.Itoa(chunkIdx) {
+			return nil, fmt.Errorf("%w: unexpected chunk %s", ErrInvalidFormattedValue, chunk.Name)
+		}
+
+		if chunk.Value.Kind != plaintext.KIND_STRUCT || len(chunk.Value.Members) > CHUNK_FIELDS {
+			return nil, fmt.Errorf("%w: chunk %s isn't a struct of up to %d fields", ErrInvalidFormattedValue, chunk.Name, CHUNK_FIELDS)
+		}
+
+		for fieldIdx, field := range chunk.Value.Members {
+			if field.Name != "f"+strconv.Itoa(fieldIdx) {
+				return nil, fmt.Errorf("%w: unexpected field %s.%s", ErrInvalidFormattedValue, chunk.Name, field.Name)
+			}
+
+			number, err := field.Value.Uint128()
+			if err != nil {
+				return nil, fmt.Errorf("%w: field %s.%s: %w", ErrInvalidFormattedValue, chunk.Name, field.Name, err)
+			}
+
+			fieldBytes := make([]byte, FIELD_SIZE)
+			number.FillBytes(fieldBytes)
+			slices.Reverse(fieldBytes)
+
+			message = append(message, fieldBytes...)
+		}
+	}
+
+	return message, nil
+}
+
+// NativeWrapper creates sessions that format and recover messages in Go, see FormatMessage and RecoverMessage, and hash
+// them with the sessions of another wrapper, which needs the WASM runtime for the Poseidon8 hash
+type NativeWrapper struct {
+	wrapper Wrapper
+}
+
+func NewNativeWrapper(wrapper Wrapper) *NativeWrapper {
+	return &NativeWrapper{wrapper: wrapper}
+}
+
+func (w *NativeWrapper) NewSession() (Session, error) {
+	session, err := w.wrapper.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	return &nativeSession{session: session}, nil
+}
+
+func (w *NativeWrapper) Close() {
+	w.wrapper.Close()
+}
+
+type nativeSession struct {
+	session Session
+}
+
+func (s *nativeSession) FormatMessage(message []byte, targetChunks int) ([]byte, error) {
+	return FormatMessage(message, targetChunks)
+}
+
+func (s *nativeSession) HashMessage(message []byte) ([]byte, error) {
+	return s.session.HashMessage(message)
+}
+
+func (s *nativeSession) RecoverMessage(formattedMessage []byte) ([]byte, error) {
+	return RecoverMessage(formattedMessage)
+}
+
+func (s *nativeSession) Close() {
+	s.session.Close()
+}
@@ -0,0 +1,220 @@
+package aleo
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	aleo_utils "github.com/venture23-aleo/aleo-utils-go"
+)
+
+// the messages that the native and the WASM formatting are compared with
+func formatTestMessages() []struct {
+	name    string
+	message []byte
+	chunks  int
+} {
+	random := make([]byte, 10*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	return []struct {
+		name    string
+		message []byte
+		chunks  int
+	}{
+		{name: "empty", message: nil, chunks: 1},
+		{name: "short", message: []byte{1, 2, 3}, chunks: 1},
+		{name: "partial field", message: bytes.Repeat([]byte{0xab}, 17), chunks: 2},
+		{name: "max u128", message: bytes.Repeat([]byte{0xff}, aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE), chunks: 1},
+		{name: "report data", message: random[:10*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE-100], chunks: 10},
+		{name: "full", message: random, chunks: 10},
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	formatted, err := FormatMessage([]byte{1, 2, 3}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantPrefix := "{  c0: {    f0: 197121u128,    f1: 0u128,"
+	if !strings.HasPrefix(string(formatted), wantPrefix) || !strings.HasSuffix(string(formatted), "    f31: 0u128  }}") {
+		t.Errorf("FormatMessage() = %s", formatted)
+	}
+
+	// the maximum number of chunks isn't compared with the WASM module, such large messages crash the wazero compiler
+	// on some platforms
+	messages := append(formatTestMessages(), struct {
+		name    string
+		message []byte
+		chunks  int
+	}{name: "max chunks", message: []byte{1}, chunks: aleo_utils.MAX_FORMAT_MESSAGE_CHUNKS})
+
+	for _, tt := range messages {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := FormatMessage(tt.message, tt.chunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recovered, err := RecoverMessage(formatted)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := make([]byte, tt.chunks*aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE)
+			copy(want, tt.message)
+			if !bytes.Equal(recovered, want) {
+				t.Errorf("RecoverMessage() = %x, want %x", recovered, want)
+			}
+		})
+	}
+
+	if _, err := FormatMessage(nil, 0); !errors.Is(err, ErrInvalidChunks) {
+		t.Errorf("FormatMessage() error = %v, want %v", err, ErrInvalidChunks)
+	}
+	if _, err := FormatMessage(make([]byte, aleo_utils.MESSAGE_FORMAT_BLOCK_SIZE+1), 1); !errors.Is(err, ErrMessageTooLong) {
+		t.Errorf("FormatMessage() error = %v, want %v", err, ErrMessageTooLong)
+	}
+}
+
+// the formatted messages that the native and the WASM recovery are compared with
+var recoverTestMessages = []struct {
+	name             string
+	formattedMessage string
+	want             []byte
+	wantErr          bool
+}{
+	{name: "single-line", formattedMessage: "{ c0: { f0: 1u128, f1: 340282366920938463463374607431768211455u128 } }", want: append([]byte{1, 15: 0}, bytes.Repeat([]byte{0xff}, 16)...)},
+	{name: "multi-line", formattedMessage: "{\n  c0: {\n    f0: 2u128\n  },\n  c1: {\n    f0: 3u128\n  }\n}", want: []byte{2, 15: 0, 16: 3, 31: 0}},
+	{name: "not a struct", formattedMessage: "1u128", wantErr: true},
+	{name: "unexpected chunk", formattedMessage: "{ c1: { f0: 1u128 } }", wantErr: true},
+	{name: "unexpected field", formattedMessage: "{ c0: { f0: 1u128, f2: 1u128 } }", wantErr: true},
+	{name: "chunk isn't a struct", formattedMessage: "{ c0: 1u128 }", wantErr: true},
+	{name: "not u128", formattedMessage: "{ c0: { f0: 1u64 } }", wantErr: true},
+	{name: "u128 out of range", formattedMessage: "{ c0: { f0: 340282366920938463463374607431768211456u128 } }", wantErr: true},
+	{name: "malformed", formattedMessage: "{ c0: { f0: 1u128 }", wantErr: true},
+}
+
+func TestRecoverMessage(t *testing.T) {
+	for _, tt := range recoverTestMessages {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RecoverMessage([]byte(tt.formattedMessage))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RecoverMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("RecoverMessage() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNativeWrapper(t *testing.T) {
+	session, err := NewNativeWrapper(FakeWrapper{}).NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	formatted, err := session.FormatMessage([]byte{1, 2, 3}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := FormatMessage([]byte{1, 2, 3}, 1); !bytes.Equal(formatted, want) {
+		t.Errorf("FormatMessage() = %s, want %s", formatted, want)
+	}
+
+	// hashed by the fake session
+	hash, _ := session.HashMessage(formatted)
+	if want, _ := (FakeSession{}).HashMessage(formatted); !bytes.Equal(hash, want) {
+		t.Errorf("HashMessage() = %x, want %x", hash, want)
+	}
+
+	recovered, err := session.RecoverMessage(formatted)
+	if err != nil || !bytes.Equal(recovered[:4], []byte{1, 2, 3, 0}) {
+		t.Errorf("RecoverMessage() = %x, %v", recovered, err)
+	}
+}
+
+// wasmSession creates a session of the aleo_utils WASM module, the differential tests are skipped without the WASM runtime
+func wasmSession(t *testing.T) Session {
+	wrapper, closeFn, err := NewWrapper()
+	if err != nil {
+		t.Skip("no WASM runtime:", err)
+	}
+	t.Cleanup(closeFn)
+
+	session, err := wrapper.NewSession()
+	if err != nil {
+		t.Skip("no WASM session:", err)
+	}
+	t.Cleanup(session.Close)
+
+	return session
+}
+
+func TestFormatMessage_wasm(t *testing.T) {
+	session := wasmSession(t)
+
+	for _, tt := range formatTestMessages() {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := session.FormatMessage(tt.message, tt.chunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := FormatMessage(tt.message, tt.chunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("FormatMessage() = %s, want %s", got, want)
+			}
+
+			// the hash is the same as the one of the WASM formatted message
+			wantHash, err := session.HashMessage(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hash, err := session.HashMessage(got)
+			if err != nil || !bytes.Equal(hash, wantHash) {
+				t.Errorf("HashMessage() = %x, %v, want %x", hash, err, wantHash)
+			}
+
+			recovered, err := session.RecoverMessage(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nativeRecovered, err := RecoverMessage(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(nativeRecovered, recovered) {
+				t.Errorf("RecoverMessage() = %x, want %x", nativeRecovered, recovered)
+			}
+		})
+	}
+}
+
+func TestRecoverMessage_wasm(t *testing.T) {
+	session := wasmSession(t)
+
+	for _, tt := range recoverTestMessages {
+		t.Run(tt.name, func(t *testing.T) {
+			want, wantErr := session.RecoverMessage([]byte(tt.formattedMessage))
+
+			got, err := RecoverMessage([]byte(tt.formattedMessage))
+			if (err != nil) != (wantErr != nil) {
+				t.Fatalf("RecoverMessage() error = %v, WASM error = %v", err, wantErr)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("RecoverMessage() = %x, want %x", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package aleo
+
+import (
+	"sync"
+	"time"
+)
+
+// OperationStats describes the calls of a session operation
+type OperationStats struct {
+	Calls  uint64 `json:"calls"`
+	Errors uint64 `json:"errors"`
+	// the average and the maximum duration of a call in milliseconds
+	AverageMs float64 `json:"averageMs"`
+	MaxMs     float64 `json:"maxMs"`
+}
+
+// Stats describes the usage of a wrapper
+type Stats struct {
+	Sessions       uint64         `json:"sessions"`
+	FormatMessage  OperationStats `json:"formatMessage"`
+	HashMessage    OperationStats `json:"hashMessage"`
+	RecoverMessage OperationStats `json:"recoverMessage"`
+}
+
+// StatsProvider is implemented by wrappers that can report their usage
+type StatsProvider interface {
+	Stats() Stats
+}
+
+// the totals of an operation, OperationStats is derived from them
+type operationTotals struct {
+	calls  uint64
+	errors uint64
+	total  time.Duration
+	max    time.Duration
+}
+
+func (o *operationTotals) add(duration time.Duration, err error) {
+	o.calls++
+	if err != nil {
+		o.errors++
+	}
+
+	o.total += duration
+	if duration > o.max {
+		o.max = duration
+	}
+}
+
+func (o *operationTotals) stats() OperationStats {
+	stats := OperationStats{
+		Calls:  o.calls,
+		Errors: o.errors,
+		MaxMs:  float64(o.max) / float64(time.Millisecond),
+	}
+	if o.calls > 0 {
+		stats.AverageMs = float64(o.total) / float64(o.calls) / float64(time.Millisecond)
+	}
+
+	return stats
+}
+
+// MeteredWrapper counts the sessions of a wrapper and the calls of their operations, and measures the latency of the
+// calls. It is safe for concurrent use.
+type MeteredWrapper struct {
+	wrapper Wrapper
+	now     func() time.Time
+
+	mu             sync.Mutex
+	sessions       uint64
+	formatMessage  operationTotals
+	hashMessage    operationTotals
+	recoverMessage operationTotals
+}
+
+func NewMeteredWrapper(wrapper Wrapper) *MeteredWrapper {
+	return &MeteredWrapper{
+		wrapper: wrapper,
+		now:     time.Now,
+	}
+}
+
+func (w *MeteredWrapper) NewSession() (Session, error) {
+	session, err := w.wrapper.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	w.sessions++
+	w.mu.Unlock()
+
+	return &meteredSession{session: session, wrapper: w}, nil
+}
+
+func (w *MeteredWrapper) Close() {
+	w.wrapper.Close()
+}
+
+// records a call of an operation that started at start
+func (w *MeteredWrapper) record(operation *operationTotals, start time.Time, err error) {
+	duration := w.now().Sub(start)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	operation.add(duration, err)
+}
+
+func (w *MeteredWrapper) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return Stats{
+		Sessions:       w.sessions,
+		FormatMessage:  w.formatMessage.stats(),
+		HashMessage:    w.hashMessage.stats(),
+		RecoverMessage: w.recoverMessage.stats(),
+	}
+}
+
+type meteredSession struct {
+	session Session
+	wrapper *MeteredWrapper
+}
+
+func (s *meteredSession) FormatMessage(message []byte, targetChunks int) ([]byte, error) {
+	start := s.wrapper.now()
+	formatted, err := s.session.FormatMessage(message, targetChunks)
+	s.wrapper.record(&s.wrapper.formatMessage, start, err)
+
+	return formatted, err
+}
+
+func (s *meteredSession) HashMessage(message []byte) ([]byte, error) {
+	start := s.wrapper.now()
+	hash, err := s.session.HashMessage(message)
+	s.wrapper.record(&s.wrapper.hashMessage, start, err)
+
+	return hash, err
+}
+
+func (s *meteredSession) RecoverMessage(formattedMessage []byte) ([]byte, error) {
+	start := s.wrapper.now()
+	message, err := s.session.RecoverMessage(formattedMessage)
+	s.wrapper.record(&s.wrapper.recoverMessage, start, err)
+
+	return message, err
+}
+
+func (s *meteredSession) Close() {
+	s.session.Close()
+}
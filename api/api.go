@@ -2,16 +2,25 @@ package api
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
 	"github.com/venture23-aleo/oracle-verification-backend/api/handlers"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/auditor"
+	"github.com/venture23-aleo/oracle-verification-backend/cache"
 	"github.com/venture23-aleo/oracle-verification-backend/config"
-
-	aleo_wrapper "github.com/venture23-aleo/aleo-utils-go"
+	"github.com/venture23-aleo/oracle-verification-backend/livecheck"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+	"github.com/venture23-aleo/oracle-verification-backend/nonce"
+	"github.com/venture23-aleo/oracle-verification-backend/replay"
 
 	"github.com/rs/cors"
 )
 
-func CreateApi(aleoWrapper aleo_wrapper.Wrapper, conf *config.Configuration) http.Handler {
+// CreateApi creates the HTTP API. policies, nodeClients and liveChecks have the verification policies, the Aleo node API client and the live checker
+// of every profile in conf, replays and audit are optional.
+func CreateApi(aleoWrapper aleo.Wrapper, conf *config.Configuration, policies map[string]tee.Policies, nodeClients map[string]*node.Client, liveChecks map[string]*livecheck.Checker, replays *replay.Store, audit *auditor.Auditor) http.Handler {
 	if conf == nil {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "server configuration missing", http.StatusInternalServerError)
@@ -20,6 +29,7 @@ func CreateApi(aleoWrapper aleo_wrapper.Wrapper, conf *config.Configuration) htt
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{http.MethodPost},
+		AllowedHeaders: []string{"Accept", "Content-Type", "X-Requested-With", handlers.PROFILE_HEADER},
 	})
 
 	addMiddleware := func(h http.Handler) http.Handler {
@@ -28,16 +38,52 @@ func CreateApi(aleoWrapper aleo_wrapper.Wrapper, conf *config.Configuration) htt
 
 	mux := http.NewServeMux()
 
-	// Avoid out-of-range panics if fewer than 3 PCR values are configured
-	var targetPcrs [3]string
-	for i := 0; i < 3 && i < len(conf.PcrValuesTarget); i++ {
-		targetPcrs[i] = conf.PcrValuesTarget[i]
+	var nonces *nonce.Store
+	if conf.Nonce.Enabled {
+		nonces = nonce.NewStore(time.Duration(conf.Nonce.TtlSeconds) * time.Second)
+		mux.Handle("/nonce", addMiddleware(handlers.CreateNonceHandler(nonces, conf.Nonce.ClientIpHeader)))
 	}
 
-	mux.Handle("/info", addMiddleware(handlers.CreateInfoHandler(conf.UniqueIdTarget, targetPcrs, conf.LiveCheck.ContractName)))
-	mux.Handle("/verify", addMiddleware(handlers.CreateVerifyHandler(aleoWrapper, conf.UniqueIdTarget, targetPcrs)))
-	mux.Handle("/decode", addMiddleware(handlers.CreateDecodeHandler(aleoWrapper)))
+	// the wrapper usage is shared by all profiles
+	aleoStats, _ := aleoWrapper.(aleo.StatsProvider)
+
+	infoHandlers := make(map[string]http.Handler)
+	verifyHandlers := make(map[string]http.Handler)
+	verifyTransactionHandlers := make(map[string]http.Handler)
+
+	for _, profile := range conf.AllProfiles() {
+		profilePolicies := policies[profile.Name]
+
+		// every profile has its own verification cache, as the profiles have different target measurements
+		var verificationCache *handlers.VerificationCache
+		var cacheStats cache.StatsProvider
+		if conf.VerificationCache.Enabled {
+			verificationCache = handlers.NewVerificationCache(conf.VerificationCache.Size, time.Duration(conf.VerificationCache.TtlSeconds)*time.Second)
+			cacheStats = verificationCache
+		}
+
+		infoHandlers[profile.Name] = handlers.CreateInfoHandler(profile.Name, profilePolicies, profile.LiveCheck.ContractName, liveChecks[profile.Name], cacheStats, aleoStats)
+		verifyHandlers[profile.Name] = handlers.CreateVerifyHandler(aleoWrapper, profilePolicies, nonces, conf.Nonce.Required, replays, conf.Replay.Policy, verificationCache)
+		verifyTransactionHandlers[profile.Name] = handlers.CreateVerifyTransactionHandler(aleoWrapper, nodeClients[profile.Name], profile.LiveCheck.ContractName, profilePolicies)
+
+		// the profile can be selected with a path prefix
+		prefix := "/" + profile.Name
+		mux.Handle(prefix+"/info", addMiddleware(infoHandlers[profile.Name]))
+		mux.Handle(prefix+"/verify", addMiddleware(verifyHandlers[profile.Name]))
+		mux.Handle(prefix+"/verify_transaction", addMiddleware(verifyTransactionHandlers[profile.Name]))
+	}
+
+	// or with a query parameter or a header
+	mux.Handle("/info", addMiddleware(handlers.CreateProfileSelector(infoHandlers, config.DEFAULT_PROFILE_NAME)))
+	mux.Handle("/verify", addMiddleware(handlers.CreateProfileSelector(verifyHandlers, config.DEFAULT_PROFILE_NAME)))
+	mux.Handle("/verify_transaction", addMiddleware(handlers.CreateProfileSelector(verifyTransactionHandlers, config.DEFAULT_PROFILE_NAME)))
+	mux.Handle("/readyz", addMiddleware(handlers.CreateReadinessHandler(liveChecks)))
+	if audit != nil {
+		mux.Handle("/audit", addMiddleware(handlers.CreateAuditHandler(audit)))
+	}
+	mux.Handle("/decode", addMiddleware(handlers.CreateDecodeHandler()))
 	mux.Handle("/decode_quote", addMiddleware(handlers.DecodeQuoteHandler()))
+	mux.Handle("/decode_nitro", addMiddleware(handlers.DecodeNitroHandler()))
 
 	return mux
 }
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/venture23-aleo/oracle-verification-backend/auditor"
+)
+
+const defaultAuditLimit = 50
+
+type AuditResponse struct {
+	Status  auditor.Status   `json:"status"`
+	Records []auditor.Record `json:"records"`
+}
+
+// CreateAuditHandler creates the handler for browsing the recent on-chain audits
+func CreateAuditHandler(audit *auditor.Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		log := GetContextLogger(req.Context())
+
+		query := req.URL.Query()
+
+		limit := defaultAuditLimit
+		if query.Has("limit") {
+			var err error
+			limit, err = strconv.Atoi(query.Get("limit"))
+			if err != nil || limit < 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		failedOnly := false
+		if query.Has("failed") {
+			var err error
+			failedOnly, err = strconv.ParseBool(query.Get("failed"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		responseBody, err := json.Marshal(&AuditResponse{
+			Status:  audit.Status(),
+			Records: audit.Store().Recent(limit, failedOnly),
+		})
+		if err != nil {
+			log.Println("failed to marshal response:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(responseBody)
+	}
+}
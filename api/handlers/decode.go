@@ -3,23 +3,23 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"strings"
 
-	aleo_wrapper "github.com/venture23-aleo/aleo-utils-go"
-
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
 	"github.com/venture23-aleo/oracle-verification-backend/attestation"
 )
 
 type DecodeProofDataRequest struct {
-	UserData string `json:"userData"`
+	UserData  string `json:"userData"`
+	PriceFeed bool   `json:"priceFeed,omitempty"`
 }
 
 
 type DecodedData interface {
 	*attestation.DecodedProofData |
-	[]*attestation.DecodedProofData
+	[]*attestation.DecodedProofData |
+	[]*attestation.DecodedPriceFeedData
 }
 
 type DecodeProofDataResponse[T DecodedData] struct {
@@ -53,7 +53,9 @@ func respondDecode[T DecodedData](ctx context.Context, w http.ResponseWriter, de
 	w.Write(msg)
 }
 
-func CreateDecodeHandler(aleo aleo_wrapper.Wrapper) http.HandlerFunc {
+// CreateDecodeHandler creates the handler that decodes the proof data of a report. The data is recovered in Go, without
+// an Aleo session.
+func CreateDecodeHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -87,48 +89,29 @@ func CreateDecodeHandler(aleo aleo_wrapper.Wrapper) http.HandlerFunc {
 			return
 		}
 
-		aleoSession, err := aleo.NewSession()
-		if err != nil {
-			log.Println("error creating new aleo session:", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		defer aleoSession.Close()
-
-		recoveredMessage, err := aleoSession.RecoverMessage([]byte(request.UserData))
+		recoveredMessage, err := aleo.RecoverMessage([]byte(request.UserData))
 		if err != nil {
 			log.Println("error recovering formatted message:", err)
 			respondDecode[*attestation.DecodedProofData](req.Context(), w, nil, err)
 			return
 		}
 
-		//split in the range of 512 bytes
-		recoveredMessages := make([][]byte, 0)
-		for i := 0; i < len(recoveredMessage); i += 512 {
-			end := i + 512
-			if end > len(recoveredMessage) {
-				end = len(recoveredMessage)
-			}
-			recoveredMessages = append(recoveredMessages, recoveredMessage[i:end])
-		}
-		
-		decodedData := make([]*attestation.DecodedProofData, 0)
-
-		for _, message := range recoveredMessages {
-			if message[0] == 0 {
-				break
-			}
-			decodedDataItem, err := attestation.DecodeProofData(message)
+		if request.PriceFeed {
+			priceFeedData, err := attestation.DecodePriceFeedDataChunks(recoveredMessage)
 			if err != nil {
-				log.Println("error decoding proof data:", err)
-				respondDecode[*attestation.DecodedProofData](req.Context(), w, nil, err)
+				log.Println("error decoding price feed data:", err)
+				respondDecode[[]*attestation.DecodedPriceFeedData](req.Context(), w, nil, err)
 				return
 			}
-			decodedData = append(decodedData, decodedDataItem)
+
+			respondDecode[[]*attestation.DecodedPriceFeedData](req.Context(), w, priceFeedData, nil)
+			return
 		}
 
-		if len(decodedData) == 0 {
-			respondDecode[*attestation.DecodedProofData](req.Context(), w, nil, errors.New("no decoded data"))
+		decodedData, err := attestation.DecodeProofDataChunks(recoveredMessage)
+		if err != nil {
+			log.Println("error decoding proof data:", err)
+			respondDecode[*attestation.DecodedProofData](req.Context(), w, nil, err)
 			return
 		}
 
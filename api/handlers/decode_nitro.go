@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+)
+
+type DecodeNitroRequest struct {
+	Report string `json:"report"`
+	// Verify enables the signature and certificate chain verification
+	Verify bool `json:"verify"`
+}
+
+type NitroVerification struct {
+	Valid      bool   `json:"valid"`
+	FailedStep string `json:"failedStep,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type DecodeNitroResponse struct {
+	Document     *nitro.Document    `json:"document"`
+	Verification *NitroVerification `json:"verification,omitempty"`
+}
+
+func DecodeNitroHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		defer req.Body.Close()
+
+		log := GetContextLogger(req.Context())
+
+		body, ok := readRequestBody(w, req)
+		if !ok {
+			return
+		}
+
+		var payload DecodeNitroRequest
+		if err := json.Unmarshal(body, &payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if payload.Report == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		reportBytes, err := base64.StdEncoding.DecodeString(payload.Report)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		document, err := nitro.Decode(reportBytes)
+		if err != nil {
+			log.Println("failed to decode Nitro attestation document:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		response := &DecodeNitroResponse{
+			Document: document,
+		}
+
+		if payload.Verify {
+			response.Verification = &NitroVerification{Valid: true}
+
+			if _, err := nitro.VerifyDocument(reportBytes); err != nil {
+				response.Verification = &NitroVerification{
+					FailedStep: nitro.FailedStep(err),
+					Error:      err.Error(),
+				}
+			}
+		}
+
+		responseBody, err := json.Marshal(response)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		w.Write(responseBody)
+	}
+}
@@ -3,13 +3,84 @@ package handlers
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 
-	"github.com/edgelesssys/ego/eclient"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/collateral"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+
+	"github.com/edgelesssys/ego/attestation"
+)
+
+// Where the collateral for verifying a quote comes from, see SgxVerification
+const (
+	COLLATERAL_SOURCE_QUOTE_PROVIDER = "quote_provider"
+	COLLATERAL_SOURCE_CACHE          = "cache"
+	COLLATERAL_SOURCE_REQUEST        = "request"
 )
 
 type DecodeQuoteRequest struct {
 	Quote string `json:"quote"`
+	// verify the quote offline with this collateral instead of the configured source
+	Collateral *collateral.Collateral `json:"collateral,omitempty"`
+}
+
+// SgxVerification is the result of verifying a quote. The TCB status is known if the quote signature was verified,
+// even if the verification failed because of the TCB level.
+type SgxVerification struct {
+	Valid              bool     `json:"valid"`
+	Collateral         string   `json:"collateral"`
+	Error              string   `json:"error,omitempty"`
+	TcbStatus          string   `json:"tcbStatus,omitempty"`
+	TcbAdvisories      []string `json:"tcbAdvisories,omitempty"`
+	TcbAdvisoriesError string   `json:"tcbAdvisoriesError,omitempty"`
+	// the collateral is past its next update, the quote is only valid with it if sgxCollateral.allowExpired is set
+	Expired bool `json:"expired,omitempty"`
+}
+
+type DecodeQuoteResponse struct {
+	Quote        *quote.Quote     `json:"quote"`
+	Verification *SgxVerification `json:"verification"`
+}
+
+func verifyQuote(reportBytes []byte, bundle *collateral.Collateral) *SgxVerification {
+	var report attestation.Report
+	var expired bool
+	var err error
+	var source string
+
+	switch {
+	case bundle != nil:
+		report, expired, err = sgx.VerifyRemoteReportWithCollateral(reportBytes, bundle)
+		source = COLLATERAL_SOURCE_REQUEST
+	case sgx.VerifiesOffline():
+		report, expired, err = sgx.VerifyRemoteReportWithCollateral(reportBytes, nil)
+		source = COLLATERAL_SOURCE_CACHE
+	default:
+		report, err = sgx.VerifyRemoteReport(reportBytes)
+		source = COLLATERAL_SOURCE_QUOTE_PROVIDER
+	}
+
+	verification := &SgxVerification{
+		Valid:      err == nil,
+		Collateral: source,
+		Expired:    expired,
+	}
+
+	if err != nil {
+		verification.Error = err.Error()
+	}
+
+	if err == nil || errors.Is(err, attestation.ErrTCBLevelInvalid) {
+		verification.TcbStatus = report.TCBStatus.String()
+		verification.TcbAdvisories = report.TCBAdvisories
+		if report.TCBAdvisoriesErr != nil {
+			verification.TcbAdvisoriesError = report.TCBAdvisoriesErr.Error()
+		}
+	}
+
+	return verification
 }
 
 func DecodeQuoteHandler() http.HandlerFunc {
@@ -21,6 +92,8 @@ func DecodeQuoteHandler() http.HandlerFunc {
 
 		defer req.Body.Close()
 
+		log := GetContextLogger(req.Context())
+
 		body, ok := readRequestBody(w, req)
 		if !ok {
 			return
@@ -43,13 +116,17 @@ func DecodeQuoteHandler() http.HandlerFunc {
 			return
 		}
 
-		report, err := eclient.VerifyRemoteReport(reportBytes)
+		parsedQuote, err := quote.Parse(reportBytes)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			log.Println("failed to parse SGX quote:", err)
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		decodedQuote, err := json.Marshal(report)
+		decodedQuote, err := json.Marshal(&DecodeQuoteResponse{
+			Quote:        parsedQuote,
+			Verification: verifyQuote(reportBytes, payload.Collateral),
+		})
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
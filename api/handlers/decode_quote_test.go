@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+func Test_DecodeQuoteHandler(t *testing.T) {
+	var reportBody quote.ReportBody
+	reportBody.Attributes.Flags = quote.ATTRIBUTE_DEBUG
+	copy(reportBody.MrEnclave[:], bytes.Repeat([]byte{0xaa}, 32))
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, quote.Header{Version: quote.QUOTE_VERSION_3, AttestationKeyType: quote.ATTESTATION_KEY_TYPE_ECDSA_P256})
+	binary.Write(buf, binary.LittleEndian, reportBody)
+	binary.Write(buf, binary.LittleEndian, uint32(0))
+	encodedQuote := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	tests := []struct {
+		name           string
+		body           string
+		wantStatus     int
+		wantCollateral string
+	}{
+		{name: "unverifiable quote", body: `{"quote": "` + encodedQuote + `"}`, wantStatus: http.StatusOK, wantCollateral: COLLATERAL_SOURCE_QUOTE_PROVIDER},
+		{name: "supplied collateral", body: `{"quote": "` + encodedQuote + `", "collateral": {"rootCa": ""}}`, wantStatus: http.StatusOK, wantCollateral: COLLATERAL_SOURCE_REQUEST},
+		{name: "malformed quote", body: `{"quote": "` + base64.StdEncoding.EncodeToString([]byte("quote")) + `"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing quote", body: `{}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			DecodeQuoteHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/decode_quote", strings.NewReader(tt.body)))
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if recorder.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Quote struct {
+					ReportBody struct {
+						MrEnclave  string `json:"mrEnclave"`
+						Attributes struct {
+							Debug bool `json:"debug"`
+						} `json:"attributes"`
+					} `json:"reportBody"`
+				} `json:"quote"`
+				Verification SgxVerification `json:"verification"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Quote.ReportBody.MrEnclave != strings.Repeat("aa", 32) || !response.Quote.ReportBody.Attributes.Debug {
+				t.Errorf("quote = %+v", response.Quote)
+			}
+			// the quote has no signature data, it can't be verified
+			if response.Verification.Valid || response.Verification.Error == "" || response.Verification.Collateral != tt.wantCollateral {
+				t.Errorf("verification = %+v", response.Verification)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation"
+	"github.com/venture23-aleo/oracle-verification-backend/testutil"
+)
+
+func Test_decodeHandler(t *testing.T) {
+	resp := testutil.Response(attestation.TEE_TYPE_NITRO, "12.34")
+
+	proofData, err := attestation.ProofData(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userData, err := aleo.FormatMessage(proofData, attestation.ALEO_STRUCT_REPORT_DATA_SIZE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		userData   string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "proof data", userData: string(userData), wantStatus: http.StatusOK},
+		{name: "not formatted", userData: "12.34", wantStatus: http.StatusOK, wantErr: true},
+		{name: "no proof data", userData: "{ c0: { f0: 0u128 } }", wantStatus: http.StatusOK, wantErr: true},
+		{name: "no user data", userData: "", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(&DecodeProofDataRequest{UserData: tt.userData})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/decode", strings.NewReader(string(body)))
+			request.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			CreateDecodeHandler().ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response DecodeProofDataResponse[*attestation.DecodedProofData]
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Success == tt.wantErr {
+				t.Fatalf("response = %s, wantErr %v", recorder.Body.String(), tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			decoded := response.DecodedData
+			if decoded.AttestationData != resp.AttestationData || decoded.Url != resp.AttestationRequest.Url || decoded.Timestamp != resp.Timestamp || decoded.ResponseStatusCode != resp.ResponseStatusCode {
+				t.Errorf("decoded data = %+v, want %+v", decoded, resp)
+			}
+		})
+	}
+}
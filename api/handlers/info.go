@@ -1,50 +1,53 @@
 package handlers
 
 import (
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
 	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
-	"github.com/venture23-aleo/oracle-verification-backend/u128"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/cache"
+	"github.com/venture23-aleo/oracle-verification-backend/livecheck"
 )
 
 type infoHandler struct {
-	uniqueId         string
-	pcrValues        [3]string
+	profile          string
+	policies         tee.Policies
 	liveCheckProgram string
+	liveCheck        *livecheck.Checker
 	startTime        time.Time
+	cacheStats       cache.StatsProvider
+	aleoStats        aleo.StatsProvider
 }
 
-func CreateInfoHandler(uniqueId string, pcrValues [3]string, liveCheckProgram string) http.Handler {
+// CreateInfoHandler creates the handler for the backend information of a profile. cacheStats and aleoStats are optional.
+func CreateInfoHandler(profile string, policies tee.Policies, liveCheckProgram string, liveCheck *livecheck.Checker, cacheStats cache.StatsProvider, aleoStats aleo.StatsProvider) http.Handler {
 	return &infoHandler{
-		uniqueId:         uniqueId,
-		pcrValues:        pcrValues,
+		profile:          profile,
+		policies:         policies,
 		liveCheckProgram: liveCheckProgram,
+		liveCheck:        liveCheck,
 		startTime:        time.Now().UTC(),
+		cacheStats:       cacheStats,
+		aleoStats:        aleoStats,
 	}
 }
 
-type uniqueIdInfo struct {
-	Hex    string `json:"hexEncoded"`
-	Base64 string `json:"base64Encoded"`
-	Aleo   string `json:"aleoEncoded"`
-}
-
-type pcrValuesInfo struct {
-	Hex    [3]string `json:"hexEncoded"`
-	Base64 [3]string `json:"base64Encoded"`
-	Aleo   string    `json:"aleoEncoded"`
-}
-
 type InfoResponse struct {
-	TargetUniqueId   uniqueIdInfo  `json:"targetUniqueId"`
-	TargetPcrValues  pcrValuesInfo `json:"targetPcrValues"`
-	LiveCheckProgram string        `json:"liveCheckProgram"`
-	StartTime        string        `json:"startTimeUTC"`
+	Profile string `json:"profile"`
+	// the target measurements of every registered TEE by report type
+	Targets map[string]any `json:"targets"`
+	// the SGX and Nitro targets, kept for the clients that don't read targets
+	TargetUniqueId    any              `json:"targetUniqueId"`
+	TargetPcrValues   any              `json:"targetPcrValues"`
+	LiveCheckProgram  string           `json:"liveCheckProgram"`
+	LiveCheck         livecheck.Status `json:"liveCheck"`
+	StartTime         string           `json:"startTimeUTC"`
+	VerificationCache *cache.Stats     `json:"verificationCache,omitempty"`
+	Aleo              *aleo.Stats      `json:"aleo,omitempty"`
 }
 
 func (h *infoHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -56,71 +59,37 @@ func (h *infoHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	log := GetContextLogger(req.Context())
 
 	response := new(InfoResponse)
+	response.Targets = make(map[string]any)
 
-	uniqueIdBytes, err := hex.DecodeString(h.uniqueId)
-	if err != nil {
-		log.Println("failed to hex-decode unique ID:", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	if len(uniqueIdBytes) < 32 {
-		log.Println("unique ID is shorter than 32 bytes")
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	uniqueIdAleo1, err := u128.SliceToU128(uniqueIdBytes[0:16])
-	if err != nil {
-		log.Println("failed to parse unique ID chunk 1:", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	uniqueIdAleo2, err := u128.SliceToU128(uniqueIdBytes[16:32])
-	if err != nil {
-		log.Println("failed to parse unique ID chunk 2:", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	response.TargetUniqueId = uniqueIdInfo{
-		Hex:    h.uniqueId,
-		Base64: base64.StdEncoding.EncodeToString(uniqueIdBytes),
-		Aleo:   fmt.Sprintf("{ chunk_1: %su128, chunk_2: %su128 }", uniqueIdAleo1.String(), uniqueIdAleo2.String()),
-	}
-
-	var pcrBytes [3][48]byte
-
-	for idx, pcr := range h.pcrValues {
-		if pcr == "" {
-			continue
-		}
-		buf, err := hex.DecodeString(pcr)
+	for _, verifier := range tee.All() {
+		target, err := verifier.DescribePolicy(h.policies[verifier.Type()])
 		if err != nil {
-			log.Println("failed to hex-decode PCR value:", err)
+			log.Printf("failed to describe %s target: %s\n", verifier.Type(), err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		if len(buf) < 48 {
-			log.Println("PCR value shorter than 48 bytes")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		copy(pcrBytes[idx][:], buf[:48])
-	}
 
-	response.TargetPcrValues = pcrValuesInfo{
-		Hex: h.pcrValues,
-		Base64: [3]string{
-			base64.StdEncoding.EncodeToString(pcrBytes[0][:]),
-			base64.StdEncoding.EncodeToString(pcrBytes[1][:]),
-			base64.StdEncoding.EncodeToString(pcrBytes[2][:]),
-		},
-		Aleo: nitro.FormatPcrValues(pcrBytes),
+		response.Targets[verifier.Type()] = target
 	}
 
+	response.TargetUniqueId = response.Targets[sgx.TEE_TYPE]
+	response.TargetPcrValues = response.Targets[nitro.TEE_TYPE]
+
+	response.Profile = h.profile
 	response.LiveCheckProgram = h.liveCheckProgram
+	response.LiveCheck = h.liveCheck.Status()
 	response.StartTime = h.startTime.Format(time.DateTime)
 
+	if h.cacheStats != nil {
+		stats := h.cacheStats.Stats()
+		response.VerificationCache = &stats
+	}
+
+	if h.aleoStats != nil {
+		stats := h.aleoStats.Stats()
+		response.Aleo = &stats
+	}
+
 	responseBody, err := json.Marshal(response)
 	if err != nil {
 		log.Println("failed to marshal response:", err)
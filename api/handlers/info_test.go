@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+	"github.com/venture23-aleo/oracle-verification-backend/livecheck"
+)
+
+func Test_infoHandler(t *testing.T) {
+	profile := config.Profile{
+		Name:            "test",
+		UniqueIdTarget:  strings.Repeat("ab", 32),
+		PcrValuesTarget: []string{strings.Repeat("01", 48), strings.Repeat("02", 48), strings.Repeat("03", 48)},
+		LiveCheck:       config.LiveCheck{Skip: true},
+	}
+
+	tests := []struct {
+		name       string
+		profile    config.Profile
+		wantStatus int
+	}{
+		{name: "valid targets", profile: profile, wantStatus: http.StatusOK},
+		{name: "short unique ID", profile: config.Profile{Name: "test", UniqueIdTarget: "abcd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := tee.ProfilePolicies(tt.profile.PolicyBlocks())
+			if (err != nil) != (tt.wantStatus == 0) {
+				t.Fatalf("ProfilePolicies() error = %v", err)
+			}
+			if err != nil {
+				return
+			}
+
+			handler := CreateInfoHandler(tt.profile.Name, policies, "", livecheck.New(tt.profile, nil), nil, nil)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/info", nil))
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			response := new(struct {
+				Targets         map[string]json.RawMessage `json:"targets"`
+				TargetUniqueId  json.RawMessage            `json:"targetUniqueId"`
+				TargetPcrValues json.RawMessage            `json:"targetPcrValues"`
+			})
+			if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, verifier := range tee.All() {
+				if _, ok := response.Targets[verifier.Type()]; !ok {
+					t.Errorf("targets don't have the %s target", verifier.Type())
+				}
+			}
+			if string(response.TargetUniqueId) != string(response.Targets["sgx"]) || string(response.TargetPcrValues) != string(response.Targets["nitro"]) {
+				t.Errorf("legacy targets don't match, response = %s", recorder.Body.String())
+			}
+		})
+	}
+}
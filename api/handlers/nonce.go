@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/venture23-aleo/oracle-verification-backend/nonce"
+)
+
+type NonceResponse struct {
+	Nonce     string `json:"nonce"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// the client the outstanding nonces of a request are limited by. Behind a proxy, it's the last address in the
+// clientIpHeader, the one appended by the proxy, as the addresses before it are sent by the client.
+func nonceClient(req *http.Request, clientIpHeader string) string {
+	if clientIpHeader != "" {
+		if values := req.Header.Values(clientIpHeader); len(values) != 0 {
+			addresses := strings.Split(values[len(values)-1], ",")
+			if client := strings.TrimSpace(addresses[len(addresses)-1]); client != "" {
+				return client
+			}
+		}
+	}
+
+	client, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		client = req.RemoteAddr
+	}
+
+	return client
+}
+
+// CreateNonceHandler creates the /nonce handler. If clientIpHeader is set, then the outstanding nonces are limited by
+// the client address in the header instead of the peer address, see nonceClient.
+func CreateNonceHandler(nonces *nonce.Store, clientIpHeader string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		log := GetContextLogger(req.Context())
+
+		// the outstanding nonces are limited by the client address
+		issuedNonce, expiresAt, err := nonces.Issue(nonceClient(req, clientIpHeader))
+		if err == nonce.ErrTooManyClientNonces {
+			log.Println("failed to issue nonce:", err)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if err == nonce.ErrTooManyNonces {
+			log.Println("failed to issue nonce:", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			log.Println("failed to issue nonce:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		responseBody, err := json.Marshal(&NonceResponse{
+			Nonce:     issuedNonce,
+			ExpiresAt: expiresAt.Unix(),
+		})
+		if err != nil {
+			log.Println("failed to marshal response:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
+		w.Write(responseBody)
+	}
+}
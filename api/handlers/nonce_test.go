@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_nonceClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		clientIpHeader string
+		headers        []string
+		want           string
+	}{
+		{name: "peer address", want: "192.0.2.1"},
+		{name: "header not configured", headers: []string{"198.51.100.1"}, want: "192.0.2.1"},
+		{name: "no header", clientIpHeader: "X-Forwarded-For", want: "192.0.2.1"},
+		{name: "empty header", clientIpHeader: "X-Forwarded-For", headers: []string{""}, want: "192.0.2.1"},
+		{name: "single address", clientIpHeader: "X-Forwarded-For", headers: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "appended address", clientIpHeader: "X-Forwarded-For", headers: []string{"203.0.113.1, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "repeated header", clientIpHeader: "X-Forwarded-For", headers: []string{"203.0.113.1", "198.51.100.1"}, want: "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/nonce", nil)
+			request.RemoteAddr = "192.0.2.1:1234"
+			for _, value := range tt.headers {
+				request.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := nonceClient(request, tt.clientIpHeader); got != tt.want {
+				t.Errorf("nonceClient() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+const (
+	// query parameter for selecting a profile
+	PROFILE_QUERY_PARAM = "profile"
+	// header for selecting a profile
+	PROFILE_HEADER = "X-Oracle-Profile"
+)
+
+type profileSelector struct {
+	profileHandlers map[string]http.Handler
+	defaultProfile  string
+}
+
+// CreateProfileSelector creates a handler that passes the request to the handler of the profile selected with
+// the profile query parameter or header, or to the handler of the default profile if neither is set
+func CreateProfileSelector(profileHandlers map[string]http.Handler, defaultProfile string) http.Handler {
+	return &profileSelector{
+		profileHandlers: profileHandlers,
+		defaultProfile:  defaultProfile,
+	}
+}
+
+func (ps *profileSelector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	log := GetContextLogger(req.Context())
+
+	fromQuery := req.URL.Query().Get(PROFILE_QUERY_PARAM)
+	fromHeader := req.Header.Get(PROFILE_HEADER)
+
+	if fromQuery != "" && fromHeader != "" && fromQuery != fromHeader {
+		log.Printf("conflicting profiles selected: query=%s, header=%s", fromQuery, fromHeader)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	profile := ps.defaultProfile
+	if fromQuery != "" {
+		profile = fromQuery
+	} else if fromHeader != "" {
+		profile = fromHeader
+	}
+
+	handler, ok := ps.profileHandlers[profile]
+	if !ok {
+		log.Printf("unknown profile %q", profile)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	handler.ServeHTTP(w, req)
+}
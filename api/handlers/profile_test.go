@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_profileSelector(t *testing.T) {
+	profileHandler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(name))
+		})
+	}
+
+	selector := CreateProfileSelector(map[string]http.Handler{
+		"default": profileHandler("default"),
+		"mainnet": profileHandler("mainnet"),
+	}, "default")
+
+	tests := []struct {
+		name       string
+		query      string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "default", wantStatus: http.StatusOK, wantBody: "default"},
+		{name: "query", query: "mainnet", wantStatus: http.StatusOK, wantBody: "mainnet"},
+		{name: "header", header: "mainnet", wantStatus: http.StatusOK, wantBody: "mainnet"},
+		{name: "same query and header", query: "mainnet", header: "mainnet", wantStatus: http.StatusOK, wantBody: "mainnet"},
+		{name: "conflicting query and header", query: "mainnet", header: "default", wantStatus: http.StatusBadRequest},
+		{name: "unknown", query: "devnet", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/info", nil)
+			if tt.query != "" {
+				req.URL.RawQuery = PROFILE_QUERY_PARAM + "=" + tt.query
+			}
+			if tt.header != "" {
+				req.Header.Set(PROFILE_HEADER, tt.header)
+			}
+
+			recorder := httptest.NewRecorder()
+			selector.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && recorder.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", recorder.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/venture23-aleo/oracle-verification-backend/livecheck"
+)
+
+type readinessHandler struct {
+	liveChecks map[string]*livecheck.Checker
+}
+
+type ReadinessResponse struct {
+	Ready    bool                        `json:"ready"`
+	Profiles map[string]livecheck.Status `json:"profiles"`
+}
+
+// CreateReadinessHandler creates the readiness probe handler. The backend is ready when the enclave measurements of
+// every profile are confirmed by the live program or the live check is skipped.
+func CreateReadinessHandler(liveChecks map[string]*livecheck.Checker) http.Handler {
+	return &readinessHandler{
+		liveChecks: liveChecks,
+	}
+}
+
+func (h *readinessHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	log := GetContextLogger(req.Context())
+
+	response := &ReadinessResponse{
+		Ready:    true,
+		Profiles: make(map[string]livecheck.Status, len(h.liveChecks)),
+	}
+
+	for name, liveCheck := range h.liveChecks {
+		status := liveCheck.Status()
+		response.Profiles[name] = status
+		response.Ready = response.Ready && status.Ready()
+	}
+
+	responseBody, err := json.Marshal(response)
+	if err != nil {
+		log.Println("failed to marshal response:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !response.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_, err = w.Write(responseBody)
+	if err != nil {
+		log.Println("failed to write response:", err)
+	}
+}
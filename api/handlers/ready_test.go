@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+	"github.com/venture23-aleo/oracle-verification-backend/livecheck"
+)
+
+func Test_readinessHandler(t *testing.T) {
+	skipped := livecheck.New(config.Profile{Name: "skipped", LiveCheck: config.LiveCheck{Skip: true}}, nil)
+	unconfirmed := livecheck.New(config.Profile{Name: "unconfirmed"}, nil)
+
+	tests := []struct {
+		name       string
+		liveChecks map[string]*livecheck.Checker
+		wantStatus int
+		wantReady  bool
+	}{
+		{name: "ready", liveChecks: map[string]*livecheck.Checker{"skipped": skipped}, wantStatus: http.StatusOK, wantReady: true},
+		{name: "degraded", liveChecks: map[string]*livecheck.Checker{"skipped": skipped, "unconfirmed": unconfirmed}, wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			CreateReadinessHandler(tt.liveChecks).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+
+			response := new(ReadinessResponse)
+			if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Ready != tt.wantReady || len(response.Profiles) != len(tt.liveChecks) {
+				t.Errorf("response = %+v", response)
+			}
+		})
+	}
+}
@@ -2,25 +2,39 @@ package handlers
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
 	"github.com/venture23-aleo/oracle-verification-backend/attestation"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/cache"
 	"github.com/venture23-aleo/oracle-verification-backend/config"
-
-	aleo_wrapper "github.com/venture23-aleo/aleo-utils-go"
+	"github.com/venture23-aleo/oracle-verification-backend/nonce"
+	"github.com/venture23-aleo/oracle-verification-backend/replay"
 )
 
 type verifyHandler struct {
-	aleoWrapper     aleo_wrapper.Wrapper
-	targetUniqueId  string
-	targetPcrValues [3]string
+	aleoWrapper  aleo.Wrapper
+	policies     tee.Policies
+	nonces       *nonce.Store
+	requireNonce bool
+	replays      *replay.Store
+	replayPolicy string
+	cache        *VerificationCache
 }
 
+var ErrNonceNotIssued = errors.New("report nonce was not issued by this backend, expired, or was already used")
+
 type VerifyReportsRequest struct {
 	Reports []attestation.AttestationResponse `json:"reports"`
 }
@@ -29,18 +43,34 @@ type VerifyReportsRequestMultipleTokens struct {
 	Reports []attestation.AttestationResponseMultipleTokens `json:"reports"`
 }
 
+// ReportTokenResults holds the per-token diagnostics of a multiple tokens report
+type ReportTokenResults struct {
+	ReportIndex int                                    `json:"reportIndex"`
+	Tokens      []*attestation.TokenVerificationResult `json:"tokens"`
+}
+
+// PreviouslySeenReport describes a valid report that was already verified by this backend
+type PreviouslySeenReport struct {
+	ReportIndex int `json:"reportIndex"`
+	replay.Record
+}
+
 type VerifyReportsResponse struct {
-	Success      bool   `json:"success"`
-	ValidReports []int  `json:"validReports"`
-	ErrorMessage string `json:"errorMessage,omitempty"`
+	Success        bool                   `json:"success"`
+	ValidReports   []int                  `json:"validReports"`
+	TokenResults   []ReportTokenResults   `json:"tokenResults,omitempty"`
+	PreviouslySeen []PreviouslySeenReport `json:"previouslySeen,omitempty"`
+	ErrorMessage   string                 `json:"errorMessage,omitempty"`
 }
 
-func respondVerify(ctx context.Context, w http.ResponseWriter, validReports []int, errors string) {
+func respondVerify(ctx context.Context, w http.ResponseWriter, validReports []int, tokenResults []ReportTokenResults, previouslySeen []PreviouslySeenReport, errors string) {
 	log := GetContextLogger(ctx)
 
 	r := &VerifyReportsResponse{
-		ValidReports: validReports,
-		Success:      true,
+		ValidReports:   validReports,
+		TokenResults:   tokenResults,
+		PreviouslySeen: previouslySeen,
+		Success:        true,
 	}
 
 	if len(errors) != 0 {
@@ -59,11 +89,29 @@ func respondVerify(ctx context.Context, w http.ResponseWriter, validReports []in
 	w.Write(msg)
 }
 
-func CreateVerifyHandler(aleoWrapper aleo_wrapper.Wrapper, uniqueId string, pcrValues [3]string) http.Handler {
+// VerificationCache holds the verification results of the /verify handler by the digest of the normalized report
+type VerificationCache = cache.LRU[string, *verificationResult]
+
+// NewVerificationCache creates a cache of up to size results. The results expire after the TTL, since a report
+// that was valid may become invalid later, e.g. when a certificate expires or the collateral is revoked.
+// The target measurements are only loaded on start, so the cache doesn't outlive them.
+func NewVerificationCache(size int, ttl time.Duration) *VerificationCache {
+	return cache.NewLRU[string, *verificationResult](size, ttl)
+}
+
+// CreateVerifyHandler creates the report verification handler. If nonces is not nil, then the nonces bound to the reports
+// are consumed from the store, and if requireNonce is set, then reports without an issued nonce are rejected.
+// If replays is not nil, then the valid reports are recorded, and the reports that were seen before are handled according to replayPolicy.
+// If verificationCache is not nil, then the results of verifying the same reports are reused.
+func CreateVerifyHandler(aleoWrapper aleo.Wrapper, policies tee.Policies, nonces *nonce.Store, requireNonce bool, replays *replay.Store, replayPolicy string, verificationCache *VerificationCache) http.Handler {
 	return &verifyHandler{
-		aleoWrapper:     aleoWrapper,
-		targetUniqueId:  uniqueId,
-		targetPcrValues: pcrValues,
+		aleoWrapper:  aleoWrapper,
+		policies:     policies,
+		nonces:       nonces,
+		requireNonce: requireNonce,
+		replays:      replays,
+		replayPolicy: replayPolicy,
+		cache:        verificationCache,
 	}
 }
 
@@ -90,6 +138,93 @@ func readRequestBody(w http.ResponseWriter, req *http.Request) ([]byte, bool) {
 	return body, true
 }
 
+// verificationResult holds the outcome of verifying a report that doesn't depend on the state of this backend, so that it can be cached
+type verificationResult struct {
+	attestationReport string
+	userData          []byte
+	nonce             []byte
+	tokenResults      []*attestation.TokenVerificationResult
+}
+
+func (vh *verifyHandler) checkReportNonce(reportNonce []byte) error {
+	if vh.nonces == nil {
+		return nil
+	}
+
+	if reportNonce == nil {
+		if vh.requireNonce {
+			return attestation.ErrReportHasNoNonce
+		}
+		return nil
+	}
+
+	if !vh.nonces.Consume(hex.EncodeToString(reportNonce)) && vh.requireNonce {
+		return ErrNonceNotIssued
+	}
+
+	return nil
+}
+
+// records a valid report in the replay store, returns the first time the report was seen if it's not new
+func (vh *verifyHandler) checkReplay(ctx context.Context, attestationReport string, userData []byte) (*replay.Record, error) {
+	if vh.replays == nil {
+		return nil, nil
+	}
+
+	// the user data starts with the hash of the attested data
+	dataHash := userData
+	if len(dataHash) > 16 {
+		dataHash = dataHash[:16]
+	}
+
+	record, seen, err := vh.replays.CheckAndRecord(replay.Key(attestationReport, dataHash), GetContextRequestId(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if !seen {
+		return nil, nil
+	}
+
+	if vh.replayPolicy == replay.POLICY_REJECT {
+		return &record, fmt.Errorf("report was already verified at %s", record.FirstSeen.Format(time.DateTime))
+	}
+
+	return &record, nil
+}
+
+// creates the verification cache key from the normalized report
+func (vh *verifyHandler) cacheKey(report *attestation.Report) (string, error) {
+	var normalized []byte
+	var err error
+
+	switch report.Kind {
+	case attestation.REPORT_KIND_MULTIPLE_TOKENS:
+		normalized, err = json.Marshal(report.Multiple)
+	default:
+		normalized, err = json.Marshal(report.Single)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(report.Kind))
+	hash.Write(normalized)
+	// the same report may be valid with some collateral and invalid with other
+	hash.Write(report.Collateral)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (vh *verifyHandler) verify(aleoSession aleo.Session, report *attestation.Report) (*verificationResult, error) {
+	switch report.Kind {
+	case attestation.REPORT_KIND_MULTIPLE_TOKENS:
+		return vh.VerifyMultipleTokensReport(aleoSession, report.Multiple, report.Collateral)
+	default:
+		return vh.VerifySingleTokenReport(aleoSession, report.Single, report.Collateral)
+	}
+}
+
 func (vh *verifyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -111,7 +246,8 @@ func (vh *verifyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	var request struct {
-		Reports []interface{} `json:"reports"`
+		Reports []json.RawMessage `json:"reports"`
+		NoCache bool              `json:"noCache"`
 	}
 	var err error
 	if err = json.Unmarshal(body, &request); err != nil {
@@ -125,7 +261,7 @@ func (vh *verifyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		log.Println("no reports to verify")
 		w.WriteHeader(http.StatusBadRequest)
 		return
-	}	
+	}
 
 	aleoSession, err := vh.aleoWrapper.NewSession()
 	if err != nil {
@@ -136,108 +272,124 @@ func (vh *verifyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	defer aleoSession.Close()
 
 	validReports := make([]int, 0)
+	var tokenResults []ReportTokenResults
+	var previouslySeen []PreviouslySeenReport
 	var errors []string
 	for i, v := range reports {
-		reportJsonBytes, err := json.Marshal(v)
+		report, err := attestation.DecodeReport(v)
 		if err != nil {
-			log.Printf("failed to marshal report to JSON: %s\n", err)
-			errors = append(errors, err.Error())
-			continue
-		}
-		var tempMap map[string]interface{}
-		if err := json.Unmarshal(reportJsonBytes, &tempMap); err != nil {
-			log.Printf("failed to unmarshal JSON for type checking: %s\n", err)
+			log.Printf("failed to decode report %d: %s\n", i, err)
 			errors = append(errors, err.Error())
 			continue
 		}
 
-		isMultipleToken := false
-		if results, ok := tempMap["attestationResults"]; ok {
-			// Check if it's an array and if it has elements
-			if resultsSlice, ok := results.([]interface{}); ok && len(resultsSlice) > 0 {
-				isMultipleToken = true
+		var cacheKey string
+		var result *verificationResult
+		var cached bool
+		if vh.cache != nil {
+			cacheKey, err = vh.cacheKey(report)
+			if err != nil {
+				log.Printf("failed to create cache key for report %d: %s\n", i, err)
+			} else if !request.NoCache {
+				result, cached = vh.cache.Get(cacheKey, time.Now())
 			}
 		}
 
-		if isMultipleToken {
-			err := vh.VerifyMultipleTokensReport(aleoSession, reportJsonBytes)
-			if err != nil {
-				log.Printf("error verifying multiple tokens report: %s\n", err)
-				errors = append(errors, err.Error())
-				continue
+		if !cached {
+			result, err = vh.verify(aleoSession, report)
+			if result != nil && result.tokenResults != nil {
+				tokenResults = append(tokenResults, ReportTokenResults{ReportIndex: i, Tokens: result.tokenResults})
 			}
-		} else {
-			err := vh.VerifySingleTokenReport(aleoSession, reportJsonBytes)
 			if err != nil {
-				log.Printf("error verifying single token report: %s\n", err)
+				log.Printf("error verifying %s report: %s\n", report.Kind, err)
 				errors = append(errors, err.Error())
 				continue
 			}
-		}
-		
-			
 
-		validReports = append(validReports, i)	
-	}
+			// only the successful verifications are cached, a failure may be caused by e.g. an unavailable quote provider
+			if vh.cache != nil && cacheKey != "" {
+				vh.cache.Add(cacheKey, result, time.Now())
+			}
+		} else if result.tokenResults != nil {
+			tokenResults = append(tokenResults, ReportTokenResults{ReportIndex: i, Tokens: result.tokenResults})
+		}
 
-	respondVerify(req.Context(), w, validReports, strings.Join(errors, "; "))
-}
+		err = vh.checkReportNonce(result.nonce)
+		if err != nil {
+			log.Printf("error verifying report %d nonce: %s\n", i, err)
+			errors = append(errors, err.Error())
+			continue
+		}
 
-func (vh *verifyHandler) VerifySingleTokenReport(aleoSession aleo_wrapper.Session, reportJsonBytes []byte) error {
+		seenRecord, err := vh.checkReplay(req.Context(), result.attestationReport, result.userData)
+		if seenRecord != nil {
+			previouslySeen = append(previouslySeen, PreviouslySeenReport{ReportIndex: i, Record: *seenRecord})
+		}
+		if err != nil {
+			log.Printf("replay check failed for report %d: %s\n", i, err)
+			errors = append(errors, err.Error())
+			continue
+		}
 
-	var report attestation.AttestationResponse
-	err := json.Unmarshal(reportJsonBytes, &report)
-	if err != nil {
-		log.Printf("failed to unmarshal report: %s\n", err)
-		return err
+		validReports = append(validReports, i)
 	}
 
+	respondVerify(req.Context(), w, validReports, tokenResults, previouslySeen, strings.Join(errors, "; "))
+}
+
+// VerifySingleTokenReport verifies a single token report, against the collateral supplied with it if it's not nil
+func (vh *verifyHandler) VerifySingleTokenReport(aleoSession aleo.Session, report *attestation.AttestationResponse, collateral json.RawMessage) (*verificationResult, error) {
 	reportBytes, err := base64.StdEncoding.DecodeString(report.AttestationReport)
 	if err != nil {
 		log.Printf("failed to decode base64 %s report: %s\n", report.ReportType, err)
-		return err
+		return nil, err
 	}
 
-	_, userData, err := attestation.VerifyReport(report.ReportType, reportBytes, report.Nonce, vh.targetUniqueId, vh.targetPcrValues)
+	verified, err := attestation.VerifyReportWithCollateral(report.ReportType, reportBytes, report.Nonce, vh.policies, collateral)
 	if err != nil {
 		log.Printf("error verifying %s report: %s\n", report.ReportType, err)
-		return err
+		return nil, err
 	}
 
-	err = attestation.VerifyReportData(aleoSession, userData, &report)
+	err = attestation.VerifyReportData(aleoSession, verified.UserData, report)
 	if err != nil {
 		log.Printf("error verifying %s report: %s\n", report.ReportType, err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	// reports without a nonce are handled when checking the nonce
+	return &verificationResult{
+		attestationReport: report.AttestationReport,
+		userData:          verified.UserData,
+		nonce:             verified.Nonce,
+	}, nil
 }
 
-func (vh *verifyHandler) VerifyMultipleTokensReport(aleoSession aleo_wrapper.Session, reportJsonBytes []byte) error {
-	var report attestation.AttestationResponseMultipleTokens
-	err := json.Unmarshal(reportJsonBytes, &report)
-	if err != nil {
-		log.Printf("failed to unmarshal report: %s\n", err)
-		return err
-	}
-
+// VerifyMultipleTokensReport verifies a multiple tokens report, against the collateral supplied with it if it's not nil
+func (vh *verifyHandler) VerifyMultipleTokensReport(aleoSession aleo.Session, report *attestation.AttestationResponseMultipleTokens, collateral json.RawMessage) (*verificationResult, error) {
 	reportBytes, err := base64.StdEncoding.DecodeString(report.AttestationReport)
 	if err != nil {
 		log.Printf("failed to decode base64 %s report: %s\n", report.ReportType, err)
-		return err
+		return nil, err
 	}
 
-	_, userData, err := attestation.VerifyReport(report.ReportType, reportBytes, report.Nonce, vh.targetUniqueId, vh.targetPcrValues)
+	verified, err := attestation.VerifyReportWithCollateral(report.ReportType, reportBytes, report.Nonce, vh.policies, collateral)
 	if err != nil {
 		log.Printf("error verifying %s report: %s\n", report.ReportType, err)
-		return err
+		return nil, err
 	}
 
-	err = attestation.VerifyReportDataForMultipleTokens(aleoSession, userData, &report)
+	tokenResults, err := attestation.VerifyReportDataForMultipleTokens(aleoSession, verified.UserData, report)
 	if err != nil {
 		log.Printf("error verifying %s report: %s\n", report.ReportType, err)
-		return err
+		return &verificationResult{tokenResults: tokenResults}, err
 	}
 
-	return nil
-}
\ No newline at end of file
+	// reports without a nonce are handled when checking the nonce
+	return &verificationResult{
+		attestationReport: report.AttestationReport,
+		userData:          verified.UserData,
+		nonce:             verified.Nonce,
+		tokenResults:      tokenResults,
+	}, nil
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+	"github.com/venture23-aleo/oracle-verification-backend/nonce"
+	"github.com/venture23-aleo/oracle-verification-backend/replay"
+	"github.com/venture23-aleo/oracle-verification-backend/testutil"
+)
+
+func Test_verifyHandler(t *testing.T) {
+	var uniqueId [32]byte
+	copy(uniqueId[:], bytes.Repeat([]byte{0xab}, 32))
+
+	var pcrs [3][48]byte
+	for idx := range pcrs {
+		copy(pcrs[idx][:], bytes.Repeat([]byte{byte(idx + 1)}, 48))
+	}
+
+	profile := config.Profile{
+		Name:            "test",
+		UniqueIdTarget:  hex.EncodeToString(uniqueId[:]),
+		PcrValuesTarget: []string{hex.EncodeToString(pcrs[0][:]), hex.EncodeToString(pcrs[1][:]), hex.EncodeToString(pcrs[2][:])},
+	}
+	policies, err := tee.ProfilePolicies(profile.PolicyBlocks())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nitroCa := testutil.NewNitroCA(t)
+	if err := nitro.Init(nitroCa.WriteRootPem(t), nitro.VERIFICATION_TIME_ATTESTATION, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		nitro.Init("", nitro.VERIFICATION_TIME_ATTESTATION, 0)
+	})
+
+	quoteProvider := testutil.NewSgxQuoteProvider(t)
+	sgx.SetReportVerifier(quoteProvider)
+	t.Cleanup(func() {
+		sgx.SetReportVerifier(nil)
+	})
+
+	aleoWrapper := aleo.FakeWrapper{}
+	session := aleo.FakeSession{}
+
+	// the reports are bound to the responses with the attested data "12.34"
+	nitroReport := func(document testutil.NitroDocument, ca *testutil.NitroCA) *attestation.AttestationResponse {
+		resp := testutil.Response(attestation.TEE_TYPE_NITRO, "12.34")
+		if document.UserData == nil {
+			userData, err := testutil.UserData(session, resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			document.UserData = userData
+		}
+
+		resp.AttestationReport = base64.StdEncoding.EncodeToString(ca.Document(t, document))
+		return resp
+	}
+
+	sgxReport := func(report testutil.SgxReport) *attestation.AttestationResponse {
+		resp := testutil.Response(attestation.TEE_TYPE_SGX, "12.34")
+		reportData, err := testutil.SgxReportData(session, resp, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report.ReportData = reportData
+
+		resp.AttestationReport = base64.StdEncoding.EncodeToString(quoteProvider.Report(t, report))
+		return resp
+	}
+
+	otherPcrs := pcrs
+	otherPcrs[2][0] ^= 0xff
+
+	tamperedData := nitroReport(testutil.NitroDocument{Pcrs: pcrs}, nitroCa)
+	tamperedData.AttestationData = "43.21"
+
+	tamperedSgxReport := sgxReport(testutil.SgxReport{UniqueId: uniqueId})
+	tamperedSgxReportBytes, _ := base64.StdEncoding.DecodeString(tamperedSgxReport.AttestationReport)
+	tamperedSgxReportBytes[0] ^= 0xff
+	tamperedSgxReport.AttestationReport = base64.StdEncoding.EncodeToString(tamperedSgxReportBytes)
+
+	tests := []struct {
+		name             string
+		reports          []*attestation.AttestationResponse
+		wantValidReports []int
+		wantErr          string
+	}{
+		{
+			name:             "Nitro report",
+			reports:          []*attestation.AttestationResponse{nitroReport(testutil.NitroDocument{Pcrs: pcrs}, nitroCa)},
+			wantValidReports: []int{0},
+		},
+		{
+			name:             "SGX report",
+			reports:          []*attestation.AttestationResponse{sgxReport(testutil.SgxReport{UniqueId: uniqueId})},
+			wantValidReports: []int{0},
+		},
+		{
+			name: "Nitro and SGX reports",
+			reports: []*attestation.AttestationResponse{
+				nitroReport(testutil.NitroDocument{Pcrs: pcrs}, nitroCa),
+				sgxReport(testutil.SgxReport{UniqueId: uniqueId}),
+			},
+			wantValidReports: []int{0, 1},
+		},
+		{
+			name:             "other PCR values",
+			reports:          []*attestation.AttestationResponse{nitroReport(testutil.NitroDocument{Pcrs: otherPcrs}, nitroCa)},
+			wantValidReports: []int{},
+			wantErr:          "report PCR values don't match target",
+		},
+		{
+			name:             "other Nitro CA",
+			reports:          []*attestation.AttestationResponse{nitroReport(testutil.NitroDocument{Pcrs: pcrs}, testutil.NewNitroCA(t))},
+			wantValidReports: []int{},
+			wantErr:          "verifying certificates",
+		},
+		{
+			name:             "other user data",
+			reports:          []*attestation.AttestationResponse{nitroReport(testutil.NitroDocument{Pcrs: pcrs, UserData: make([]byte, 16)}, nitroCa)},
+			wantValidReports: []int{},
+			wantErr:          attestation.ErrVerificationFailedToMatchData.Error(),
+		},
+		{
+			name:             "tampered attestation data",
+			reports:          []*attestation.AttestationResponse{tamperedData},
+			wantValidReports: []int{},
+			wantErr:          attestation.ErrVerificationFailedToMatchData.Error(),
+		},
+		{
+			name:             "other unique ID",
+			reports:          []*attestation.AttestationResponse{sgxReport(testutil.SgxReport{UniqueId: [32]byte{1}})},
+			wantValidReports: []int{},
+			wantErr:          "report unique ID doesn't match target",
+		},
+		{
+			name:             "debug SGX report",
+			reports:          []*attestation.AttestationResponse{sgxReport(testutil.SgxReport{UniqueId: uniqueId, Debug: true})},
+			wantValidReports: []int{},
+			wantErr:          "quote is in debug mode",
+		},
+		{
+			name:             "tampered SGX report",
+			reports:          []*attestation.AttestationResponse{tamperedSgxReport},
+			wantValidReports: []int{},
+			wantErr:          testutil.ErrInvalidSgxReport.Error(),
+		},
+		{
+			name: "one invalid report",
+			reports: []*attestation.AttestationResponse{
+				sgxReport(testutil.SgxReport{UniqueId: [32]byte{1}}),
+				nitroReport(testutil.NitroDocument{Pcrs: pcrs}, nitroCa),
+			},
+			wantValidReports: []int{1},
+			wantErr:          "report unique ID doesn't match target",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CreateVerifyHandler(aleoWrapper, policies, nil, false, nil, "", nil)
+
+			body, err := json.Marshal(map[string]any{"reports": tt.reports})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+			}
+
+			var response VerifyReportsResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Success != (tt.wantErr == "") || !strings.Contains(response.ErrorMessage, tt.wantErr) {
+				t.Errorf("response = %+v, want error %q", response, tt.wantErr)
+			}
+			if len(response.ValidReports) != len(tt.wantValidReports) {
+				t.Fatalf("valid reports = %v, want %v", response.ValidReports, tt.wantValidReports)
+			}
+			for idx := range response.ValidReports {
+				if response.ValidReports[idx] != tt.wantValidReports[idx] {
+					t.Errorf("valid reports = %v, want %v", response.ValidReports, tt.wantValidReports)
+				}
+			}
+		})
+	}
+}
+
+func Test_verifyHandler_badRequest(t *testing.T) {
+	handler := CreateVerifyHandler(aleo.FakeWrapper{}, nil, nil, false, nil, "", nil)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "GET", method: http.MethodGet, contentType: "application/json", wantStatus: http.StatusMethodNotAllowed},
+		{name: "not JSON", method: http.MethodPost, contentType: "text/plain", body: `{"reports": []}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed JSON", method: http.MethodPost, contentType: "application/json", body: `{"reports": `, wantStatus: http.StatusBadRequest},
+		{name: "no reports", method: http.MethodPost, contentType: "application/json", body: `{"reports": []}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, "/verify", strings.NewReader(tt.body))
+			request.Header.Set("Content-Type", tt.contentType)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func Test_verifyHandler_state(t *testing.T) {
+	var pcrs [3][48]byte
+	for idx := range pcrs {
+		copy(pcrs[idx][:], bytes.Repeat([]byte{byte(idx + 1)}, 48))
+	}
+
+	profile := config.Profile{
+		Name:            "test",
+		PcrValuesTarget: []string{hex.EncodeToString(pcrs[0][:]), hex.EncodeToString(pcrs[1][:]), hex.EncodeToString(pcrs[2][:])},
+	}
+	policies, err := tee.ProfilePolicies(profile.PolicyBlocks())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nitroCa := testutil.NewNitroCA(t)
+	initNitro := func(ca *testutil.NitroCA) {
+		if err := nitro.Init(ca.WriteRootPem(t), nitro.VERIFICATION_TIME_ATTESTATION, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	initNitro(nitroCa)
+	t.Cleanup(func() {
+		nitro.Init("", nitro.VERIFICATION_TIME_ATTESTATION, 0)
+	})
+
+	aleoWrapper := aleo.FakeWrapper{}
+	session := aleo.FakeSession{}
+
+	nitroReport := func(reportNonce []byte) *attestation.AttestationResponse {
+		resp := testutil.Response(attestation.TEE_TYPE_NITRO, "12.34")
+		resp.Nonce = hex.EncodeToString(reportNonce)
+		userData, err := testutil.UserData(session, resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.AttestationReport = base64.StdEncoding.EncodeToString(nitroCa.Document(t, testutil.NitroDocument{Pcrs: pcrs, UserData: userData, Nonce: reportNonce}))
+		return resp
+	}
+
+	verify := func(handler http.Handler, report *attestation.AttestationResponse, noCache bool) VerifyReportsResponse {
+		body, err := json.Marshal(map[string]any{"reports": []*attestation.AttestationResponse{report}, "noCache": noCache})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+		}
+
+		var response VerifyReportsResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+
+		return response
+	}
+
+	t.Run("nonce", func(t *testing.T) {
+		nonces := nonce.NewStore(time.Minute)
+		handler := CreateVerifyHandler(aleoWrapper, policies, nonces, true, nil, "", nil)
+
+		issued, _, err := nonces.Issue("192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		issuedBytes, _ := hex.DecodeString(issued)
+		report := nitroReport(issuedBytes)
+
+		if response := verify(handler, report, false); !response.Success {
+			t.Errorf("response with an issued nonce = %+v", response)
+		}
+		if response := verify(handler, report, false); response.Success || response.ErrorMessage != ErrNonceNotIssued.Error() {
+			t.Errorf("response with a used nonce = %+v", response)
+		}
+		if response := verify(handler, nitroReport(make([]byte, nonce.NONCE_SIZE)), false); response.Success || response.ErrorMessage != ErrNonceNotIssued.Error() {
+			t.Errorf("response with a nonce that wasn't issued = %+v", response)
+		}
+		if response := verify(handler, nitroReport(nil), false); response.Success || response.ErrorMessage != attestation.ErrReportHasNoNonce.Error() {
+			t.Errorf("response without a nonce = %+v", response)
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		for _, policy := range []string{replay.POLICY_FLAG, replay.POLICY_REJECT} {
+			handler := CreateVerifyHandler(aleoWrapper, policies, nil, false, replay.NewMemoryStore(0), policy, nil)
+			report := nitroReport(nil)
+
+			response := verify(handler, report, false)
+			if !response.Success || len(response.PreviouslySeen) != 0 {
+				t.Errorf("%s: first response = %+v", policy, response)
+			}
+
+			response = verify(handler, report, false)
+			if len(response.PreviouslySeen) != 1 || response.PreviouslySeen[0].ReportIndex != 0 {
+				t.Errorf("%s: previously seen = %+v", policy, response.PreviouslySeen)
+			}
+			// flagged reports are still valid
+			wantValid := policy == replay.POLICY_FLAG
+			if response.Success != wantValid || (len(response.ValidReports) == 1) != wantValid {
+				t.Errorf("%s: second response = %+v", policy, response)
+			}
+		}
+	})
+
+	t.Run("collateral", func(t *testing.T) {
+		handler := CreateVerifyHandler(aleoWrapper, policies, nil, false, nil, "", nil)
+
+		// a malformed collateral is rejected by the SGX verifier, Nitro reports have no collateral
+		tests := []struct {
+			reportType string
+			wantErr    string
+		}{
+			{reportType: attestation.TEE_TYPE_SGX, wantErr: "collateral: malformed collateral"},
+			{reportType: attestation.TEE_TYPE_NITRO, wantErr: tee.ErrCollateralNotSupported.Error()},
+		}
+		for _, tt := range tests {
+			report := nitroReport(nil)
+			report.ReportType = tt.reportType
+
+			body, err := json.Marshal(map[string]any{"reports": []any{map[string]any{
+				"reportType":         report.ReportType,
+				"attestationReport":  report.AttestationReport,
+				"attestationData":    report.AttestationData,
+				"responseBody":       report.ResponseBody,
+				"responseStatusCode": report.ResponseStatusCode,
+				"timestamp":          report.Timestamp,
+				"attestationRequest": report.AttestationRequest,
+				"collateral":         "bundle",
+			}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			var response VerifyReportsResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Success || !strings.Contains(response.ErrorMessage, tt.wantErr) {
+				t.Errorf("%s: response = %+v, want error %q", tt.reportType, response, tt.wantErr)
+			}
+		}
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		handler := CreateVerifyHandler(aleoWrapper, policies, nil, false, nil, "", NewVerificationCache(16, time.Minute))
+		report := nitroReport(nil)
+
+		if response := verify(handler, report, false); !response.Success {
+			t.Fatalf("first response = %+v", response)
+		}
+
+		// the report doesn't verify with another root CA, unless the cached result is used
+		initNitro(testutil.NewNitroCA(t))
+		defer initNitro(nitroCa)
+
+		if response := verify(handler, report, false); !response.Success {
+			t.Errorf("cached response = %+v", response)
+		}
+		if response := verify(handler, report, true); response.Success || !strings.Contains(response.ErrorMessage, "verifying certificates") {
+			t.Errorf("response without the cache = %+v", response)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+	"github.com/venture23-aleo/oracle-verification-backend/transaction"
+)
+
+type verifyTransactionHandler struct {
+	aleoWrapper  aleo.Wrapper
+	nodeClient   *node.Client
+	contractName string
+	policies     tee.Policies
+}
+
+type VerifyTransactionRequest struct {
+	TransactionId string `json:"transactionId"`
+}
+
+type VerifyTransactionResponse struct {
+	Success      bool                              `json:"success"`
+	Results      []*transaction.VerificationResult `json:"results"`
+	ErrorMessage string                            `json:"errorMessage,omitempty"`
+}
+
+func CreateVerifyTransactionHandler(aleoWrapper aleo.Wrapper, nodeClient *node.Client, contractName string, policies tee.Policies) http.Handler {
+	return &verifyTransactionHandler{
+		aleoWrapper:  aleoWrapper,
+		nodeClient:   nodeClient,
+		contractName: contractName,
+		policies:     policies,
+	}
+}
+
+func (h *verifyTransactionHandler) respond(w http.ResponseWriter, req *http.Request, results []*transaction.VerificationResult, err error) {
+	log := GetContextLogger(req.Context())
+
+	r := &VerifyTransactionResponse{
+		Success: err == nil && len(results) > 0,
+		Results: results,
+	}
+
+	if err != nil {
+		r.ErrorMessage = err.Error()
+	}
+
+	msg, err := json.Marshal(r)
+	if err != nil {
+		log.Println("failed to marshal response:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(msg)
+}
+
+func (h *verifyTransactionHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if req.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	log := GetContextLogger(req.Context())
+
+	defer req.Body.Close()
+
+	body, ok := readRequestBody(w, req)
+	if !ok {
+		return
+	}
+
+	request := new(VerifyTransactionRequest)
+	if err := json.Unmarshal(body, request); err != nil {
+		log.Println("error reading request:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.TransactionId == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tx, err := transaction.Fetch(req.Context(), h.nodeClient, request.TransactionId)
+	if err != nil {
+		log.Println("error fetching transaction:", err)
+		h.respond(w, req, nil, err)
+		return
+	}
+
+	updates := transaction.ExtractOracleUpdates(tx, h.contractName)
+	if len(updates) == 0 {
+		h.respond(w, req, nil, transaction.ErrNoOracleUpdate)
+		return
+	}
+
+	aleoSession, err := h.aleoWrapper.NewSession()
+	if err != nil {
+		log.Println("error creating new aleo session:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer aleoSession.Close()
+
+	results := make([]*transaction.VerificationResult, 0, len(updates))
+	for idx := range updates {
+		results = append(results, transaction.VerifyOracleUpdate(aleoSession, tx.Id, &updates[idx], h.policies))
+	}
+
+	var verificationErr error
+	for _, result := range results {
+		if !result.Valid {
+			verificationErr = errors.New("some oracle updates failed verification")
+			break
+		}
+	}
+
+	h.respond(w, req, results, verificationErr)
+}
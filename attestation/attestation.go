@@ -2,24 +2,32 @@ package attestation
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"log"
 
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
 	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
 	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/snp"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tdx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
 	"github.com/venture23-aleo/oracle-verification-backend/common"
 	"github.com/venture23-aleo/oracle-verification-backend/constants"
 
 	encoding "github.com/venture23-aleo/aleo-oracle-encoding"
-	aleo_wrapper "github.com/venture23-aleo/aleo-utils-go"
 )
 
 // Tee types
 const (
 	// AWS Nitro enclave
-	TEE_TYPE_NITRO string = "nitro"
+	TEE_TYPE_NITRO string = nitro.TEE_TYPE
 	// Intel SGX
-	TEE_TYPE_SGX string = "sgx"
+	TEE_TYPE_SGX string = sgx.TEE_TYPE
+	// AMD SEV-SNP
+	TEE_TYPE_SNP string = snp.TEE_TYPE
+	// Intel TDX
+	TEE_TYPE_TDX string = tdx.TEE_TYPE
 
 	ALEO_STRUCT_REPORT_DATA_SIZE = 10
 )
@@ -43,6 +51,7 @@ type AttestationRequest struct {
 }
 
 type AttestationResponse struct {
+	Kind               string             `json:"kind,omitempty"`
 	AttestationReport  string             `json:"attestationReport"`
 	ReportType         string             `json:"reportType"`
 	AttestationData    string             `json:"attestationData"`
@@ -54,6 +63,7 @@ type AttestationResponse struct {
 }
 
 type AttestationResponseMultipleTokens struct {
+	Kind               string             `json:"kind,omitempty"`
 	AttestationReport  string             `json:"attestationReport"`
 	ReportType         string             `json:"reportType"`
 	Nonce              string             `json:"nonce,omitempty"`
@@ -78,68 +88,81 @@ var (
 	ErrVerificationFailedToFormat    = errors.New("verification error: failed to format message for report verification")
 	ErrVerificationFailedToHash      = errors.New("verification error: failed to hash message for report verification")
 	ErrVerificationFailedToMatchData = errors.New("verification error: userData hashes don't match")
-	ErrUnsupportedReportType         = errors.New("unsupported report type")
+	ErrUnsupportedReportType         = tee.ErrUnsupportedReportType
+	ErrReportHasNoNonce              = errors.New("report doesn't have a nonce")
 )
 
-func VerifyReport(reportType string, report []byte, nonce string, targetUniqueId string, targetPcrValues [3]string) (interface{}, []byte, error) {
-	switch reportType {
-	case TEE_TYPE_SGX:
-		parsedReport, err := sgx.VerifySgxReport(report, targetUniqueId)
-		if err != nil {
-			return nil, nil, err
-		}
+// VerifyReport verifies a report with the registered verifier for its type, see tee.Register
+func VerifyReport(reportType string, report []byte, nonce string, policies tee.Policies) (*tee.Result, error) {
+	return tee.Verify(reportType, report, nonce, policies)
+}
 
-		return parsedReport, parsedReport.Data, nil
+// VerifyReportWithCollateral verifies a report the same way as VerifyReport, against the collateral supplied with it
+// if it's not nil, see tee.CollateralVerifier
+func VerifyReportWithCollateral(reportType string, report []byte, nonce string, policies tee.Policies, collateral json.RawMessage) (*tee.Result, error) {
+	return tee.VerifyWithCollateral(reportType, report, nonce, policies, collateral)
+}
 
-	case TEE_TYPE_NITRO:
-		parsedReport, err := nitro.VerifyNitroReport(report, nonce, targetPcrValues)
-		if err != nil {
-			return nil, nil, err
-		}
+// ProofData prepares the proof data of a response the same way as the notarization backend does for the report
+func ProofData(resp *AttestationResponse) ([]byte, error) {
+	dataBytes, err := PrepareProofData(resp.ResponseStatusCode, resp.AttestationData, resp.Timestamp, &resp.AttestationRequest)
+	if err != nil {
+		return nil, err
+	}
 
-		return parsedReport, parsedReport.UserData, nil
+	setPriceFeedTokenId(dataBytes, resp.AttestationRequest.Url)
 
-	default:
-		return nil, nil, ErrUnsupportedReportType
+	return dataBytes, nil
+}
+
+// stores the token ID of a price feed in the meta header of the proof data, other proof data is left as is
+func setPriceFeedTokenId(dataBytes []byte, url string) {
+	tokenId := common.GetTokenIDFromPriceFeedURL(url)
+	if tokenId == 0 || len(dataBytes) <= priceFeedTokenIdPosition {
+		return
 	}
+
+	dataBytes[priceFeedTokenIdPosition] = byte(tokenId)
 }
 
-func VerifyReportData(aleoSession aleo_wrapper.Session, userData []byte, resp *AttestationResponse) error {
+func VerifyReportData(aleoSession aleo.Session, userData []byte, resp *AttestationResponse) error {
 	if resp == nil {
 		return ErrVerificationFailedToPrepare
 	}
 
-	dataBytes, err := PrepareProofData(resp.ResponseStatusCode, resp.AttestationData, resp.Timestamp, &resp.AttestationRequest)
+	dataBytes, err := ProofData(resp)
 	if err != nil {
 		log.Printf("prepareProofData: %v", err)
 		return ErrVerificationFailedToPrepare
 	}
 
-	// Ensure dataBytes is non-empty before writing special-case overrides
-	if len(dataBytes) > 0 {
-		if resp.AttestationRequest.Url == PriceFeedAleoUrl {
-			dataBytes[21] = 8
-		} else if resp.AttestationRequest.Url == PriceFeedBtcUrl {
-			dataBytes[21] = 12
-		} else if resp.AttestationRequest.Url == PriceFeedEthUrl {
-			dataBytes[21] = 11
-		} else if resp.AttestationRequest.Url == PriceFeedUsdtUrl {
-			dataBytes[21] = 9
-		} else if resp.AttestationRequest.Url == PriceFeedUsdcUrl {
-			dataBytes[21] = 10
-		}
-	}
+	return VerifyProofData(aleoSession, dataBytes, userData)
+}
 
+// HashProofData formats the encoded proof data the same way as it's done for the Aleo program and returns its
+// Poseidon8 hash, which the report's user data starts with.
+func HashProofData(aleoSession aleo.Session, dataBytes []byte) ([]byte, error) {
 	formattedData, err := aleoSession.FormatMessage(dataBytes, ALEO_STRUCT_REPORT_DATA_SIZE)
 	if err != nil {
 		log.Printf("aleo.FormatMessage(): %v\n", err)
-		return ErrVerificationFailedToFormat
+		return nil, ErrVerificationFailedToFormat
 	}
 
 	attestationHash, err := aleoSession.HashMessage(formattedData)
 	if err != nil {
 		log.Printf("aleo.HashMessage(): %v\n", err)
-		return ErrVerificationFailedToHash
+		return nil, ErrVerificationFailedToHash
+	}
+
+	return attestationHash, nil
+}
+
+// VerifyProofData formats the encoded proof data the same way as it's done for the Aleo program,
+// and compares its hash with the report's user data.
+func VerifyProofData(aleoSession aleo.Session, dataBytes []byte, userData []byte) error {
+	attestationHash, err := HashProofData(aleoSession, dataBytes)
+	if err != nil {
+		return err
 	}
 
 	// Poseidon8 hash is 16 bytes when represented in bytes so here we compare
@@ -168,16 +191,7 @@ func PrepareOracleUserDataChunk(statusCode int,
 		return nil, err
 	}
 
-	if common.IsPriceFeedURL(attestationRequest.Url) {
-		tokenID := common.GetTokenIDFromPriceFeedURL(attestationRequest.Url)
-		if tokenID == 0 {
-			return nil, errors.New("unsupported price feed URL")
-		}
-		// MetaHeaders is 32 bytes total.
-		// - The first 21 bytes are reserved for other metadata.
-		// - The token ID is stored at byte index 21 (0-based).
-		userDataProof[21] = byte(tokenID)
-	}
+	setPriceFeedTokenId(userDataProof, attestationRequest.Url)
 
 	userDataChunk = make([]byte, constants.ChunkSizeInBytes)
 	copy(userDataChunk, userDataProof)
@@ -185,43 +199,59 @@ func PrepareOracleUserDataChunk(statusCode int,
 	return userDataChunk, nil
 }
 
-func VerifyReportDataForMultipleTokens(aleoSession aleo_wrapper.Session, userData []byte, resp *AttestationResponseMultipleTokens) error {
+// TokenVerificationResult holds the diagnostics for a single token of a multiple tokens report
+type TokenVerificationResult struct {
+	Index        int    `json:"index"`
+	TokenID      int    `json:"tokenId"`
+	Value        string `json:"value,omitempty"`
+	ChunkOffset  int    `json:"chunkOffset"`
+	Prepared     bool   `json:"prepared"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// VerifyReportDataForMultipleTokens verifies the report's user data against all of the attestation results.
+// Returns the per-token diagnostics along with the report-level result.
+func VerifyReportDataForMultipleTokens(aleoSession aleo.Session, userData []byte, resp *AttestationResponseMultipleTokens) ([]*TokenVerificationResult, error) {
 	if resp == nil {
-		return ErrVerificationFailedToPrepare
+		return nil, ErrVerificationFailedToPrepare
 	}
 
 	dataBytes := make([]byte, 0)
+	tokenResults := make([]*TokenVerificationResult, 0, len(resp.AttestationResults))
+
+	var prepareErr error
+	for idx, result := range resp.AttestationResults {
+		tokenResult := &TokenVerificationResult{
+			Index:       idx,
+			TokenID:     common.GetTokenIDFromPriceFeedURL(result.AtttestationRequest.Url),
+			ChunkOffset: idx * constants.ChunkSizeInBytes,
+		}
+		tokenResults = append(tokenResults, tokenResult)
 
-	for _, result := range resp.AttestationResults {
 		userDataChunk, err := PrepareOracleUserDataChunk(result.ResponseStatusCode, result.AttestationData, uint64(result.AttestationTimestamp), result.AtttestationRequest)
 		if err != nil {
-			log.Printf("PrepareOracleUserDataChunk(): %v", err)
-			return err
+			log.Printf("PrepareOracleUserDataChunk(): token %d: %v", idx, err)
+			tokenResult.ErrorMessage = err.Error()
+			if prepareErr == nil {
+				prepareErr = err
+			}
+			continue
 		}
-		dataBytes = append(dataBytes, userDataChunk...)
-	}
-	
-	formattedData, err := aleoSession.FormatMessage(dataBytes, ALEO_STRUCT_REPORT_DATA_SIZE)
-	if err != nil {
-		log.Printf("aleo.FormatMessage(): %v\n", err)
-		return ErrVerificationFailedToFormat
-	}
+		tokenResult.Prepared = true
 
-	attestationHash, err := aleoSession.HashMessage(formattedData)
-	if err != nil {
-		log.Printf("aleo.HashMessage(): %v\n", err)
-		return ErrVerificationFailedToHash
-	}
+		decoded, err := DecodeProofData(userDataChunk)
+		if err != nil {
+			log.Printf("DecodeProofData(): token %d: %v", idx, err)
+		} else {
+			tokenResult.Value = decoded.AttestationData
+		}
 
-	// Poseidon8 hash is 16 bytes when represented in bytes so here we compare
-	// the resulting hash only with 16 out of 64 bytes of the report's user data.
-	// IMPORTANT! this needs to be adjusted if we put more data in the report
-	if len(userData) < 16 {
-		return ErrVerificationFailedToMatchData
+		dataBytes = append(dataBytes, userDataChunk...)
 	}
-	if !bytes.Equal(attestationHash, userData[:16]) {
-		return ErrVerificationFailedToMatchData
+
+	if prepareErr != nil {
+		return tokenResults, prepareErr
 	}
 
-	return nil
-}
\ No newline at end of file
+	return tokenResults, VerifyProofData(aleoSession, dataBytes, userData)
+}
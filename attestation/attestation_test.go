@@ -0,0 +1,81 @@
+package attestation
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	encoding "github.com/venture23-aleo/aleo-oracle-encoding"
+)
+
+func Test_VerifyReportDataForMultipleTokens(t *testing.T) {
+	priceFeedResult := func(url, data string) AttestationResultForEachToken {
+		return AttestationResultForEachToken{
+			AttestationData: data,
+			AtttestationRequest: AttestationRequest{
+				Url:            url,
+				RequestMethod:  http.MethodGet,
+				ResponseFormat: "json",
+				EncodingOptions: encoding.EncodingOptions{
+					Value:     encoding.ENCODING_OPTION_FLOAT,
+					Precision: 6,
+				},
+			},
+			ResponseStatusCode:   200,
+			AttestationTimestamp: 1701851063,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		resp    *AttestationResponseMultipleTokens
+		want    []*TokenVerificationResult
+		wantErr bool
+	}{
+		{
+			name:    "nil response",
+			resp:    nil,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "malformed token chunk",
+			resp: &AttestationResponseMultipleTokens{
+				AttestationResults: []AttestationResultForEachToken{
+					priceFeedResult(PriceFeedBtcUrl, "65123.45"),
+					priceFeedResult(PriceFeedEthUrl, "not a number"),
+				},
+			},
+			want: []*TokenVerificationResult{
+				{
+					Index:       0,
+					TokenID:     12,
+					Value:       "65123.450000",
+					ChunkOffset: 0,
+					Prepared:    true,
+				},
+				{
+					Index:        1,
+					TokenID:      11,
+					ChunkOffset:  512,
+					Prepared:     false,
+					ErrorMessage: encoding.ErrFloatValueParseFailure.Error(),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the session is not used when the data fails to be prepared
+			got, err := VerifyReportDataForMultipleTokens(nil, nil, tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyReportDataForMultipleTokens() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VerifyReportDataForMultipleTokens() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,21 @@ package attestation
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/venture23-aleo/oracle-verification-backend/common"
+	"github.com/venture23-aleo/oracle-verification-backend/constants"
+
 	encoding "github.com/venture23-aleo/aleo-oracle-encoding"
 )
 
+// position of the token ID in the meta header of a price feed chunk
+const priceFeedTokenIdPosition = 21
+
+var ErrNoDecodedData = errors.New("no decoded data")
+
 type DecodedProofData struct {
 	AttestationRequest
 
@@ -16,6 +25,14 @@ type DecodedProofData struct {
 	Timestamp          int64  `json:"timestamp"`
 }
 
+type DecodedPriceFeedData struct {
+	Symbol     string `json:"symbol"`
+	TokenID    int    `json:"tokenId"`
+	Price      string `json:"price"`
+	Timestamp  int64  `json:"timestamp"`
+	ChunkIndex int    `json:"chunkIndex"`
+}
+
 // returns a number aligned to a full block
 func alignToBlock(num int) int {
 	if num%encoding.TARGET_ALIGNMENT == 0 {
@@ -198,3 +215,89 @@ func DecodeProofData(buf []byte) (*DecodedProofData, error) {
 		},
 	}, nil
 }
+
+// DecodePriceFeedData decodes a single price feed chunk of the proof data. The token ID is taken from the meta header,
+// the price is the attestation data of the chunk, which DecodeProofData already decodes with the encoding options.
+func DecodePriceFeedData(buf []byte, chunkIndex int) (*DecodedPriceFeedData, error) {
+	decoded, err := DecodeProofData(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if !common.IsPriceFeedURL(decoded.Url) {
+		return nil, fmt.Errorf("chunk %d is not a price feed", chunkIndex)
+	}
+
+	tokenId := int(buf[priceFeedTokenIdPosition])
+	if tokenId == 0 {
+		// older reports don't have the token ID in the meta header, fall back to the URL
+		tokenId = common.GetTokenIDFromPriceFeedURL(decoded.Url)
+	}
+
+	return &DecodedPriceFeedData{
+		Symbol:     common.GetTokenSymbolFromTokenID(tokenId),
+		TokenID:    tokenId,
+		Price:      decoded.AttestationData,
+		Timestamp:  decoded.Timestamp,
+		ChunkIndex: chunkIndex,
+	}, nil
+}
+
+// the chunks of encoded proof data in proof data recovered from a Leo ReportData value, up to the first empty chunk
+func proofDataChunks(recoveredMessage []byte) [][]byte {
+	chunks := make([][]byte, 0)
+
+	for i := 0; i < len(recoveredMessage); i += constants.ChunkSizeInBytes {
+		end := i + constants.ChunkSizeInBytes
+		if end > len(recoveredMessage) {
+			end = len(recoveredMessage)
+		}
+
+		chunk := recoveredMessage[i:end]
+		if chunk[0] == 0 {
+			break
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
+// DecodeProofDataChunks decodes proof data recovered from a Leo ReportData value, which can have one or more chunks
+// of encoded proof data. Decoding stops at the first empty chunk.
+func DecodeProofDataChunks(recoveredMessage []byte) ([]*DecodedProofData, error) {
+	decodedData := make([]*DecodedProofData, 0)
+
+	for _, chunk := range proofDataChunks(recoveredMessage) {
+		decodedDataItem, err := DecodeProofData(chunk)
+		if err != nil {
+			return nil, err
+		}
+		decodedData = append(decodedData, decodedDataItem)
+	}
+
+	if len(decodedData) == 0 {
+		return nil, ErrNoDecodedData
+	}
+
+	return decodedData, nil
+}
+
+// DecodePriceFeedDataChunks decodes the price feed data of every chunk the same way as DecodeProofDataChunks
+func DecodePriceFeedDataChunks(recoveredMessage []byte) ([]*DecodedPriceFeedData, error) {
+	priceFeedData := make([]*DecodedPriceFeedData, 0)
+
+	for idx, chunk := range proofDataChunks(recoveredMessage) {
+		priceFeedItem, err := DecodePriceFeedData(chunk, idx)
+		if err != nil {
+			return nil, err
+		}
+		priceFeedData = append(priceFeedData, priceFeedItem)
+	}
+
+	if len(priceFeedData) == 0 {
+		return nil, ErrNoDecodedData
+	}
+
+	return priceFeedData, nil
+}
@@ -64,3 +64,111 @@ func Test_decodeProofData(t *testing.T) {
 		})
 	}
 }
+
+func Test_DecodePriceFeedData(t *testing.T) {
+	priceFeedRequest := AttestationRequest{
+		Url:            PriceFeedBtcUrl,
+		RequestMethod:  http.MethodGet,
+		ResponseFormat: "json",
+		EncodingOptions: encoding.EncodingOptions{
+			Value:     encoding.ENCODING_OPTION_FLOAT,
+			Precision: 6,
+		},
+	}
+	priceFeedChunk, err := PrepareOracleUserDataChunk(200, "65123.45", 1701851063, priceFeedRequest)
+	if err != nil {
+		t.Fatalf("PrepareOracleUserDataChunk() error = %v", err)
+	}
+
+	otherRequest := priceFeedRequest
+	otherRequest.Url = "https://localhost:8080/resource"
+	otherChunk, err := PrepareOracleUserDataChunk(200, "1.5", 1701851063, otherRequest)
+	if err != nil {
+		t.Fatalf("PrepareOracleUserDataChunk() error = %v", err)
+	}
+
+	type args struct {
+		buf        []byte
+		chunkIndex int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *DecodedPriceFeedData
+		wantErr bool
+	}{
+		{
+			name: "btc price feed",
+			args: args{
+				buf:        priceFeedChunk,
+				chunkIndex: 2,
+			},
+			want: &DecodedPriceFeedData{
+				Symbol:     "BTC",
+				TokenID:    12,
+				Price:      "65123.450000",
+				Timestamp:  1701851063,
+				ChunkIndex: 2,
+			},
+			wantErr: false,
+		},
+		{
+			name: "not a price feed",
+			args: args{
+				buf:        otherChunk,
+				chunkIndex: 0,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePriceFeedData(tt.args.buf, tt.args.chunkIndex)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodePriceFeedData() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodePriceFeedData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_DecodePriceFeedDataChunks(t *testing.T) {
+	priceFeedRequest := AttestationRequest{
+		Url:            PriceFeedBtcUrl,
+		RequestMethod:  http.MethodGet,
+		ResponseFormat: "json",
+		EncodingOptions: encoding.EncodingOptions{
+			Value:     encoding.ENCODING_OPTION_FLOAT,
+			Precision: 6,
+		},
+	}
+	btcChunk, err := PrepareOracleUserDataChunk(200, "65123.45", 1701851063, priceFeedRequest)
+	if err != nil {
+		t.Fatalf("PrepareOracleUserDataChunk() error = %v", err)
+	}
+
+	priceFeedRequest.Url = PriceFeedEthUrl
+	ethChunk, err := PrepareOracleUserDataChunk(200, "3012.5", 1701851064, priceFeedRequest)
+	if err != nil {
+		t.Fatalf("PrepareOracleUserDataChunk() error = %v", err)
+	}
+
+	// the empty chunk ends the price feed
+	recoveredMessage := append(append(append([]byte{}, btcChunk...), ethChunk...), make([]byte, len(btcChunk))...)
+
+	got, err := DecodePriceFeedDataChunks(recoveredMessage)
+	if err != nil {
+		t.Fatalf("DecodePriceFeedDataChunks() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Symbol != "BTC" || got[1].Symbol != "ETH" || got[1].ChunkIndex != 1 {
+		t.Errorf("DecodePriceFeedDataChunks() = %+v", got)
+	}
+
+	if _, err := DecodePriceFeedDataChunks(make([]byte, len(btcChunk))); err != ErrNoDecodedData {
+		t.Errorf("DecodePriceFeedDataChunks() error = %v, want %v", err, ErrNoDecodedData)
+	}
+}
@@ -0,0 +1,131 @@
+package nitro
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Verification steps of an attestation document, see FailedStep
+const (
+	STEP_COSE              = "cose"
+	STEP_DOCUMENT          = "document"
+	STEP_DEBUG             = "debug"
+	STEP_FIELDS            = "fields"
+	STEP_CERTIFICATE_CHAIN = "certificate_chain"
+	STEP_SIGNATURE         = "signature"
+	STEP_UNKNOWN           = "unknown"
+)
+
+var ErrMalformedDocument = errors.New("nitro: malformed attestation document")
+
+// Errors of the verification steps of an attestation document, see FailedStep
+var (
+	ErrCose             = errors.New("nitro: unmarshaling CoseSign1 from attestation bytes")
+	ErrDocument         = errors.New("nitro: unmarshaling document from payload")
+	ErrDebugDocument    = errors.New("nitro: attestation was generated in debug mode")
+	ErrFields           = errors.New("nitro: verifying document fields")
+	ErrCertificateChain = errors.New("nitro: verifying certificates")
+	ErrSignature        = errors.New("nitro: verifying CoseSign1")
+)
+
+// the COSE_Sign1 structure of an attestation document
+type coseSign1 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected cbor.RawMessage
+	Payload     []byte
+	Signature   []byte
+}
+
+// the attestation document payload
+type documentPayload struct {
+	ModuleID    string          `cbor:"module_id"`
+	Digest      string          `cbor:"digest"`
+	Timestamp   uint64          `cbor:"timestamp"`
+	PCRs        map[uint][]byte `cbor:"pcrs"`
+	Certificate []byte          `cbor:"certificate"`
+	CABundle    [][]byte        `cbor:"cabundle"`
+	PublicKey   []byte          `cbor:"public_key"`
+	UserData    []byte          `cbor:"user_data"`
+	Nonce       []byte          `cbor:"nonce"`
+}
+
+func decodeCertificate(der []byte) Certificate {
+	result := Certificate{
+		Raw: base64.StdEncoding.EncodeToString(der),
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		result.ParseError = err.Error()
+		return result
+	}
+
+	result.Subject = certificate.Subject.String()
+	result.Issuer = certificate.Issuer.String()
+	result.NotBefore = certificate.NotBefore.UTC().Format(time.RFC3339)
+	result.NotAfter = certificate.NotAfter.UTC().Format(time.RFC3339)
+
+	return result
+}
+
+// Decode decodes an attestation document without verifying it. Binary values are hex-encoded.
+func Decode(reportBytes []byte) (*Document, error) {
+	var cose coseSign1
+	if err := cbor.Unmarshal(reportBytes, &cose); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedDocument, err)
+	}
+
+	var payload documentPayload
+	if err := cbor.Unmarshal(cose.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedDocument, err)
+	}
+
+	document := &Document{
+		ModuleID:    payload.ModuleID,
+		Timestamp:   payload.Timestamp,
+		Digest:      payload.Digest,
+		Certificate: decodeCertificate(payload.Certificate),
+		PCRs:        make(map[uint]string, len(payload.PCRs)),
+		CABundle:    make([]Certificate, 0, len(payload.CABundle)),
+		PublicKey:   hex.EncodeToString(payload.PublicKey),
+		UserData:    hex.EncodeToString(payload.UserData),
+		Nonce:       hex.EncodeToString(payload.Nonce),
+	}
+
+	for idx, value := range payload.PCRs {
+		document.PCRs[idx] = hex.EncodeToString(value)
+	}
+
+	for _, der := range payload.CABundle {
+		document.CABundle = append(document.CABundle, decodeCertificate(der))
+	}
+
+	return document, nil
+}
+
+// FailedStep returns the verification step that failed with an error from VerifyDocument
+func FailedStep(err error) string {
+	switch {
+	case errors.Is(err, ErrCose):
+		return STEP_COSE
+	case errors.Is(err, ErrDocument):
+		return STEP_DOCUMENT
+	case errors.Is(err, ErrDebugDocument):
+		return STEP_DEBUG
+	case errors.Is(err, ErrFields):
+		return STEP_FIELDS
+	case errors.Is(err, ErrCertificateChain):
+		return STEP_CERTIFICATE_CHAIN
+	case errors.Is(err, ErrSignature):
+		return STEP_SIGNATURE
+	default:
+		return STEP_UNKNOWN
+	}
+}
@@ -0,0 +1,153 @@
+package nitro
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func readTestReport(t *testing.T) []byte {
+	encoded, err := os.ReadFile("testdata/report.b64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encoded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return report
+}
+
+// re-encodes the report with a modified payload
+func modifyTestReport(t *testing.T, report []byte, modify func(payload *documentPayload)) []byte {
+	var cose coseSign1
+	if err := cbor.Unmarshal(report, &cose); err != nil {
+		t.Fatal(err)
+	}
+
+	var payload documentPayload
+	if err := cbor.Unmarshal(cose.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	modify(&payload)
+
+	var err error
+	if cose.Payload, err = cbor.Marshal(&payload); err != nil {
+		t.Fatal(err)
+	}
+
+	modified, err := cbor.Marshal(&cose)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return modified
+}
+
+func TestDecode(t *testing.T) {
+	document, err := Decode(readTestReport(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if document.ModuleID == "" || document.Digest != "SHA384" || document.Timestamp == 0 {
+		t.Errorf("Decode() = %+v", document)
+	}
+
+	if pcr0 := document.PCRs[0]; len(pcr0) != 96 {
+		t.Errorf("Decode() PCR0 = %q", pcr0)
+	}
+
+	if document.Certificate.Subject == "" || document.Certificate.NotAfter == "" || document.Certificate.ParseError != "" {
+		t.Errorf("Decode() certificate = %+v", document.Certificate)
+	}
+
+	if len(document.CABundle) == 0 || document.CABundle[0].Subject != "CN=aws.nitro-enclaves,OU=AWS,O=Amazon,C=US" {
+		t.Errorf("Decode() CA bundle = %+v", document.CABundle)
+	}
+
+	if _, err := Decode([]byte("not CBOR")); err == nil {
+		t.Error("Decode() decoded a malformed document")
+	}
+}
+
+func TestFailedStep(t *testing.T) {
+	if err := Init("", VERIFICATION_TIME_ATTESTATION, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	report := readTestReport(t)
+
+	tamperedSignature := bytes.Clone(report)
+	tamperedSignature[len(tamperedSignature)-1] ^= 0xff
+
+	notDocument, err := cbor.Marshal(&coseSign1{Protected: []byte{0xa0}, Unprotected: cbor.RawMessage{0xa0}, Payload: []byte("not CBOR")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		report []byte
+		want   string
+	}{
+		{
+			name:   "not CBOR",
+			report: []byte("not CBOR"),
+			want:   STEP_COSE,
+		},
+		{
+			name:   "not a document",
+			report: notDocument,
+			want:   STEP_DOCUMENT,
+		},
+		{
+			name:   "signature",
+			report: tamperedSignature,
+			want:   STEP_SIGNATURE,
+		},
+		{
+			name: "debug",
+			report: modifyTestReport(t, report, func(payload *documentPayload) {
+				payload.PCRs[0] = make([]byte, 48)
+			}),
+			want: STEP_DEBUG,
+		},
+		{
+			name: "missing fields",
+			report: modifyTestReport(t, report, func(payload *documentPayload) {
+				payload.ModuleID = ""
+			}),
+			want: STEP_FIELDS,
+		},
+		{
+			name: "certificate chain",
+			report: modifyTestReport(t, report, func(payload *documentPayload) {
+				payload.CABundle = payload.CABundle[:len(payload.CABundle)-1]
+			}),
+			want: STEP_CERTIFICATE_CHAIN,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyDocument(tt.report)
+			if err == nil {
+				t.Fatal("VerifyDocument() succeeded")
+			}
+
+			if got := FailedStep(err); got != tt.want {
+				t.Errorf("FailedStep(%v) = %s, want %s", err, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := VerifyDocument(report); err != nil {
+		t.Errorf("VerifyDocument() error = %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+// Package eif computes the Nitro enclave PCR values of an enclave image file (EIF) built with nitro-cli, without
+// nitro-cli or a Nitro instance.
+package eif
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	PCR_SIZE = sha512.Size384
+
+	// the EIF format limits the number of sections
+	maxSections = 32
+	// the signature section only has a few certificates and signatures
+	maxSignatureSectionSize = 1024 * 1024
+)
+
+// EIF section types
+const (
+	SECTION_KERNEL    = 1
+	SECTION_CMDLINE   = 2
+	SECTION_RAMDISK   = 3
+	SECTION_SIGNATURE = 4
+	SECTION_METADATA  = 5
+)
+
+var eifMagic = [4]byte{'.', 'e', 'i', 'f'}
+
+var (
+	ErrNotAnEif           = errors.New("eif: not an enclave image file")
+	ErrMalformed          = errors.New("eif: malformed enclave image file")
+	ErrMissingSections    = errors.New("eif: the enclave image file must have a kernel, a command line and a ramdisk")
+	ErrMalformedSignature = errors.New("eif: malformed signature section")
+)
+
+// the EIF header, all integers are big-endian
+type header struct {
+	Magic          [4]byte
+	Version        uint16
+	Flags          uint16
+	DefaultMemory  uint64
+	DefaultCpus    uint64
+	Reserved       uint16
+	NumSections    uint16
+	SectionOffsets [maxSections]uint64
+	SectionSizes   [maxSections]uint64
+	Unused         uint32
+	Crc32          uint32
+}
+
+type sectionHeader struct {
+	SectionType uint16
+	Flags       uint16
+	SectionSize uint64
+}
+
+// a signature of the PCR values in the signature section
+type pcrSignature struct {
+	SigningCertificate []byte `cbor:"signing_certificate"`
+	Signature          []byte `cbor:"signature"`
+}
+
+// Measurements are the PCR values of an enclave image file
+type Measurements struct {
+	// PCR0 (enclave image), PCR1 (kernel and bootstrap) and PCR2 (application)
+	PcrValues [3][PCR_SIZE]byte
+	// Signed is set if the image is signed, then Pcr8 has the PCR of the signing certificate
+	Signed bool
+	Pcr8   [PCR_SIZE]byte
+}
+
+// a PCR extended once from zero with the hash of the data written to it
+type pcr struct {
+	data hash.Hash
+}
+
+func newPcr() *pcr {
+	return &pcr{data: sha512.New384()}
+}
+
+func (p *pcr) Write(buf []byte) (int, error) {
+	return p.data.Write(buf)
+}
+
+func (p *pcr) value() [PCR_SIZE]byte {
+	extended := sha512.New384()
+	extended.Write(make([]byte, PCR_SIZE))
+	extended.Write(p.data.Sum(nil))
+
+	var result [PCR_SIZE]byte
+	copy(result[:], extended.Sum(nil))
+
+	return result
+}
+
+// the signing certificate is stored PEM-encoded, PCR8 is computed over the DER encoding
+func certificateDer(certificate []byte) []byte {
+	if block, _ := pem.Decode(certificate); block != nil {
+		return block.Bytes
+	}
+
+	return certificate
+}
+
+// Measure computes the PCR values of an enclave image file
+func Measure(r io.ReaderAt) (*Measurements, error) {
+	var eifHeader header
+	if err := binary.Read(io.NewSectionReader(r, 0, int64(binary.Size(eifHeader))), binary.BigEndian, &eifHeader); err != nil {
+		return nil, ErrNotAnEif
+	}
+
+	if eifHeader.Magic != eifMagic {
+		return nil, ErrNotAnEif
+	}
+
+	if eifHeader.NumSections > maxSections {
+		return nil, fmt.Errorf("%w: %d sections", ErrMalformed, eifHeader.NumSections)
+	}
+
+	imagePcr := newPcr()
+	bootstrapPcr := newPcr()
+	appPcr := newPcr()
+	certificatePcr := newPcr()
+
+	result := new(Measurements)
+	var hasKernel, hasCmdline bool
+	ramdisks := 0
+
+	for idx := 0; idx < int(eifHeader.NumSections); idx++ {
+		offset := eifHeader.SectionOffsets[idx]
+
+		var section sectionHeader
+		if err := binary.Read(io.NewSectionReader(r, int64(offset), int64(binary.Size(section))), binary.BigEndian, &section); err != nil {
+			return nil, fmt.Errorf("%w: section %d: %w", ErrMalformed, idx, err)
+		}
+
+		if section.SectionSize > 1<<62 {
+			return nil, fmt.Errorf("%w: section %d is too large", ErrMalformed, idx)
+		}
+
+		data := io.NewSectionReader(r, int64(offset)+int64(binary.Size(section)), int64(section.SectionSize))
+
+		var writer io.Writer
+		switch section.SectionType {
+		case SECTION_KERNEL:
+			hasKernel = true
+			writer = io.MultiWriter(imagePcr, bootstrapPcr)
+		case SECTION_CMDLINE:
+			hasCmdline = true
+			writer = io.MultiWriter(imagePcr, bootstrapPcr)
+		case SECTION_RAMDISK:
+			// the first ramdisk is the bootstrap one, the rest are the application
+			if ramdisks == 0 {
+				writer = io.MultiWriter(imagePcr, bootstrapPcr)
+			} else {
+				writer = io.MultiWriter(imagePcr, appPcr)
+			}
+			ramdisks++
+		case SECTION_SIGNATURE:
+			if section.SectionSize > maxSignatureSectionSize {
+				return nil, fmt.Errorf("%w: section %d is too large", ErrMalformedSignature, idx)
+			}
+
+			buf := make([]byte, section.SectionSize)
+			if _, err := io.ReadFull(data, buf); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrMalformedSignature, err)
+			}
+
+			var signatures []pcrSignature
+			if err := cbor.Unmarshal(buf, &signatures); err != nil || len(signatures) == 0 {
+				return nil, ErrMalformedSignature
+			}
+
+			certificatePcr.Write(certificateDer(signatures[0].SigningCertificate))
+			result.Signed = true
+			continue
+		default:
+			// the metadata isn't measured
+			continue
+		}
+
+		written, err := io.Copy(writer, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: section %d: %w", ErrMalformed, idx, err)
+		}
+		if uint64(written) != section.SectionSize {
+			return nil, fmt.Errorf("%w: section %d is truncated", ErrMalformed, idx)
+		}
+	}
+
+	if !hasKernel || !hasCmdline || ramdisks == 0 {
+		return nil, ErrMissingSections
+	}
+
+	result.PcrValues = [3][PCR_SIZE]byte{imagePcr.value(), bootstrapPcr.value(), appPcr.value()}
+	if result.Signed {
+		result.Pcr8 = certificatePcr.value()
+	}
+
+	return result, nil
+}
+
+// ReadFile computes the PCR values of an enclave image file
+func ReadFile(path string) (*Measurements, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Measure(file)
+}
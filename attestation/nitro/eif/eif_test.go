@@ -0,0 +1,138 @@
+package eif
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+type testSection struct {
+	sectionType uint16
+	data        []byte
+}
+
+// creates an enclave image file with the sections
+func newTestEif(sections []testSection) []byte {
+	eifHeader := header{
+		Magic:       eifMagic,
+		Version:     4,
+		NumSections: uint16(len(sections)),
+	}
+
+	body := new(bytes.Buffer)
+	offset := uint64(binary.Size(eifHeader))
+	for idx, section := range sections {
+		eifHeader.SectionOffsets[idx] = offset + uint64(body.Len())
+		eifHeader.SectionSizes[idx] = uint64(len(section.data))
+
+		binary.Write(body, binary.BigEndian, sectionHeader{SectionType: section.sectionType, SectionSize: uint64(len(section.data))})
+		body.Write(section.data)
+	}
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, eifHeader)
+	buf.Write(body.Bytes())
+
+	return buf.Bytes()
+}
+
+// the PCR extended from zero with the SHA-384 of the concatenated data
+func expectedPcr(data ...[]byte) [PCR_SIZE]byte {
+	digest := sha512.Sum384(bytes.Join(data, nil))
+	return sha512.Sum384(append(make([]byte, PCR_SIZE), digest[:]...))
+}
+
+func newSignatureSection(t *testing.T, certificate []byte) testSection {
+	signatures, err := cbor.Marshal([]pcrSignature{{SigningCertificate: certificate, Signature: []byte("signature")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testSection{SECTION_SIGNATURE, signatures}
+}
+
+func TestMeasure(t *testing.T) {
+	kernel := []byte("kernel")
+	cmdline := []byte("reboot=k panic=30 pci=off")
+	bootstrap := []byte("bootstrap ramdisk")
+	app1 := []byte("application ramdisk 1")
+	app2 := []byte("application ramdisk 2")
+	certificateDer := []byte("certificate")
+	certificatePem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDer})
+
+	unsigned := []testSection{
+		{SECTION_KERNEL, kernel},
+		{SECTION_CMDLINE, cmdline},
+		{SECTION_METADATA, []byte(`{"ImageName":"oracle"}`)},
+		{SECTION_RAMDISK, bootstrap},
+		{SECTION_RAMDISK, app1},
+		{SECTION_RAMDISK, app2},
+	}
+
+	wantPcrValues := [3][PCR_SIZE]byte{
+		expectedPcr(kernel, cmdline, bootstrap, app1, app2),
+		expectedPcr(kernel, cmdline, bootstrap),
+		expectedPcr(app1, app2),
+	}
+
+	truncated := newTestEif(unsigned)
+
+	tests := []struct {
+		name    string
+		eif     []byte
+		want    *Measurements
+		wantErr error
+	}{
+		{
+			name: "unsigned",
+			eif:  newTestEif(unsigned),
+			want: &Measurements{PcrValues: wantPcrValues},
+		},
+		{
+			name: "signed with a PEM certificate",
+			eif:  newTestEif(append(unsigned, newSignatureSection(t, certificatePem))),
+			want: &Measurements{PcrValues: wantPcrValues, Signed: true, Pcr8: expectedPcr(certificateDer)},
+		},
+		{
+			name: "signed with a DER certificate",
+			eif:  newTestEif(append(unsigned, newSignatureSection(t, certificateDer))),
+			want: &Measurements{PcrValues: wantPcrValues, Signed: true, Pcr8: expectedPcr(certificateDer)},
+		},
+		{
+			name:    "malformed signature",
+			eif:     newTestEif(append(unsigned, testSection{SECTION_SIGNATURE, []byte("signature")})),
+			wantErr: ErrMalformedSignature,
+		},
+		{
+			name:    "no ramdisk",
+			eif:     newTestEif(unsigned[:2]),
+			wantErr: ErrMissingSections,
+		},
+		{
+			name:    "truncated",
+			eif:     truncated[:len(truncated)-1],
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "not an EIF",
+			eif:     []byte("\x7fELF"),
+			wantErr: ErrNotAnEif,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Measure(bytes.NewReader(tt.eif))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Measure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && *got != *tt.want {
+				t.Errorf("Measure() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package nitro
+
+import (
+	"time"
+
+	"github.com/blocky/nitrite"
+)
+
+// the internals used by the tests of package nitro_test, which are outside the package to use testutil without an import cycle
+
+var AwsRootCaPem = awsRootCaPem
+
+// VerifyDocumentAt verifies an attestation document at now with a verifier that trusts rootCaPem
+func VerifyDocumentAt(rootCaPem []byte, verificationTime string, grace time.Duration, reportBytes []byte, now time.Time) (*nitrite.Document, error) {
+	v, err := newDocumentVerifier(rootCaPem, verificationTime, grace)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.verify(reportBytes, now)
+}
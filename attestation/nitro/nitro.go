@@ -5,49 +5,78 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"slices"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/blocky/nitrite"
 	"github.com/venture23-aleo/oracle-verification-backend/u128"
 )
 
-var (
-	verifier *nitrite.Verifier
-	initErr  error
-	initOnce sync.Once
-)
+// the verifier of the attestation documents, see Init
+var verifier *documentVerifier
+
+// Certificate is a certificate in an attestation document
+type Certificate struct {
+	Subject    string `json:"subject"`
+	Issuer     string `json:"issuer"`
+	NotBefore  string `json:"not_before"`
+	NotAfter   string `json:"not_after"`
+	Raw        string `json:"raw"`
+	ParseError string `json:"parse_error,omitempty"`
+}
 
+// Document is a decoded attestation document, see Decode
 type Document struct {
-	ModuleID    string `cbor:"module_id" json:"module_id"`
-	Timestamp   uint64 `cbor:"timestamp" json:"timestamp"`
-	Digest      string `cbor:"digest" json:"digest"`
-	Certificate string `cbor:"certificate" json:"certificate"`
+	ModuleID    string      `json:"module_id"`
+	Timestamp   uint64      `json:"timestamp"`
+	Digest      string      `json:"digest"`
+	Certificate Certificate `json:"certificate"`
 
-	PCRs     map[uint]string `cbor:"pcrs" json:"pcrs"`
-	CABundle []string        `cbor:"cabundle" json:"cabundle"`
+	PCRs     map[uint]string `json:"pcrs"`
+	CABundle []Certificate   `json:"cabundle"`
 
-	PublicKey string `cbor:"public_key" json:"public_key,omitempty"`
-	UserData  string `cbor:"user_data" json:"user_data,omitempty"`
-	Nonce     string `cbor:"nonce" json:"nonce,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+	UserData  string `json:"user_data,omitempty"`
+	Nonce     string `json:"nonce,omitempty"`
 }
 
-func Init() error {
-	initOnce.Do(func() {
-		log.Println("nitro: initializing verifier...")
-		verifier, initErr = nitrite.New(nitrite.WithVerificationTime(nitrite.AttestationTime))
-	})
+// Init sets up the verifier with the root CA certificate in the PEM file, or the AWS Nitro Enclaves root CA if the path
+// is empty. The verification time is one of VERIFICATION_TIME_*, the grace period is only used with
+// VERIFICATION_TIME_CURRENT_WITH_GRACE.
+func Init(rootCaPath string, verificationTime string, grace time.Duration) error {
+	log.Println("nitro: initializing verifier...")
+
+	rootCaPem := awsRootCaPem
+	if rootCaPath != "" {
+		var err error
+		if rootCaPem, err = os.ReadFile(rootCaPath); err != nil {
+			return fmt.Errorf("nitro: failed to read the root CA: %w", err)
+		}
+	}
+
+	v, err := newDocumentVerifier(rootCaPem, verificationTime, grace)
+	if err != nil {
+		return err
+	}
 
-	return initErr
+	verifier = v
+
+	return nil
 }
 
-func VerifyNitroReport(reportBytes []byte, nonceString string, targetPcrValues [3]string) (*nitrite.Document, error) {
+// VerifyDocument verifies the signature and the certificate chain of a Nitro attestation document without checking its contents
+func VerifyDocument(reportBytes []byte) (*nitrite.Document, error) {
 	if verifier == nil {
 		return nil, errors.New("nitro verifier is not initialized")
 	}
 
-	report, err := verifier.Verify(reportBytes)
+	return verifier.verify(reportBytes, time.Now())
+}
+
+func VerifyNitroReport(reportBytes []byte, nonceString string, targetPcrValues [3]string) (*nitrite.Document, error) {
+	report, err := VerifyDocument(reportBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -73,9 +102,7 @@ func VerifyNitroReport(reportBytes []byte, nonceString string, targetPcrValues [
 		return nil, errors.New("unexpected length of the attestation report data")
 	}
 
-	nitriteDocument := nitrite.Document(report)
-
-	return &nitriteDocument, nil
+	return report, nil
 }
 
 func FormatPcrValues(pcrs [3][48]byte) string {
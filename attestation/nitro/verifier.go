@@ -0,0 +1,134 @@
+package nitro
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+)
+
+// the report type of Nitro attestation documents
+const TEE_TYPE = "nitro"
+
+// the size of a PCR value
+const PCR_VALUE_SIZE = 48
+
+// the measurements of Nitro attestation documents, "pcr0" to "pcr2"
+var MEASUREMENT_PCRS = [3]string{"pcr0", "pcr1", "pcr2"}
+
+// PcrValuesInfo is a set of PCR values in the encodings of /info
+type PcrValuesInfo struct {
+	Hex    [3]string `json:"hexEncoded"`
+	Base64 [3]string `json:"base64Encoded"`
+	Aleo   string    `json:"aleoEncoded"`
+}
+
+// PolicyConfig is the "nitro" policy block of a profile
+type PolicyConfig struct {
+	// PCR0-2, hex- or base64-encoded. The PCR values after the first 3 aren't checked.
+	PcrValuesTarget []string `json:"pcrValuesTarget"`
+}
+
+// Verifier verifies Nitro attestation documents, the policy has the target PCR values
+type Verifier struct{}
+
+func init() {
+	tee.Register(Verifier{})
+}
+
+func (Verifier) Type() string {
+	return TEE_TYPE
+}
+
+// Policy parses a PolicyConfig
+func (Verifier) Policy(block json.RawMessage) (tee.Policy, error) {
+	var policyConfig PolicyConfig
+	if err := tee.DecodePolicy(block, &policyConfig); err != nil {
+		return tee.Policy{}, err
+	}
+
+	measurements := make(map[string]string, len(MEASUREMENT_PCRS))
+	for idx, name := range MEASUREMENT_PCRS {
+		measurements[name] = ""
+		if idx >= len(policyConfig.PcrValuesTarget) {
+			continue
+		}
+
+		pcr, ok := tee.NormalizeMeasurement(policyConfig.PcrValuesTarget[idx], PCR_VALUE_SIZE)
+		if !ok {
+			return tee.Policy{}, fmt.Errorf("\"pcrValuesTarget\" values must be %d bytes hex- or base64-encoded", PCR_VALUE_SIZE)
+		}
+		measurements[name] = pcr
+	}
+
+	return tee.Policy{Measurements: measurements}, nil
+}
+
+func targetPcrValues(policy tee.Policy) [3]string {
+	var pcrValues [3]string
+	for idx, name := range MEASUREMENT_PCRS {
+		pcrValues[idx] = policy.Measurements[name]
+	}
+
+	return pcrValues
+}
+
+func (Verifier) Verify(reportBytes []byte, nonce string, policy tee.Policy) (*tee.Result, error) {
+	document, err := VerifyNitroReport(reportBytes, nonce, targetPcrValues(policy))
+	if err != nil {
+		return nil, err
+	}
+
+	measurements := make(map[string]string, len(MEASUREMENT_PCRS))
+	for idx, name := range MEASUREMENT_PCRS {
+		measurements[name] = hex.EncodeToString(document.PCRs[uint(idx)])
+	}
+
+	result := &tee.Result{
+		Type:         TEE_TYPE,
+		Measurements: measurements,
+		UserData:     document.UserData,
+		Nonce:        document.Nonce,
+		Timestamp:    time.UnixMilli(int64(document.Timestamp)).UTC(),
+		Report:       document,
+	}
+	if len(result.Nonce) == 0 {
+		result.Nonce = nil
+	}
+
+	return result, nil
+}
+
+// DescribePolicy describes the target PCR values as PcrValuesInfo, missing PCR values are encoded as zeroes
+func (Verifier) DescribePolicy(policy tee.Policy) (any, error) {
+	pcrValues := targetPcrValues(policy)
+
+	var pcrBytes [3][48]byte
+
+	for idx, pcr := range pcrValues {
+		if pcr == "" {
+			continue
+		}
+		buf, err := hex.DecodeString(pcr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hex-decode PCR value: %w", err)
+		}
+		if len(buf) < 48 {
+			return nil, fmt.Errorf("PCR value shorter than 48 bytes")
+		}
+		copy(pcrBytes[idx][:], buf[:48])
+	}
+
+	return PcrValuesInfo{
+		Hex: pcrValues,
+		Base64: [3]string{
+			base64.StdEncoding.EncodeToString(pcrBytes[0][:]),
+			base64.StdEncoding.EncodeToString(pcrBytes[1][:]),
+			base64.StdEncoding.EncodeToString(pcrBytes[2][:]),
+		},
+		Aleo: FormatPcrValues(pcrBytes),
+	}, nil
+}
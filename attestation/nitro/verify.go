@@ -0,0 +1,216 @@
+package nitro
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha512"
+	"crypto/x509"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/blocky/nitrite"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// the COSE algorithm of the attestation document signatures, ECDSA P-384 with SHA-384
+const COSE_ALGORITHM_ES384 = -35
+
+// Verification times
+const (
+	// check the certificate chain of an attestation document at the time it was created
+	VERIFICATION_TIME_ATTESTATION = "attestation"
+	// check the certificate chain of an attestation document at the current time
+	VERIFICATION_TIME_CURRENT = "current"
+	// same as VERIFICATION_TIME_CURRENT, also accepting a certificate chain that was valid the grace period ago
+	VERIFICATION_TIME_CURRENT_WITH_GRACE = "currentWithGrace"
+)
+
+// the AWS Nitro Enclaves root certificate, see https://docs.aws.amazon.com/enclaves/latest/user/verify-root.html
+//
+//go:embed aws_nitro_enclaves_root_g1.pem
+var awsRootCaPem []byte
+
+// the protected header of the COSE_Sign1 structure, the algorithm is an integer or a name
+type coseHeader struct {
+	Alg any `cbor:"1,keyasint,omitempty"`
+}
+
+// the root certificates of the document certificate chains, see nitrite.Document.VerifyCertificates
+type rootCertificates struct {
+	pool *x509.CertPool
+}
+
+func (roots rootCertificates) Roots() (*x509.CertPool, error) {
+	return roots.pool, nil
+}
+
+// documentVerifier verifies the COSE_Sign1 signature and the certificate chain of attestation documents, the same way
+// the nitrite verifier does, with a configurable root CA and verification time
+type documentVerifier struct {
+	roots            rootCertificates
+	verificationTime string
+	grace            time.Duration
+}
+
+func newDocumentVerifier(rootCaPem []byte, verificationTime string, grace time.Duration) (*documentVerifier, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(rootCaPem) {
+		return nil, errors.New("nitro: no root CA certificate in the PEM data")
+	}
+
+	switch verificationTime {
+	case VERIFICATION_TIME_ATTESTATION, VERIFICATION_TIME_CURRENT, VERIFICATION_TIME_CURRENT_WITH_GRACE:
+	default:
+		return nil, fmt.Errorf("nitro: unknown verification time %q", verificationTime)
+	}
+
+	return &documentVerifier{
+		roots:            rootCertificates{pool},
+		verificationTime: verificationTime,
+		grace:            grace,
+	}, nil
+}
+
+// the times to check the certificate chain of a document at, the chain has to be valid at one of them
+func (v *documentVerifier) verificationTimes(document *nitrite.Document, now time.Time) []time.Time {
+	switch v.verificationTime {
+	case VERIFICATION_TIME_CURRENT:
+		return []time.Time{now}
+	case VERIFICATION_TIME_CURRENT_WITH_GRACE:
+		// the document was created when its certificate chain was valid, as long as that's within the grace period
+		gracePeriodTime := document.CreatedAt()
+		if gracePeriodTime.Before(now.Add(-v.grace)) {
+			gracePeriodTime = now.Add(-v.grace)
+		} else if gracePeriodTime.After(now) {
+			gracePeriodTime = now
+		}
+		return []time.Time{now, gracePeriodTime}
+	default:
+		return []time.Time{document.CreatedAt()}
+	}
+}
+
+// verify verifies an attestation document the same way as the nitrite verifier, the errors wrap the error of the
+// failed step, see FailedStep
+func (v *documentVerifier) verify(reportBytes []byte, now time.Time) (*nitrite.Document, error) {
+	var cose coseSign1
+	if err := cbor.Unmarshal(reportBytes, &cose); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCose, err)
+	}
+
+	var document nitrite.Document
+	if err := document.UnmarshalBinary(cose.Payload); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDocument, err)
+	}
+
+	debug, err := document.Debug()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDebugDocument, err)
+	}
+	if debug {
+		return nil, ErrDebugDocument
+	}
+
+	if err := document.VerifyMandatoryFields(); err != nil {
+		return nil, fmt.Errorf("%w: mandatory fields: %w", ErrFields, err)
+	}
+	if err := document.VerifyOptionalFields(); err != nil {
+		return nil, fmt.Errorf("%w: optional fields: %w", ErrFields, err)
+	}
+
+	var certificates []*x509.Certificate
+	for idx, verificationTime := range v.verificationTimes(&document, now) {
+		chain, chainErr := document.VerifyCertificates(v.roots, func(nitrite.Document) time.Time {
+			return verificationTime
+		})
+		if chainErr == nil {
+			certificates, err = chain, nil
+			break
+		}
+
+		// report the error at the first verification time
+		if idx == 0 {
+			err = chainErr
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCertificateChain, err)
+	}
+
+	if len(certificates) < 1 {
+		return nil, fmt.Errorf("%w: certificates chain is empty", ErrCertificateChain)
+	}
+
+	publicKey, ok := certificates[0].PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: the document certificate doesn't have an ECDSA key", ErrSignature)
+	}
+
+	if err := cose.verify(publicKey); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSignature, err)
+	}
+
+	return &document, nil
+}
+
+// the signed bytes of a COSE_Sign1 structure, see https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
+func (cose *coseSign1) sigStructure() ([]byte, error) {
+	sigStructure := struct {
+		_           struct{} `cbor:",toarray"`
+		Context     string
+		Protected   []byte
+		ExternalAAD []byte
+		Payload     []byte
+	}{
+		Context:     "Signature1",
+		Protected:   cose.Protected,
+		ExternalAAD: []byte{},
+		Payload:     cose.Payload,
+	}
+
+	return cbor.Marshal(&sigStructure)
+}
+
+// verifies the ES384 signature of a COSE_Sign1 structure
+func (cose *coseSign1) verify(publicKey *ecdsa.PublicKey) error {
+	if len(cose.Protected) == 0 || len(cose.Payload) == 0 || len(cose.Signature) == 0 {
+		return errors.New("missing cose protected headers, payload or signature")
+	}
+
+	var header coseHeader
+	if err := cbor.Unmarshal(cose.Protected, &header); err != nil {
+		return fmt.Errorf("unmarshaling cose protected header: %w", err)
+	}
+
+	switch header.Alg {
+	case int64(COSE_ALGORITHM_ES384), "ES384":
+	default:
+		return fmt.Errorf("unsupported signing algorithm %v", header.Alg)
+	}
+
+	if publicKey.Curve != elliptic.P384() {
+		return fmt.Errorf("the signing key isn't a P-384 key but %s", publicKey.Curve.Params().Name)
+	}
+
+	sigStructure, err := cose.sigStructure()
+	if err != nil {
+		return fmt.Errorf("marshaling cose signature struct: %w", err)
+	}
+
+	if len(cose.Signature) != 2*sha512.Size384 {
+		return fmt.Errorf("expected signature length %d, got %d", 2*sha512.Size384, len(cose.Signature))
+	}
+
+	digest := sha512.Sum384(sigStructure)
+	r := new(big.Int).SetBytes(cose.Signature[:sha512.Size384])
+	s := new(big.Int).SetBytes(cose.Signature[sha512.Size384:])
+
+	if !ecdsa.Verify(publicKey, digest[:], r, s) {
+		return errors.New("failed to verify ecdsa signature")
+	}
+
+	return nil
+}
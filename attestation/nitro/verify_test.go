@@ -0,0 +1,106 @@
+package nitro_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+	"github.com/venture23-aleo/oracle-verification-backend/testutil"
+)
+
+// creates an attestation document at the timestamp, the enclave certificate is valid for three hours from then
+func newTestDocument(t *testing.T, ca *testutil.NitroCA, timestamp time.Time) []byte {
+	return ca.Document(t, testutil.NitroDocument{
+		Pcrs:      [3][48]byte{[48]byte(bytes.Repeat([]byte{0xaa}, 48)), [48]byte(bytes.Repeat([]byte{0xbb}, 48)), [48]byte(bytes.Repeat([]byte{0xcc}, 48))},
+		UserData:  []byte("0123456789abcdef"),
+		Timestamp: timestamp,
+	})
+}
+
+func TestDocumentVerifier(t *testing.T) {
+	ca := testutil.NewNitroCA(t)
+	now := time.Now()
+
+	fresh := newTestDocument(t, ca, now.Add(-time.Minute))
+	// the enclave certificate expired an hour ago
+	stale := newTestDocument(t, ca, now.Add(-4*time.Hour))
+
+	tamperedSignature := bytes.Clone(fresh)
+	tamperedSignature[len(tamperedSignature)-1] ^= 0xff
+
+	tests := []struct {
+		name             string
+		rootCaPem        []byte
+		verificationTime string
+		grace            time.Duration
+		report           []byte
+		wantStep         string
+	}{
+		{name: "attestation time", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_ATTESTATION, report: fresh},
+		{name: "stale at attestation time", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_ATTESTATION, report: stale},
+		{name: "current time", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_CURRENT, report: fresh},
+		{name: "stale at current time", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_CURRENT, report: stale, wantStep: nitro.STEP_CERTIFICATE_CHAIN},
+		{name: "fresh within grace", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_CURRENT_WITH_GRACE, grace: 2 * time.Hour, report: fresh},
+		{name: "stale within grace", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_CURRENT_WITH_GRACE, grace: 2 * time.Hour, report: stale},
+		{name: "stale within long grace", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_CURRENT_WITH_GRACE, grace: 8 * time.Hour, report: stale},
+		{name: "stale past grace", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_CURRENT_WITH_GRACE, grace: 30 * time.Minute, report: stale, wantStep: nitro.STEP_CERTIFICATE_CHAIN},
+		{name: "AWS root", rootCaPem: nitro.AwsRootCaPem, verificationTime: nitro.VERIFICATION_TIME_ATTESTATION, report: fresh, wantStep: nitro.STEP_CERTIFICATE_CHAIN},
+		{name: "tampered signature", rootCaPem: ca.RootPem(), verificationTime: nitro.VERIFICATION_TIME_ATTESTATION, report: tamperedSignature, wantStep: nitro.STEP_SIGNATURE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := nitro.VerifyDocumentAt(tt.rootCaPem, tt.verificationTime, tt.grace, tt.report, now)
+			if tt.wantStep != "" {
+				if err == nil {
+					t.Fatal("VerifyDocumentAt() succeeded")
+				}
+				if got := nitro.FailedStep(err); got != tt.wantStep {
+					t.Errorf("FailedStep(%v) = %s, want %s", err, got, tt.wantStep)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("VerifyDocumentAt() error = %v", err)
+			}
+			if !bytes.Equal(document.PCRs[1], bytes.Repeat([]byte{0xbb}, 48)) || string(document.UserData) != "0123456789abcdef" {
+				t.Errorf("VerifyDocumentAt() = %+v", document)
+			}
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	ca := testutil.NewNitroCA(t)
+
+	rootCaPath := filepath.Join(t.TempDir(), "root.pem")
+	if err := os.WriteFile(rootCaPath, ca.RootPem(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nitro.Init(rootCaPath, nitro.VERIFICATION_TIME_CURRENT, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nitro.VerifyDocument(newTestDocument(t, ca, time.Now())); err != nil {
+		t.Errorf("VerifyDocument() error = %v", err)
+	}
+
+	if err := nitro.Init(filepath.Join(t.TempDir(), "missing.pem"), nitro.VERIFICATION_TIME_CURRENT, 0); err == nil {
+		t.Error("Init() succeeded with a missing root CA")
+	}
+
+	if err := os.WriteFile(rootCaPath, []byte("not PEM"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := nitro.Init(rootCaPath, nitro.VERIFICATION_TIME_CURRENT, 0); err == nil || !strings.Contains(err.Error(), "no root CA") {
+		t.Errorf("Init() error = %v", err)
+	}
+
+	if err := nitro.Init("", "yesterday", 0); err == nil {
+		t.Error("Init() succeeded with an unknown verification time")
+	}
+}
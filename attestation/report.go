@@ -0,0 +1,105 @@
+package attestation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Report kinds
+const (
+	// Report attesting to a single attestation request
+	REPORT_KIND_SINGLE_TOKEN string = "singleToken"
+	// Report attesting to multiple attestation requests, e.g. a price feed of several tokens
+	REPORT_KIND_MULTIPLE_TOKENS string = "multipleTokens"
+)
+
+var (
+	ErrReportNotAnObject         = errors.New("report is not a JSON object")
+	ErrReportUnknownShape        = errors.New("report has unknown shape, expected attestationRequest or attestationResults")
+	ErrReportAmbiguousShape      = errors.New("report has both attestationRequest and attestationResults")
+	ErrReportNoAttestationResult = errors.New("multiple tokens report has no attestation results")
+)
+
+// Report is a report submitted for verification, either a single or a multiple tokens one.
+// Exactly one of Single and Multiple is set, depending on Kind.
+type Report struct {
+	Kind     string
+	Single   *AttestationResponse
+	Multiple *AttestationResponseMultipleTokens
+	// the collateral supplied with the report to verify it against, nil if the configured source is used
+	Collateral json.RawMessage
+}
+
+// the fields that tell the report kinds apart, the report is then decoded into the type of its kind
+type reportProbe struct {
+	Kind               *string           `json:"kind"`
+	AttestationRequest json.RawMessage   `json:"attestationRequest"`
+	AttestationResults []json.RawMessage `json:"attestationResults"`
+	Collateral         json.RawMessage   `json:"collateral"`
+}
+
+// DecodeReport decodes a report using the kind field. If the report doesn't have the kind field, then
+// the kind is inferred from the presence of the attestationRequest or attestationResults fields.
+// Empty attestationResults are treated as absent, as older clients send them with single token reports.
+func DecodeReport(data []byte) (*Report, error) {
+	var probe reportProbe
+	if err := json.Unmarshal(data, &probe); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return nil, err
+		}
+		return nil, ErrReportNotAnObject
+	}
+
+	hasResults := len(probe.AttestationResults) != 0
+	hasRequest := len(probe.AttestationRequest) != 0 && string(probe.AttestationRequest) != "null"
+
+	var kind string
+	if probe.Kind != nil {
+		kind = *probe.Kind
+	} else {
+		// older clients don't send the report kind
+		switch {
+		case hasResults && hasRequest:
+			return nil, ErrReportAmbiguousShape
+		case hasResults:
+			kind = REPORT_KIND_MULTIPLE_TOKENS
+		case hasRequest:
+			kind = REPORT_KIND_SINGLE_TOKEN
+		default:
+			return nil, ErrReportUnknownShape
+		}
+	}
+
+	report := &Report{Kind: kind}
+	if len(probe.Collateral) != 0 && string(probe.Collateral) != "null" {
+		report.Collateral = probe.Collateral
+	}
+
+	switch kind {
+	case REPORT_KIND_SINGLE_TOKEN:
+		if hasResults {
+			return nil, fmt.Errorf("%s report must not have attestationResults", kind)
+		}
+		if err := json.Unmarshal(data, &report.Single); err != nil {
+			return nil, err
+		}
+
+	case REPORT_KIND_MULTIPLE_TOKENS:
+		if hasRequest {
+			return nil, fmt.Errorf("%s report must not have attestationRequest", kind)
+		}
+		if !hasResults {
+			return nil, ErrReportNoAttestationResult
+		}
+		if err := json.Unmarshal(data, &report.Multiple); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown report kind \"%s\"", kind)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,139 @@
+package attestation
+
+import (
+	"testing"
+)
+
+func Test_DecodeReport(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           string
+		wantKind       string
+		wantErr        bool
+		wantSingle     bool
+		wantMultiple   bool
+		wantCollateral string
+	}{
+		{
+			name:       "explicit single token",
+			data:       `{"kind": "singleToken", "reportType": "sgx", "attestationRequest": {"url": "google.com"}}`,
+			wantKind:   REPORT_KIND_SINGLE_TOKEN,
+			wantSingle: true,
+		},
+		{
+			name:         "explicit multiple tokens",
+			data:         `{"kind": "multipleTokens", "reportType": "nitro", "attestationResults": [{"attestationData": "1"}]}`,
+			wantKind:     REPORT_KIND_MULTIPLE_TOKENS,
+			wantMultiple: true,
+		},
+		{
+			name:           "supplied collateral",
+			data:           `{"kind": "singleToken", "reportType": "sgx", "attestationRequest": {"url": "google.com"}, "collateral": {"rootCa": ""}}`,
+			wantKind:       REPORT_KIND_SINGLE_TOKEN,
+			wantSingle:     true,
+			wantCollateral: `{"rootCa": ""}`,
+		},
+		{
+			name:       "null collateral",
+			data:       `{"kind": "singleToken", "reportType": "sgx", "attestationRequest": {"url": "google.com"}, "collateral": null}`,
+			wantKind:   REPORT_KIND_SINGLE_TOKEN,
+			wantSingle: true,
+		},
+		{
+			name:       "inferred single token",
+			data:       `{"reportType": "sgx", "attestationData": "1", "attestationRequest": {"url": "google.com"}}`,
+			wantKind:   REPORT_KIND_SINGLE_TOKEN,
+			wantSingle: true,
+		},
+		{
+			name:         "inferred multiple tokens",
+			data:         `{"reportType": "sgx", "attestationResults": [{"attestationData": "1"}]}`,
+			wantKind:     REPORT_KIND_MULTIPLE_TOKENS,
+			wantMultiple: true,
+		},
+		{
+			name:       "inferred single token with empty results",
+			data:       `{"reportType": "sgx", "attestationData": "1", "attestationRequest": {"url": "google.com"}, "attestationResults": []}`,
+			wantKind:   REPORT_KIND_SINGLE_TOKEN,
+			wantSingle: true,
+		},
+		{
+			name:       "explicit single token with empty results",
+			data:       `{"kind": "singleToken", "reportType": "sgx", "attestationRequest": {"url": "google.com"}, "attestationResults": []}`,
+			wantKind:   REPORT_KIND_SINGLE_TOKEN,
+			wantSingle: true,
+		},
+		{
+			name:    "inferred multiple tokens with empty results",
+			data:    `{"reportType": "sgx", "attestationResults": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "explicit multiple tokens with empty results",
+			data:    `{"kind": "multipleTokens", "reportType": "sgx", "attestationResults": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "ambiguous",
+			data:    `{"reportType": "sgx", "attestationRequest": {"url": "google.com"}, "attestationResults": [{"attestationData": "1"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "kind doesn't match the shape",
+			data:    `{"kind": "singleToken", "attestationResults": [{"attestationData": "1"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			data:    `{"kind": "everyToken", "attestationRequest": {"url": "google.com"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown shape",
+			data:    `{"reportType": "sgx", "attestationReport": "AAAA"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			data:    `[1, 2, 3]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid field type",
+			data:    `{"attestationResults": "abc"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid field type of the kind",
+			data:    `{"attestationRequest": {"url": "google.com"}, "responseStatusCode": "200"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeReport([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeReport() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Kind != tt.wantKind {
+				t.Errorf("DecodeReport() kind = %v, want %v", got.Kind, tt.wantKind)
+			}
+			if (got.Single != nil) != tt.wantSingle || (got.Multiple != nil) != tt.wantMultiple {
+				t.Errorf("DecodeReport() single = %v, multiple = %v", got.Single, got.Multiple)
+			}
+			if got.Single != nil && (got.Single.ReportType == "" || got.Single.AttestationRequest.Url != "google.com") {
+				t.Errorf("DecodeReport() single = %+v", got.Single)
+			}
+			if got.Multiple != nil && (got.Multiple.ReportType == "" || got.Multiple.AttestationResults[0].AttestationData != "1") {
+				t.Errorf("DecodeReport() multiple = %+v", got.Multiple)
+			}
+			if string(got.Collateral) != tt.wantCollateral {
+				t.Errorf("DecodeReport() collateral = %s, want %s", got.Collateral, tt.wantCollateral)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package collateral
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrNotCached = errors.New("collateral: no cached collateral for the platform")
+
+// the prefix of the files with the TDX collateral of a platform, e.g. tdx-00806f050000.json
+const TDX_FILE_PREFIX = "tdx-"
+
+// Cache is a directory of collateral bundles, one file per FMSPC named after it, e.g. 00906ed50000.json. TDX platforms
+// have a second bundle with the TDX collateral, see TDX_FILE_PREFIX.
+type Cache struct {
+	dir string
+}
+
+func NewCache(dir string) *Cache {
+	return &Cache{
+		dir: dir,
+	}
+}
+
+func (c *Cache) path(name string) string {
+	return filepath.Join(c.dir, strings.ToLower(name)+".json")
+}
+
+// Load loads the collateral for a platform
+func (c *Cache) Load(fmspc string) (*Collateral, error) {
+	return c.load(fmspc)
+}
+
+// LoadTd loads the TDX collateral for a platform
+func (c *Cache) LoadTd(fmspc string) (*Collateral, error) {
+	return c.load(TDX_FILE_PREFIX + fmspc)
+}
+
+func (c *Cache) load(name string) (*Collateral, error) {
+	content, err := os.ReadFile(c.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotCached, filepath.Base(c.path(name)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	collateral := new(Collateral)
+	if err := json.Unmarshal(content, collateral); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrMalformedCollateral, c.path(name), err)
+	}
+
+	return collateral, nil
+}
+
+// Store stores the collateral for a platform, replacing the collateral stored before
+func (c *Cache) Store(fmspc string, collateral *Collateral) error {
+	return c.store(fmspc, collateral)
+}
+
+// StoreTd stores the TDX collateral for a platform, replacing the TDX collateral stored before
+func (c *Cache) StoreTd(fmspc string, collateral *Collateral) error {
+	return c.store(TDX_FILE_PREFIX+fmspc, collateral)
+}
+
+func (c *Cache) store(name string, collateral *Collateral) error {
+	content, err := json.MarshalIndent(collateral, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so that a verifier never reads a partial bundle
+	tmpPath := c.path(name) + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, c.path(name))
+}
+
+// Verify verifies a quote, either bare or in an EGo remote report, against the cached collateral of the platform that
+// created it, see Verify
+func (c *Cache) Verify(reportBytes []byte, pin RootCaPin, now time.Time) (*Result, error) {
+	pck, err := ReadPckCertificate(reportBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	collateral, err := c.Load(pck.Fmspc)
+	if err != nil {
+		return nil, err
+	}
+
+	return Verify(reportBytes, collateral, pin, now)
+}
+
+// VerifyTd verifies a TD quote against the cached TDX collateral of the platform that created it
+func (c *Cache) VerifyTd(reportBytes []byte, pin RootCaPin, now time.Time) (*TdResult, error) {
+	pck, err := ReadPckCertificate(reportBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	collateral, err := c.LoadTd(pck.Fmspc)
+	if err != nil {
+		return nil, err
+	}
+
+	return VerifyTd(reportBytes, collateral, pin, now)
+}
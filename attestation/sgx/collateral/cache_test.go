@@ -0,0 +1,46 @@
+package collateral
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+)
+
+func TestCache(t *testing.T) {
+	pki := newTestPki(t, 3, 13)
+	report := pki.quote(t, testReportBody())
+
+	cache := NewCache(t.TempDir())
+
+	if _, err := cache.Verify(report, pki.pin(), time.Now()); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("Verify() error = %v, want %v", err, ErrNotCached)
+	}
+
+	collateral := pki.collateral(t, testCollateralOptions{})
+	if err := cache.Store("00906ED50000", collateral); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := cache.Load(testFmspc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *loaded != *collateral {
+		t.Errorf("Load() = %+v, want %+v", loaded, collateral)
+	}
+
+	result, err := cache.Verify(report, pki.pin(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TcbStatus != tcbstatus.UpToDate {
+		t.Errorf("Verify() = %+v", result)
+	}
+
+	// the cached root CA isn't trusted unless it's pinned
+	if _, err := cache.Verify(report, RootCaPin{}, time.Now()); !errors.Is(err, ErrUntrustedRootCa) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrUntrustedRootCa)
+	}
+}
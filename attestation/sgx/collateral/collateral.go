@@ -0,0 +1,442 @@
+// Package collateral verifies SGX DCAP quotes offline, against a bundle of the collateral that the quote provider
+// would otherwise fetch from the PCCS at verification time.
+package collateral
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+var (
+	ErrMalformedCollateral   = errors.New("collateral: malformed collateral")
+	ErrUnsupportedCollateral = errors.New("collateral: unsupported collateral")
+	ErrUnsupportedQuote      = errors.New("collateral: unsupported quote")
+	ErrCertificateChain      = errors.New("collateral: certificate chain verification failed")
+	ErrRevoked               = errors.New("collateral: certificate is revoked")
+	ErrInvalidSignature      = errors.New("collateral: invalid signature")
+	ErrCollateralMismatch    = errors.New("collateral: the collateral is for another platform")
+	ErrQeIdentityMismatch    = errors.New("collateral: the quoting enclave doesn't match the QE identity")
+	ErrTcbLevelNotFound      = errors.New("collateral: no matching TCB level")
+	ErrUntrustedRootCa       = errors.New("collateral: the collateral root CA isn't the trusted root CA")
+	ErrUntrustedTcbSigner    = errors.New("collateral: the collateral isn't signed by the TCB signing certificate")
+	ErrExpiredCollateral     = errors.New("collateral: the collateral is past its next update")
+)
+
+// TCB_SIGNING_COMMON_NAME is the subject common name of the Intel SGX TCB Signing certificate, which signs the TCB info
+// and the QE identity. It's issued directly by the root CA.
+const TCB_SIGNING_COMMON_NAME = "Intel SGX TCB Signing"
+
+// INTEL_ROOT_CA_FINGERPRINT is the SHA-256 fingerprint of the Intel SGX root CA certificate, which issues the PCK
+// certificates and the TCB signing certificate of SGX and TDX platforms, see
+// https://certificates.trustedservices.intel.com/Intel_SGX_Provisioning_Certification_RootCA.pem
+const INTEL_ROOT_CA_FINGERPRINT = "44a0196b2b99f889b8e149e95b807a350e7424964399e885a7cbb8ccfab674d3"
+
+// RootCaPin is the root CA that collateral must be issued by. The root CA certificate in the collateral is only
+// trusted if it's the pinned one. The zero value pins the Intel SGX root CA.
+type RootCaPin struct {
+	fingerprint string
+}
+
+// NewRootCaPin pins the root CA with the SHA-256 fingerprint, hex-encoded, or the Intel SGX root CA if it's empty.
+// Other root CAs are only pinned to verify the collateral of test PKIs.
+func NewRootCaPin(fingerprint string) RootCaPin {
+	if fingerprint == "" {
+		fingerprint = INTEL_ROOT_CA_FINGERPRINT
+	}
+
+	return RootCaPin{fingerprint: strings.ToLower(fingerprint)}
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the pinned root CA, hex-encoded
+func (p RootCaPin) Fingerprint() string {
+	if p.fingerprint == "" {
+		return INTEL_ROOT_CA_FINGERPRINT
+	}
+
+	return p.fingerprint
+}
+
+// Check returns ErrUntrustedRootCa if the root CA isn't the pinned one
+func (p RootCaPin) Check(root *x509.Certificate) error {
+	if fingerprint := Fingerprint(root); fingerprint != p.Fingerprint() {
+		return fmt.Errorf("%w: %s", ErrUntrustedRootCa, fingerprint)
+	}
+
+	return nil
+}
+
+// Collateral is the collateral for verifying the quotes of platforms with one FMSPC. Certificates and CRLs are
+// PEM-encoded. The TCB info and the QE identity are the response bodies of the PCS, as they were served, because their
+// signatures are over the original JSON.
+type Collateral struct {
+	RootCa                string `json:"rootCa"`
+	RootCaCrl             string `json:"rootCaCrl"`
+	PckCrl                string `json:"pckCrl"`
+	TcbInfo               string `json:"tcbInfo"`
+	TcbInfoIssuerChain    string `json:"tcbInfoIssuerChain"`
+	QeIdentity            string `json:"qeIdentity"`
+	QeIdentityIssuerChain string `json:"qeIdentityIssuerChain"`
+}
+
+// Result is the result of verifying a quote. The quote is valid, the TCB status still has to be checked.
+type Result struct {
+	Quote     *quote.Quote
+	Pck       *PckCertificate
+	TcbStatus tcbstatus.Status
+	// the advisories of the platform and the quoting enclave TCB levels
+	TcbAdvisories []string
+	// the SHA-256 fingerprint of the root CA certificate the quote was verified against, hex-encoded
+	RootCaFingerprint string
+	// the TCB info, the QE identity or a CRL is past its next update
+	Expired bool
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a certificate, hex-encoded
+func Fingerprint(certificate *x509.Certificate) string {
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return hex.EncodeToString(fingerprint[:])
+}
+
+// ParseCrl parses a CRL, either PEM-encoded, hex-encoded DER as served by the PCCS, or DER
+func ParseCrl(data []byte) (*x509.RevocationList, error) {
+	der := data
+	if block, _ := pem.Decode(data); block != nil {
+		der = block.Bytes
+	} else if decoded, err := hex.DecodeString(string(bytes.TrimSpace(data))); err == nil {
+		der = decoded
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: CRL: %w", ErrMalformedCollateral, err)
+	}
+
+	return crl, nil
+}
+
+// ReadRootCa reads the root CA certificate of the collateral
+func ReadRootCa(collateral *Collateral) (*x509.Certificate, error) {
+	roots, err := parseCertificateChain([]byte(collateral.RootCa))
+	if err != nil {
+		return nil, err
+	}
+
+	return roots[0], nil
+}
+
+// the parsed collateral
+type verifier struct {
+	root      *x509.Certificate
+	rootCaCrl *x509.RevocationList
+	pckCrl    *x509.RevocationList
+	now       time.Time
+	expired   bool
+}
+
+// parses the collateral, its root CA must be the pinned one
+func newVerifier(collateral *Collateral, pin RootCaPin, now time.Time) (*verifier, error) {
+	root, err := ReadRootCa(collateral)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pin.Check(root); err != nil {
+		return nil, err
+	}
+
+	v := &verifier{
+		root: root,
+		now:  now,
+	}
+
+	if v.rootCaCrl, err = ParseCrl([]byte(collateral.RootCaCrl)); err != nil {
+		return nil, err
+	}
+
+	if err := v.rootCaCrl.CheckSignatureFrom(v.root); err != nil {
+		return nil, fmt.Errorf("%w: root CA CRL: %w", ErrCertificateChain, err)
+	}
+
+	if v.pckCrl, err = ParseCrl([]byte(collateral.PckCrl)); err != nil {
+		return nil, err
+	}
+
+	v.checkExpiry(v.rootCaCrl.NextUpdate)
+	v.checkExpiry(v.pckCrl.NextUpdate)
+
+	return v, nil
+}
+
+func (v *verifier) checkExpiry(nextUpdate time.Time) {
+	if !nextUpdate.IsZero() && v.now.After(nextUpdate) {
+		v.expired = true
+	}
+}
+
+func isRevoked(crl *x509.RevocationList, certificate *x509.Certificate) bool {
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(certificate.SerialNumber) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifies a certificate chain, leaf first, up to the root CA and checks the certificates issued by the root CA
+// against its CRL
+func (v *verifier) verifyChain(chain []*x509.Certificate) error {
+	roots := x509.NewCertPool()
+	roots.AddCert(v.root)
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	verifiedChains, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCertificateChain, err)
+	}
+
+	for _, verifiedChain := range verifiedChains {
+		for _, certificate := range verifiedChain[:len(verifiedChain)-1] {
+			if isRevoked(v.rootCaCrl, certificate) {
+				return fmt.Errorf("%w: %s", ErrRevoked, certificate.Subject)
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifies the chain of the PCK certificate and checks the PCK certificate against the CRL of the PCK CA
+func (v *verifier) verifyPck(pck *PckCertificate) error {
+	if err := v.verifyChain(pck.Chain); err != nil {
+		return err
+	}
+
+	if len(pck.Chain) < 2 {
+		return fmt.Errorf("%w: the PCK certificate chain has no PCK CA", ErrCertificateChain)
+	}
+
+	if err := v.pckCrl.CheckSignatureFrom(pck.Chain[1]); err != nil {
+		return fmt.Errorf("%w: the PCK CRL isn't issued by the PCK CA: %w", ErrCertificateChain, err)
+	}
+
+	if isRevoked(v.pckCrl, pck.Certificate) {
+		return fmt.Errorf("%w: PCK certificate", ErrRevoked)
+	}
+
+	return nil
+}
+
+// checks that a certificate is the TCB signing certificate, the same way as the Intel quote verification library.
+// Other certificates issued by the root CA, e.g. a PCK certificate whose key is on the platform, must not sign collateral.
+func (v *verifier) checkTcbSigning(certificate *x509.Certificate) error {
+	if certificate.Subject.CommonName != TCB_SIGNING_COMMON_NAME {
+		return fmt.Errorf("%w: %s", ErrUntrustedTcbSigner, certificate.Subject)
+	}
+
+	if !bytes.Equal(certificate.RawIssuer, v.root.RawSubject) || certificate.CheckSignatureFrom(v.root) != nil {
+		return fmt.Errorf("%w: the certificate isn't issued by the root CA", ErrUntrustedTcbSigner)
+	}
+
+	for _, extension := range certificate.Extensions {
+		if extension.Id.Equal(oidSgxExtensions) {
+			return fmt.Errorf("%w: the certificate has PCK extensions", ErrUntrustedTcbSigner)
+		}
+	}
+
+	return nil
+}
+
+// verifies a signed PCS response body with the issuer chain and returns the signed object
+func (v *verifier) verifyBody(body string, issuerChain string, field func(*signedBody) json.RawMessage) (json.RawMessage, error) {
+	chain, err := parseCertificateChain([]byte(issuerChain))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.verifyChain(chain); err != nil {
+		return nil, err
+	}
+
+	if err := v.checkTcbSigning(chain[0]); err != nil {
+		return nil, err
+	}
+
+	var signed signedBody
+	if err := json.Unmarshal([]byte(body), &signed); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedCollateral, err)
+	}
+
+	if err := verifySignedBody(chain[0], field(&signed), signed.Signature); err != nil {
+		return nil, err
+	}
+
+	return field(&signed), nil
+}
+
+// verifies the quote signature, the QE report signature and that the attestation key belongs to the quoting enclave
+func verifySignatures(signedData []byte, signatureData *quote.SignatureData, pck *PckCertificate) error {
+	if !verifySignature(pck.Certificate.PublicKey, signatureData.RawQeReport, signatureData.QeReportSignature[:]) {
+		return fmt.Errorf("%w: QE report", ErrInvalidSignature)
+	}
+
+	// the QE report data binds the attestation key and the QE authentication data
+	hash := sha256.New()
+	hash.Write(signatureData.AttestationKey[:])
+	hash.Write(signatureData.QeAuthData)
+	if !bytes.Equal(hash.Sum(nil), signatureData.QeReport.ReportData[:32]) {
+		return fmt.Errorf("%w: the QE report data doesn't match the attestation key", ErrInvalidSignature)
+	}
+
+	attestationKey := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(signatureData.AttestationKey[:32]),
+		Y:     new(big.Int).SetBytes(signatureData.AttestationKey[32:]),
+	}
+
+	if !verifySignature(attestationKey, signedData, signatureData.Signature[:]) {
+		return fmt.Errorf("%w: quote", ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// a quote with a valid signature and PCK certificate, and the verified collateral of its platform
+type verifiedQuote struct {
+	v            *verifier
+	pck          *PckCertificate
+	info         *tcbInfo
+	qeStatus     tcbstatus.Status
+	qeAdvisories []string
+}
+
+// verifies the PCK certificate and the signatures of a quote, and the collateral, which must have the TCB info and the
+// QE identity with the IDs
+func verifyQuote(signedData []byte, signatureData *quote.SignatureData, collateral *Collateral, pin RootCaPin, now time.Time, tcbInfoId string, qeIdentityId string) (*verifiedQuote, error) {
+	pck, err := readPckCertificate(signatureData)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := newVerifier(collateral, pin, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.verifyPck(pck); err != nil {
+		return nil, err
+	}
+
+	if err := verifySignatures(signedData, signatureData, pck); err != nil {
+		return nil, err
+	}
+
+	signedTcbInfo, err := v.verifyBody(collateral.TcbInfo, collateral.TcbInfoIssuerChain, func(body *signedBody) json.RawMessage {
+		return body.TcbInfo
+	})
+	if err != nil {
+		return nil, fmt.Errorf("TCB info: %w", err)
+	}
+
+	info := new(tcbInfo)
+	if err := json.Unmarshal(signedTcbInfo, info); err != nil {
+		return nil, fmt.Errorf("%w: TCB info: %w", ErrMalformedCollateral, err)
+	}
+
+	if info.Id != tcbInfoId || info.Version != TCB_INFO_VERSION {
+		return nil, fmt.Errorf("%w: TCB info %s version %d", ErrUnsupportedCollateral, info.Id, info.Version)
+	}
+
+	signedQeIdentity, err := v.verifyBody(collateral.QeIdentity, collateral.QeIdentityIssuerChain, func(body *signedBody) json.RawMessage {
+		return body.EnclaveIdentity
+	})
+	if err != nil {
+		return nil, fmt.Errorf("QE identity: %w", err)
+	}
+
+	var identity enclaveIdentity
+	if err := json.Unmarshal(signedQeIdentity, &identity); err != nil {
+		return nil, fmt.Errorf("%w: QE identity: %w", ErrMalformedCollateral, err)
+	}
+
+	if identity.Id != qeIdentityId || identity.Version != QE_IDENTITY_VERSION {
+		return nil, fmt.Errorf("%w: QE identity %s version %d", ErrUnsupportedCollateral, identity.Id, identity.Version)
+	}
+
+	v.checkExpiry(info.NextUpdate)
+	v.checkExpiry(identity.NextUpdate)
+
+	qeStatus, qeAdvisories, err := identity.match(&signatureData.QeReport)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifiedQuote{
+		v:            v,
+		pck:          pck,
+		info:         info,
+		qeStatus:     qeStatus,
+		qeAdvisories: qeAdvisories,
+	}, nil
+}
+
+// Verify verifies a quote, either bare or in an EGo remote report, against the collateral, which must be issued by the
+// pinned root CA. Certificates are checked for validity at the given time. Collateral that is past its next update is
+// accepted and reported in the result, the callers reject it with ErrExpiredCollateral unless it's allowed.
+func Verify(reportBytes []byte, collateral *Collateral, pin RootCaPin, now time.Time) (*Result, error) {
+	parsedQuote, err := quote.Parse(reportBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedQuote.Header.AttestationKeyType != quote.ATTESTATION_KEY_TYPE_ECDSA_P256 {
+		return nil, fmt.Errorf("%w: attestation key type %d", ErrUnsupportedQuote, parsedQuote.Header.AttestationKeyType)
+	}
+
+	signatureData, err := quote.ParseSignatureData(parsedQuote.SignatureData)
+	if err != nil {
+		return nil, err
+	}
+
+	verified, err := verifyQuote(parsedQuote.SignedData, signatureData, collateral, pin, now, TCB_INFO_ID, QE_IDENTITY_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := verified.info.match(verified.pck, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Quote:             parsedQuote,
+		Pck:               verified.pck,
+		TcbStatus:         convergeTcbStatus(parseTcbStatus(level.TcbStatus), verified.qeStatus),
+		TcbAdvisories:     append(append([]string{}, level.AdvisoryIds...), verified.qeAdvisories...),
+		RootCaFingerprint: Fingerprint(verified.v.root),
+		Expired:           verified.v.expired,
+	}, nil
+}
@@ -0,0 +1,551 @@
+package collateral
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+const (
+	testFmspc = "00906ed50000"
+	testPceId = "0000"
+	testQeSvn = 8
+)
+
+var testQeMrSigner = strings.Repeat("8c", 32)
+
+// the certificates and keys of a test platform, issued by a test root CA
+type testPki struct {
+	rootKey       *ecdsa.PrivateKey
+	root          *x509.Certificate
+	pckCaKey      *ecdsa.PrivateKey
+	pckCa         *x509.Certificate
+	pckKey        *ecdsa.PrivateKey
+	pck           *x509.Certificate
+	tcbSigningKey *ecdsa.PrivateKey
+	tcbSigning    *x509.Certificate
+}
+
+// the collateral of a test platform
+type testCollateralOptions struct {
+	fmspc      string
+	tcbLevels  string
+	qeMrSigner string
+	revokePck  bool
+	nextUpdate time.Time
+	// the collateral is signed with the key of the first certificate of the chain instead of the TCB signing key
+	signingKey   *ecdsa.PrivateKey
+	signingChain []*x509.Certificate
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func newCertificate(t *testing.T, template *x509.Certificate, key *ecdsa.PrivateKey, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) *x509.Certificate {
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().AddDate(10, 0, 0)
+
+	if issuer == nil {
+		issuer, issuerKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return certificate
+}
+
+// creates the SGX extensions of a PCK certificate with all TCB components at svn
+func newPckExtensions(t *testing.T, fmspc string, svn int, pceSvn int) []byte {
+	marshal := func(value any) asn1.RawValue {
+		encoded, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return asn1.RawValue{FullBytes: encoded}
+	}
+
+	var tcb []extensionEntry
+	for component := 1; component <= 16; component++ {
+		tcb = append(tcb, extensionEntry{Id: append(append(asn1.ObjectIdentifier{}, oidTcb...), component), Value: marshal(svn)})
+	}
+	tcb = append(tcb, extensionEntry{Id: oidPceSvn, Value: marshal(pceSvn)})
+	tcb = append(tcb, extensionEntry{Id: append(append(asn1.ObjectIdentifier{}, oidTcb...), 18), Value: marshal(bytes.Repeat([]byte{byte(svn)}, 16))})
+
+	fmspcBytes, _ := hex.DecodeString(fmspc)
+	pceIdBytes, _ := hex.DecodeString(testPceId)
+
+	return marshal([]extensionEntry{
+		{Id: oidTcb, Value: marshal(tcb)},
+		{Id: oidPceId, Value: marshal(pceIdBytes)},
+		{Id: oidFmspc, Value: marshal(fmspcBytes)},
+	}).FullBytes
+}
+
+// creates a test PKI with a PCK certificate at the TCB level
+func newTestPki(t *testing.T, svn int, pceSvn int) *testPki {
+	p := &testPki{
+		rootKey:       newKey(t),
+		pckCaKey:      newKey(t),
+		pckKey:        newKey(t),
+		tcbSigningKey: newKey(t),
+	}
+
+	p.root = newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test SGX Root CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, p.rootKey, nil, nil)
+
+	p.pckCa = newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test SGX PCK Processor CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, p.pckCaKey, p.root, p.rootKey)
+
+	p.pck = newCertificate(t, &x509.Certificate{
+		Subject:         pkix.Name{CommonName: "Test SGX PCK Certificate"},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{{Id: oidSgxExtensions, Value: newPckExtensions(t, testFmspc, svn, pceSvn)}},
+	}, p.pckKey, p.pckCa, p.pckCaKey)
+
+	p.tcbSigning = newCertificate(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: TCB_SIGNING_COMMON_NAME},
+		KeyUsage: x509.KeyUsageDigitalSignature,
+	}, p.tcbSigningKey, p.root, p.rootKey)
+
+	return p
+}
+
+// pins the test root CA
+func (p *testPki) pin() RootCaPin {
+	return NewRootCaPin(Fingerprint(p.root))
+}
+
+// pins the root CA in the collateral, as if it were configured as the trusted root CA
+func collateralPin(t *testing.T, collateral *Collateral) RootCaPin {
+	root, err := ReadRootCa(collateral)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewRootCaPin(Fingerprint(root))
+}
+
+func encodeCertificates(certificates ...*x509.Certificate) string {
+	buf := new(bytes.Buffer)
+	for _, certificate := range certificates {
+		pem.Encode(buf, &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	}
+
+	return buf.String()
+}
+
+// signs data, the signature is r and s big-endian
+func sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	hash := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return signature
+}
+
+func newCrl(t *testing.T, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey, nextUpdate time.Time, revoked ...*x509.Certificate) []byte {
+	var entries []x509.RevocationListEntry
+	for _, certificate := range revoked {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: certificate.SerialNumber, RevocationTime: time.Now()})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Hour),
+		NextUpdate:                nextUpdate,
+		RevokedCertificateEntries: entries,
+	}, issuer, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+// creates a signed PCS response body
+func newSignedBody(t *testing.T, key *ecdsa.PrivateKey, field string, signed string) string {
+	return `{"` + field + `":` + signed + `,"signature":"` + hex.EncodeToString(sign(t, key, []byte(signed))) + `"}`
+}
+
+func (p *testPki) collateral(t *testing.T, options testCollateralOptions) *Collateral {
+	if options.fmspc == "" {
+		options.fmspc = testFmspc
+	}
+	if options.tcbLevels == "" {
+		options.tcbLevels = `[` + testTcbLevel(3, 13, "UpToDate") + `,` + testTcbLevel(2, 13, "OutOfDate", "INTEL-SA-00615") + `]`
+	}
+	if options.qeMrSigner == "" {
+		options.qeMrSigner = testQeMrSigner
+	}
+	if options.nextUpdate.IsZero() {
+		options.nextUpdate = time.Now().AddDate(0, 0, 30)
+	}
+	if options.signingKey == nil {
+		options.signingKey = p.tcbSigningKey
+		options.signingChain = []*x509.Certificate{p.tcbSigning, p.root}
+	}
+
+	nextUpdate := options.nextUpdate.UTC().Format(time.RFC3339)
+
+	tcbInfo := `{"id":"SGX","version":3,"issueDate":"2024-01-01T00:00:00Z","nextUpdate":"` + nextUpdate + `","fmspc":"` + strings.ToUpper(options.fmspc) +
+		`","pceId":"` + testPceId + `","tcbType":0,"tcbEvaluationDataNumber":16,"tcbLevels":` + options.tcbLevels + `}`
+
+	qeIdentity := `{"id":"QE","version":2,"issueDate":"2024-01-01T00:00:00Z","nextUpdate":"` + nextUpdate + `","tcbEvaluationDataNumber":16,` +
+		`"miscselect":"00000000","miscselectMask":"FFFFFFFF","attributes":"11000000000000000000000000000000","attributesMask":"FBFFFFFFFFFFFFFF0000000000000000",` +
+		`"mrsigner":"` + strings.ToUpper(options.qeMrSigner) + `","isvprodid":1,"tcbLevels":[{"tcb":{"isvsvn":8},"tcbDate":"2023-08-09T00:00:00Z","tcbStatus":"UpToDate"},` +
+		`{"tcb":{"isvsvn":6},"tcbDate":"2021-11-10T00:00:00Z","tcbStatus":"OutOfDate","advisoryIDs":["INTEL-SA-00615"]}]}`
+
+	var revoked []*x509.Certificate
+	if options.revokePck {
+		revoked = append(revoked, p.pck)
+	}
+
+	return &Collateral{
+		RootCa:                encodeCertificates(p.root),
+		RootCaCrl:             string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: newCrl(t, p.root, p.rootKey, options.nextUpdate)})),
+		PckCrl:                string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: newCrl(t, p.pckCa, p.pckCaKey, options.nextUpdate, revoked...)})),
+		TcbInfo:               newSignedBody(t, options.signingKey, "tcbInfo", tcbInfo),
+		TcbInfoIssuerChain:    encodeCertificates(options.signingChain...),
+		QeIdentity:            newSignedBody(t, options.signingKey, "enclaveIdentity", qeIdentity),
+		QeIdentityIssuerChain: encodeCertificates(options.signingChain...),
+	}
+}
+
+func testTcbLevel(svn int, pceSvn int, status string, advisories ...string) string {
+	components := make([]string, 16)
+	for i := range components {
+		components[i] = `{"svn":` + strconv.Itoa(svn) + `}`
+	}
+
+	advisoryIds, _ := json.Marshal(advisories)
+
+	return `{"tcb":{"sgxtcbcomponents":[` + strings.Join(components, ",") + `],"pcesvn":` + strconv.Itoa(pceSvn) + `},` +
+		`"tcbDate":"2023-08-09T00:00:00Z","tcbStatus":"` + status + `","advisoryIDs":` + string(advisoryIds) + `}`
+}
+
+// creates an attestation key of the quoting enclave of the platform and the QE report certification data, which is
+// the QE report binding the key, signed with the PCK, the QE authentication data and the PCK certificate chain
+func (p *testPki) qeReportCertificationData(t *testing.T) (*ecdsa.PrivateKey, [64]byte, []byte) {
+	attestationKey := newKey(t)
+	qeAuthData := []byte("QE authentication data")
+
+	var publicKey [64]byte
+	attestationKey.PublicKey.X.FillBytes(publicKey[:32])
+	attestationKey.PublicKey.Y.FillBytes(publicKey[32:])
+
+	qeReport := quote.ReportBody{
+		Attributes: quote.Attributes{Flags: 0x11, Xfrm: 0xe7},
+		IsvProdId:  1,
+		IsvSvn:     testQeSvn,
+	}
+	mrSigner, _ := hex.DecodeString(testQeMrSigner)
+	copy(qeReport.MrSigner[:], mrSigner)
+	reportDataHash := sha256.Sum256(append(publicKey[:], qeAuthData...))
+	copy(qeReport.ReportData[:], reportDataHash[:])
+
+	rawQeReport := new(bytes.Buffer)
+	binary.Write(rawQeReport, binary.LittleEndian, qeReport)
+
+	certificationData := append([]byte(encodeCertificates(p.pck, p.pckCa, p.root)), 0)
+
+	data := new(bytes.Buffer)
+	data.Write(rawQeReport.Bytes())
+	data.Write(sign(t, p.pckKey, rawQeReport.Bytes()))
+	binary.Write(data, binary.LittleEndian, uint16(len(qeAuthData)))
+	data.Write(qeAuthData)
+	binary.Write(data, binary.LittleEndian, uint16(quote.CERTIFICATION_DATA_PCK_CERT_CHAIN))
+	binary.Write(data, binary.LittleEndian, uint32(len(certificationData)))
+	data.Write(certificationData)
+
+	return attestationKey, publicKey, data.Bytes()
+}
+
+// creates a quote of an enclave, signed by the quoting enclave of the platform
+func (p *testPki) quote(t *testing.T, reportBody quote.ReportBody) []byte {
+	attestationKey, publicKey, qeReportCertificationData := p.qeReportCertificationData(t)
+
+	signed := new(bytes.Buffer)
+	binary.Write(signed, binary.LittleEndian, quote.Header{
+		Version:            quote.QUOTE_VERSION_3,
+		AttestationKeyType: quote.ATTESTATION_KEY_TYPE_ECDSA_P256,
+		QeSvn:              testQeSvn,
+		PceSvn:             13,
+	})
+	binary.Write(signed, binary.LittleEndian, reportBody)
+
+	signatureData := new(bytes.Buffer)
+	signatureData.Write(sign(t, attestationKey, signed.Bytes()))
+	signatureData.Write(publicKey[:])
+	signatureData.Write(qeReportCertificationData)
+
+	buf := bytes.NewBuffer(signed.Bytes())
+	binary.Write(buf, binary.LittleEndian, uint32(signatureData.Len()))
+	buf.Write(signatureData.Bytes())
+
+	return buf.Bytes()
+}
+
+func testReportBody() quote.ReportBody {
+	reportBody := quote.ReportBody{
+		Attributes: quote.Attributes{Flags: quote.ATTRIBUTE_INIT | quote.ATTRIBUTE_MODE64, Xfrm: 0xe7},
+		IsvProdId:  1,
+		IsvSvn:     2,
+	}
+	copy(reportBody.MrEnclave[:], bytes.Repeat([]byte{0xaa}, 32))
+	copy(reportBody.ReportData[:], []byte("report data"))
+
+	return reportBody
+}
+
+func TestVerify(t *testing.T) {
+	pki := newTestPki(t, 3, 13)
+	report := pki.quote(t, testReportBody())
+
+	outOfDatePki := newTestPki(t, 2, 13)
+	outOfDateReport := outOfDatePki.quote(t, testReportBody())
+
+	tamperedReport := bytes.Clone(report)
+	tamperedReport[quote.HEADER_SIZE+quote.REPORT_BODY_SIZE-1] ^= 0xff
+
+	tamperedTcbInfo := pki.collateral(t, testCollateralOptions{})
+	tamperedTcbInfo.TcbInfo = strings.Replace(tamperedTcbInfo.TcbInfo, `"OutOfDate"`, `"UpToDate"`, 1)
+
+	otherRoot := pki.collateral(t, testCollateralOptions{})
+	otherRoot.RootCa = outOfDatePki.collateral(t, testCollateralOptions{}).RootCa
+
+	// a certificate named like the TCB signing certificate, but issued by the PCK CA
+	fakeTcbSigningKey := newKey(t)
+	fakeTcbSigning := newCertificate(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: TCB_SIGNING_COMMON_NAME},
+		KeyUsage: x509.KeyUsageDigitalSignature,
+	}, fakeTcbSigningKey, pki.pckCa, pki.pckCaKey)
+
+	// a certificate named like the TCB signing certificate and issued by the root CA, but with PCK extensions
+	pckTcbSigningKey := newKey(t)
+	pckTcbSigning := newCertificate(t, &x509.Certificate{
+		Subject:         pkix.Name{CommonName: TCB_SIGNING_COMMON_NAME},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{{Id: oidSgxExtensions, Value: newPckExtensions(t, testFmspc, 3, 13)}},
+	}, pckTcbSigningKey, pki.root, pki.rootKey)
+
+	// the default pin, a self-consistent collateral bundle with another root CA must be rejected
+	intelPin := RootCaPin{}
+	otherPin := outOfDatePki.pin()
+
+	tests := []struct {
+		name       string
+		report     []byte
+		collateral *Collateral
+		// the root CA of the collateral is pinned if it's not set
+		pin            *RootCaPin
+		now            time.Time
+		wantErr        error
+		wantStatus     tcbstatus.Status
+		wantAdvisories []string
+		wantExpired    bool
+	}{
+		{
+			name:       "up to date",
+			report:     report,
+			collateral: pki.collateral(t, testCollateralOptions{}),
+			wantStatus: tcbstatus.UpToDate,
+		},
+		{
+			name:           "out of date",
+			report:         outOfDateReport,
+			collateral:     outOfDatePki.collateral(t, testCollateralOptions{}),
+			wantStatus:     tcbstatus.OutOfDate,
+			wantAdvisories: []string{"INTEL-SA-00615"},
+		},
+		{
+			name:        "expired collateral",
+			report:      report,
+			collateral:  pki.collateral(t, testCollateralOptions{}),
+			now:         time.Now().AddDate(0, 2, 0),
+			wantStatus:  tcbstatus.UpToDate,
+			wantExpired: true,
+		},
+		{
+			name:       "no matching TCB level",
+			report:     outOfDateReport,
+			collateral: outOfDatePki.collateral(t, testCollateralOptions{tcbLevels: `[` + testTcbLevel(3, 13, "UpToDate") + `]`}),
+			wantErr:    ErrTcbLevelNotFound,
+		},
+		{
+			name:       "tampered quote",
+			report:     tamperedReport,
+			collateral: pki.collateral(t, testCollateralOptions{}),
+			wantErr:    ErrInvalidSignature,
+		},
+		{
+			name:       "tampered TCB info",
+			report:     report,
+			collateral: tamperedTcbInfo,
+			wantErr:    ErrInvalidSignature,
+		},
+		{
+			name:       "other root CA",
+			report:     report,
+			collateral: otherRoot,
+			wantErr:    ErrCertificateChain,
+		},
+		{
+			name:       "root CA isn't Intel's",
+			report:     report,
+			collateral: pki.collateral(t, testCollateralOptions{}),
+			pin:        &intelPin,
+			wantErr:    ErrUntrustedRootCa,
+		},
+		{
+			name:       "root CA isn't the pinned one",
+			report:     report,
+			collateral: pki.collateral(t, testCollateralOptions{}),
+			pin:        &otherPin,
+			wantErr:    ErrUntrustedRootCa,
+		},
+		{
+			name:   "collateral signed with the PCK key",
+			report: report,
+			collateral: pki.collateral(t, testCollateralOptions{
+				signingKey:   pki.pckKey,
+				signingChain: []*x509.Certificate{pki.pck, pki.pckCa, pki.root},
+			}),
+			wantErr: ErrUntrustedTcbSigner,
+		},
+		{
+			name:   "collateral signed by a TCB signing certificate not issued by the root CA",
+			report: report,
+			collateral: pki.collateral(t, testCollateralOptions{
+				signingKey:   fakeTcbSigningKey,
+				signingChain: []*x509.Certificate{fakeTcbSigning, pki.pckCa, pki.root},
+			}),
+			wantErr: ErrUntrustedTcbSigner,
+		},
+		{
+			name:   "collateral signed by a TCB signing certificate with PCK extensions",
+			report: report,
+			collateral: pki.collateral(t, testCollateralOptions{
+				signingKey:   pckTcbSigningKey,
+				signingChain: []*x509.Certificate{pckTcbSigning, pki.root},
+			}),
+			wantErr: ErrUntrustedTcbSigner,
+		},
+		{
+			name:       "revoked PCK certificate",
+			report:     report,
+			collateral: pki.collateral(t, testCollateralOptions{revokePck: true}),
+			wantErr:    ErrRevoked,
+		},
+		{
+			name:       "other platform",
+			report:     report,
+			collateral: pki.collateral(t, testCollateralOptions{fmspc: "00906ea10000"}),
+			wantErr:    ErrCollateralMismatch,
+		},
+		{
+			name:       "other quoting enclave",
+			report:     report,
+			collateral: pki.collateral(t, testCollateralOptions{qeMrSigner: strings.Repeat("ff", 32)}),
+			wantErr:    ErrQeIdentityMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := tt.now
+			if now.IsZero() {
+				now = time.Now()
+			}
+
+			pin := collateralPin(t, tt.collateral)
+			if tt.pin != nil {
+				pin = *tt.pin
+			}
+
+			got, err := Verify(tt.report, tt.collateral, pin, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.TcbStatus != tt.wantStatus || strings.Join(got.TcbAdvisories, ",") != strings.Join(tt.wantAdvisories, ",") || got.Expired != tt.wantExpired {
+				t.Errorf("Verify() = %+v", got)
+			}
+			if got.Pck.Fmspc != testFmspc || got.Pck.CA != PCK_CA_PROCESSOR || got.Quote.ReportBody.MrEnclave != testReportBody().MrEnclave {
+				t.Errorf("Verify() PCK = %+v, quote = %+v", got.Pck, got.Quote)
+			}
+		})
+	}
+}
+
+func TestConvergeTcbStatus(t *testing.T) {
+	tests := []struct {
+		platform tcbstatus.Status
+		qe       tcbstatus.Status
+		want     tcbstatus.Status
+	}{
+		{platform: tcbstatus.UpToDate, qe: tcbstatus.UpToDate, want: tcbstatus.UpToDate},
+		{platform: tcbstatus.SWHardeningNeeded, qe: tcbstatus.UpToDate, want: tcbstatus.SWHardeningNeeded},
+		{platform: tcbstatus.UpToDate, qe: tcbstatus.OutOfDate, want: tcbstatus.OutOfDate},
+		{platform: tcbstatus.ConfigurationNeeded, qe: tcbstatus.OutOfDate, want: tcbstatus.OutOfDateConfigurationNeeded},
+		{platform: tcbstatus.UpToDate, qe: tcbstatus.Revoked, want: tcbstatus.Revoked},
+	}
+	for _, tt := range tests {
+		if got := convergeTcbStatus(tt.platform, tt.qe); got != tt.want {
+			t.Errorf("convergeTcbStatus(%s, %s) = %s, want %s", tt.platform, tt.qe, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package collateral
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// the collateral service of the Intel PCS, see sgx_default_qcnl.conf
+	DEFAULT_SERVICE_URL = "https://api.trustedservices.intel.com/sgx/certification/v4/"
+	// the TDX collateral service of the Intel PCS, the PCK CRL is served by the SGX collateral service only
+	DEFAULT_TDX_SERVICE_URL = "https://api.trustedservices.intel.com/tdx/certification/v4/"
+	// the CRL of the Intel SGX root CA
+	DEFAULT_ROOT_CA_CRL_URL = "https://certificates.trustedservices.intel.com/IntelSGXRootCA.der"
+
+	// the response headers with the issuer chains, URL-encoded PEM
+	HEADER_TCB_INFO_ISSUER_CHAIN    = "TCB-Info-Issuer-Chain"
+	HEADER_QE_IDENTITY_ISSUER_CHAIN = "SGX-Enclave-Identity-Issuer-Chain"
+	// the PCCS still uses the header name of version 3 of the API
+	headerTcbInfoIssuerChainV3 = "SGX-TCB-Info-Issuer-Chain"
+
+	maxResponseSize = 1024 * 1024 * 8 // 8MB
+)
+
+// fetches a collateral resource and returns the body and the headers
+func fetch(ctx context.Context, client *http.Client, resourceUrl string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resourceUrl, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("collateral: GET %s: %s", resourceUrl, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, resp.Header, nil
+}
+
+// reads an issuer chain from a response header
+func issuerChain(headers http.Header, names ...string) (string, error) {
+	for _, name := range names {
+		if value := headers.Get(name); value != "" {
+			return url.PathUnescape(value)
+		}
+	}
+
+	return "", fmt.Errorf("%w: missing %s header", ErrMalformedCollateral, names[0])
+}
+
+// fetches a CRL and PEM-encodes it
+func fetchCrl(ctx context.Context, client *http.Client, crlUrl string) (string, error) {
+	body, _, err := fetch(ctx, client, crlUrl)
+	if err != nil {
+		return "", err
+	}
+
+	crl, err := ParseCrl(body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl.Raw})), nil
+}
+
+// Fetch fetches the collateral for the platforms with the FMSPC from the PCS or a PCCS. The PCK CA is either
+// PCK_CA_PROCESSOR or PCK_CA_PLATFORM. The root CA is taken from the end of the TCB info issuer chain.
+func Fetch(ctx context.Context, client *http.Client, serviceUrl string, rootCaCrlUrl string, fmspc string, ca string) (*Collateral, error) {
+	return fetchCollateral(ctx, client, serviceUrl, serviceUrl, rootCaCrlUrl, fmspc, ca)
+}
+
+// FetchTd fetches the TDX collateral for the platforms with the FMSPC, the TCB info and the QE identity from the TDX
+// collateral service and the PCK CRL from the SGX collateral service
+func FetchTd(ctx context.Context, client *http.Client, tdxServiceUrl string, serviceUrl string, rootCaCrlUrl string, fmspc string, ca string) (*Collateral, error) {
+	return fetchCollateral(ctx, client, tdxServiceUrl, serviceUrl, rootCaCrlUrl, fmspc, ca)
+}
+
+func fetchCollateral(ctx context.Context, client *http.Client, tcbServiceUrl string, serviceUrl string, rootCaCrlUrl string, fmspc string, ca string) (*Collateral, error) {
+	tcbServiceUrl = strings.TrimSuffix(tcbServiceUrl, "/") + "/"
+	serviceUrl = strings.TrimSuffix(serviceUrl, "/") + "/"
+
+	tcbInfo, headers, err := fetch(ctx, client, tcbServiceUrl+"tcb?fmspc="+url.QueryEscape(fmspc))
+	if err != nil {
+		return nil, err
+	}
+
+	tcbInfoIssuerChain, err := issuerChain(headers, HEADER_TCB_INFO_ISSUER_CHAIN, headerTcbInfoIssuerChainV3)
+	if err != nil {
+		return nil, err
+	}
+
+	qeIdentity, headers, err := fetch(ctx, client, tcbServiceUrl+"qe/identity")
+	if err != nil {
+		return nil, err
+	}
+
+	qeIdentityIssuerChain, err := issuerChain(headers, HEADER_QE_IDENTITY_ISSUER_CHAIN)
+	if err != nil {
+		return nil, err
+	}
+
+	pckCrl, err := fetchCrl(ctx, client, serviceUrl+"pckcrl?ca="+url.QueryEscape(ca)+"&encoding=pem")
+	if err != nil {
+		return nil, err
+	}
+
+	rootCaCrl, err := fetchCrl(ctx, client, rootCaCrlUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := parseCertificateChain([]byte(tcbInfoIssuerChain))
+	if err != nil {
+		return nil, err
+	}
+
+	rootCa := chain[len(chain)-1]
+
+	return &Collateral{
+		RootCa:                string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootCa.Raw})),
+		RootCaCrl:             rootCaCrl,
+		PckCrl:                pckCrl,
+		TcbInfo:               string(tcbInfo),
+		TcbInfoIssuerChain:    tcbInfoIssuerChain,
+		QeIdentity:            string(qeIdentity),
+		QeIdentityIssuerChain: qeIdentityIssuerChain,
+	}, nil
+}
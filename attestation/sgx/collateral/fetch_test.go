@@ -0,0 +1,71 @@
+package collateral
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// serves the collateral of the test platform like the PCS, the TCB info and the QE identity under the path of the
+// collateral service
+func newTestCollateralServer(t *testing.T, servicePath string, collateral *Collateral, rootCaCrl []byte) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(servicePath+"/tcb", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("fmspc") != testFmspc {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(HEADER_TCB_INFO_ISSUER_CHAIN, url.PathEscape(collateral.TcbInfoIssuerChain))
+		w.Write([]byte(collateral.TcbInfo))
+	})
+	mux.HandleFunc(servicePath+"/qe/identity", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(HEADER_QE_IDENTITY_ISSUER_CHAIN, url.PathEscape(collateral.QeIdentityIssuerChain))
+		w.Write([]byte(collateral.QeIdentity))
+	})
+	mux.HandleFunc("/sgx/certification/v4/pckcrl", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("ca") != PCK_CA_PROCESSOR {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(collateral.PckCrl))
+	})
+	// the root CA CRL is served as DER
+	mux.HandleFunc("/IntelSGXRootCA.der", func(w http.ResponseWriter, req *http.Request) {
+		w.Write(rootCaCrl)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestFetch(t *testing.T) {
+	pki := newTestPki(t, 3, 13)
+	collateral := pki.collateral(t, testCollateralOptions{})
+
+	rootCaCrl, _ := pem.Decode([]byte(collateral.RootCaCrl))
+
+	server := newTestCollateralServer(t, "/sgx/certification/v4", collateral, rootCaCrl.Bytes)
+
+	fetched, err := Fetch(context.Background(), server.Client(), server.URL+"/sgx/certification/v4", server.URL+"/IntelSGXRootCA.der", testFmspc, PCK_CA_PROCESSOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *fetched != *collateral {
+		t.Errorf("Fetch() = %+v, want %+v", fetched, collateral)
+	}
+
+	if _, err := Verify(pki.quote(t, testReportBody()), fetched, pki.pin(), time.Now()); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	if _, err := Fetch(context.Background(), server.Client(), server.URL+"/sgx/certification/v4", server.URL+"/IntelSGXRootCA.der", "00906ea10000", PCK_CA_PROCESSOR); err == nil {
+		t.Error("Fetch() fetched the collateral of an unknown platform")
+	}
+}
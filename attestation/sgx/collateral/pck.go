@@ -0,0 +1,214 @@
+package collateral
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+// PCK certificate authorities, see PckCertificate.CA
+const (
+	PCK_CA_PROCESSOR = "processor"
+	PCK_CA_PLATFORM  = "platform"
+)
+
+var (
+	oidSgxExtensions = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1}
+	oidTcb           = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 2}
+	oidPceSvn        = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 2, 17}
+	oidPceId         = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 3}
+	oidFmspc         = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 4}
+)
+
+// an entry of the SGX extensions of a PCK certificate
+type extensionEntry struct {
+	Id    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// PckCertificate is the PCK certificate of the platform that created a quote
+type PckCertificate struct {
+	Certificate *x509.Certificate
+	// the certificate chain up to the root CA, the PCK certificate first
+	Chain []*x509.Certificate
+	// the family-model-stepping-platform-custom SKU, hex-encoded
+	Fmspc string
+	// the provisioning certification enclave ID, hex-encoded
+	PceId string
+	// the PCK CA that issued the certificate, either PCK_CA_PROCESSOR or PCK_CA_PLATFORM
+	CA string
+	// the TCB level of the platform
+	CpuSvnComponents [16]uint8
+	PceSvn           uint16
+}
+
+// parses all PEM certificates in data, the certification data of a quote can be padded with zeros
+func parseCertificateChain(data []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(bytes.TrimRight(data, "\x00"))
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedCollateral, err)
+		}
+
+		chain = append(chain, certificate)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%w: no certificates", ErrMalformedCollateral)
+	}
+
+	return chain, nil
+}
+
+// parses the SGX extensions of a PCK certificate
+func parsePckExtensions(certificate *x509.Certificate, pck *PckCertificate) error {
+	var extension []byte
+	for _, ext := range certificate.Extensions {
+		if ext.Id.Equal(oidSgxExtensions) {
+			extension = ext.Value
+		}
+	}
+
+	if extension == nil {
+		return fmt.Errorf("%w: the PCK certificate has no SGX extensions", ErrMalformedCollateral)
+	}
+
+	var entries []extensionEntry
+	if _, err := asn1.Unmarshal(extension, &entries); err != nil {
+		return fmt.Errorf("%w: SGX extensions: %w", ErrMalformedCollateral, err)
+	}
+
+	var foundTcb, foundPceId, foundFmspc bool
+
+	for _, entry := range entries {
+		switch {
+		case entry.Id.Equal(oidFmspc):
+			pck.Fmspc = hex.EncodeToString(entry.Value.Bytes)
+			foundFmspc = len(entry.Value.Bytes) == 6
+
+		case entry.Id.Equal(oidPceId):
+			pck.PceId = hex.EncodeToString(entry.Value.Bytes)
+			foundPceId = len(entry.Value.Bytes) == 2
+
+		case entry.Id.Equal(oidTcb):
+			if err := parsePckTcb(entry.Value.FullBytes, pck); err != nil {
+				return err
+			}
+			foundTcb = true
+		}
+	}
+
+	if !foundTcb || !foundPceId || !foundFmspc {
+		return fmt.Errorf("%w: the PCK certificate is missing the TCB, the PCE ID or the FMSPC", ErrMalformedCollateral)
+	}
+
+	return nil
+}
+
+// parses the TCB extension, which has the 16 SGX TCB components, the PCESVN and the CPUSVN
+func parsePckTcb(tcb []byte, pck *PckCertificate) error {
+	var entries []extensionEntry
+	if _, err := asn1.Unmarshal(tcb, &entries); err != nil {
+		return fmt.Errorf("%w: TCB extension: %w", ErrMalformedCollateral, err)
+	}
+
+	for _, entry := range entries {
+		if len(entry.Id) != len(oidTcb)+1 || !entry.Id[:len(oidTcb)].Equal(oidTcb) {
+			continue
+		}
+
+		component := entry.Id[len(oidTcb)]
+		if component < 1 || component > 17 {
+			// the CPUSVN, which is made of the components
+			continue
+		}
+
+		var svn int
+		if _, err := asn1.Unmarshal(entry.Value.FullBytes, &svn); err != nil {
+			return fmt.Errorf("%w: TCB component %d: %w", ErrMalformedCollateral, component, err)
+		}
+
+		if entry.Id.Equal(oidPceSvn) {
+			pck.PceSvn = uint16(svn)
+		} else {
+			pck.CpuSvnComponents[component-1] = uint8(svn)
+		}
+	}
+
+	return nil
+}
+
+// ReadPckCertificate reads the PCK certificate from the certification data of a quote, either bare or in an EGo
+// remote report, or of a TD quote. The certificate isn't verified.
+func ReadPckCertificate(reportBytes []byte) (*PckCertificate, error) {
+	if quote.IsTd(reportBytes) {
+		parsedQuote, err := quote.ParseTd(reportBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		signatureData, err := quote.ParseTdSignatureData(parsedQuote.SignatureData)
+		if err != nil {
+			return nil, err
+		}
+
+		return readPckCertificate(signatureData)
+	}
+
+	parsedQuote, err := quote.Parse(reportBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signatureData, err := quote.ParseSignatureData(parsedQuote.SignatureData)
+	if err != nil {
+		return nil, err
+	}
+
+	return readPckCertificate(signatureData)
+}
+
+func readPckCertificate(signatureData *quote.SignatureData) (*PckCertificate, error) {
+	if signatureData.CertificationDataType != quote.CERTIFICATION_DATA_PCK_CERT_CHAIN {
+		return nil, fmt.Errorf("%w: certification data type %d", ErrUnsupportedQuote, signatureData.CertificationDataType)
+	}
+
+	chain, err := parseCertificateChain(signatureData.CertificationData)
+	if err != nil {
+		return nil, err
+	}
+
+	pck := &PckCertificate{
+		Certificate: chain[0],
+		Chain:       chain,
+		CA:          PCK_CA_PROCESSOR,
+	}
+
+	if strings.Contains(chain[0].Issuer.CommonName, "Platform") {
+		pck.CA = PCK_CA_PLATFORM
+	}
+
+	if err := parsePckExtensions(chain[0], pck); err != nil {
+		return nil, err
+	}
+
+	return pck, nil
+}
@@ -0,0 +1,259 @@
+package collateral
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+const (
+	// the supported TCB info, as served by version 4 of the PCS API
+	TCB_INFO_ID      = "SGX"
+	TCB_INFO_VERSION = 3
+	// the supported QE identity, as served by version 4 of the PCS API
+	QE_IDENTITY_ID      = "QE"
+	QE_IDENTITY_VERSION = 2
+	// the TCB info and the QE identity of TDX platforms, same versions
+	TDX_TCB_INFO_ID   = "TDX"
+	TD_QE_IDENTITY_ID = "TD_QE"
+)
+
+var tcbStatuses = map[string]tcbstatus.Status{
+	"UpToDate":                          tcbstatus.UpToDate,
+	"OutOfDate":                         tcbstatus.OutOfDate,
+	"Revoked":                           tcbstatus.Revoked,
+	"ConfigurationNeeded":               tcbstatus.ConfigurationNeeded,
+	"OutOfDateConfigurationNeeded":      tcbstatus.OutOfDateConfigurationNeeded,
+	"SWHardeningNeeded":                 tcbstatus.SWHardeningNeeded,
+	"ConfigurationAndSWHardeningNeeded": tcbstatus.ConfigurationAndSWHardeningNeeded,
+}
+
+// a signed PCS response body, the signature is over the exact JSON of the signed object
+type signedBody struct {
+	TcbInfo         json.RawMessage `json:"tcbInfo"`
+	EnclaveIdentity json.RawMessage `json:"enclaveIdentity"`
+	Signature       string          `json:"signature"`
+}
+
+type tcbInfo struct {
+	Id         string     `json:"id"`
+	Version    int        `json:"version"`
+	NextUpdate time.Time  `json:"nextUpdate"`
+	Fmspc      string     `json:"fmspc"`
+	PceId      string     `json:"pceId"`
+	TcbLevels  []tcbLevel `json:"tcbLevels"`
+	// the TDX module of TDX 1.0 and the TDX modules from TDX 1.5 on, only in the TCB info of TDX platforms
+	TdxModule           *tdxModule          `json:"tdxModule"`
+	TdxModuleIdentities []tdxModuleIdentity `json:"tdxModuleIdentities"`
+}
+
+type tcbComponent struct {
+	Svn uint8 `json:"svn"`
+}
+
+type tcbLevel struct {
+	Tcb struct {
+		SgxTcbComponents []tcbComponent `json:"sgxtcbcomponents"`
+		PceSvn           uint16         `json:"pcesvn"`
+		TdxTcbComponents []tcbComponent `json:"tdxtcbcomponents"`
+	} `json:"tcb"`
+	TcbStatus   string   `json:"tcbStatus"`
+	AdvisoryIds []string `json:"advisoryIDs"`
+}
+
+type enclaveIdentity struct {
+	Id             string    `json:"id"`
+	Version        int       `json:"version"`
+	NextUpdate     time.Time `json:"nextUpdate"`
+	MiscSelect     string    `json:"miscselect"`
+	MiscSelectMask string    `json:"miscselectMask"`
+	Attributes     string    `json:"attributes"`
+	AttributesMask string    `json:"attributesMask"`
+	MrSigner       string    `json:"mrsigner"`
+	IsvProdId      uint16    `json:"isvprodid"`
+	TcbLevels      []struct {
+		Tcb struct {
+			IsvSvn uint16 `json:"isvsvn"`
+		} `json:"tcb"`
+		TcbStatus   string   `json:"tcbStatus"`
+		AdvisoryIds []string `json:"advisoryIDs"`
+	} `json:"tcbLevels"`
+}
+
+// verifies an ECDSA P-256 signature of r and s big-endian
+func verifySignature(publicKey any, data []byte, signature []byte) bool {
+	key, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok || len(signature) != 64 {
+		return false
+	}
+
+	hash := sha256.Sum256(data)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+
+	return ecdsa.Verify(key, hash[:], r, s)
+}
+
+// verifies the signature of a PCS response body with the signing certificate
+func verifySignedBody(signingCertificate *x509.Certificate, signed json.RawMessage, encodedSignature string) error {
+	signature, err := hex.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("%w: signature: %w", ErrMalformedCollateral, err)
+	}
+
+	if !verifySignature(signingCertificate.PublicKey, signed, signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// decodes a hex-encoded value of the expected size
+func decodeHex(value string, size int) ([]byte, error) {
+	decoded, err := hex.DecodeString(value)
+	if err != nil || len(decoded) != size {
+		return nil, fmt.Errorf("%w: %q is not %d bytes hex-encoded", ErrMalformedCollateral, value, size)
+	}
+
+	return decoded, nil
+}
+
+// finds the TCB level of the platform, which is the first level, from the highest, the platform is at or above. The
+// TEE TCB SVN of a TD quote is matched against the TDX components, it's nil for SGX quotes.
+func (info *tcbInfo) match(pck *PckCertificate, teeTcbSvn []byte) (*tcbLevel, error) {
+	if !strings.EqualFold(info.Fmspc, pck.Fmspc) || !strings.EqualFold(info.PceId, pck.PceId) {
+		return nil, fmt.Errorf("%w: the TCB info is for FMSPC %s and PCE ID %s, the platform has %s and %s", ErrCollateralMismatch, info.Fmspc, info.PceId, pck.Fmspc, pck.PceId)
+	}
+
+levels:
+	for idx := range info.TcbLevels {
+		level := &info.TcbLevels[idx]
+
+		if len(level.Tcb.SgxTcbComponents) != len(pck.CpuSvnComponents) {
+			return nil, fmt.Errorf("%w: TCB level has %d components", ErrMalformedCollateral, len(level.Tcb.SgxTcbComponents))
+		}
+
+		for i, component := range level.Tcb.SgxTcbComponents {
+			if pck.CpuSvnComponents[i] < component.Svn {
+				continue levels
+			}
+		}
+
+		if teeTcbSvn != nil {
+			if len(level.Tcb.TdxTcbComponents) != len(teeTcbSvn) {
+				return nil, fmt.Errorf("%w: TCB level has %d TDX components", ErrMalformedCollateral, len(level.Tcb.TdxTcbComponents))
+			}
+
+			// from TDX 1.5 on, the SVN and the major version of the TDX module are matched against its identity instead
+			start := 0
+			if teeTcbSvn[1] > 0 {
+				start = 2
+			}
+
+			for i := start; i < len(teeTcbSvn); i++ {
+				if teeTcbSvn[i] < level.Tcb.TdxTcbComponents[i].Svn {
+					continue levels
+				}
+			}
+		}
+
+		if pck.PceSvn >= level.Tcb.PceSvn {
+			return level, nil
+		}
+	}
+
+	return nil, ErrTcbLevelNotFound
+}
+
+// checks the quoting enclave report against the identity and returns the TCB status and advisories of the QE
+func (identity *enclaveIdentity) match(qeReport *quote.ReportBody) (tcbstatus.Status, []string, error) {
+	mrSigner, err := decodeHex(identity.MrSigner, 32)
+	if err != nil {
+		return tcbstatus.Unknown, nil, err
+	}
+
+	miscSelect, err := decodeHex(identity.MiscSelect, 4)
+	if err != nil {
+		return tcbstatus.Unknown, nil, err
+	}
+
+	miscSelectMask, err := decodeHex(identity.MiscSelectMask, 4)
+	if err != nil {
+		return tcbstatus.Unknown, nil, err
+	}
+
+	attributes, err := decodeHex(identity.Attributes, 16)
+	if err != nil {
+		return tcbstatus.Unknown, nil, err
+	}
+
+	attributesMask, err := decodeHex(identity.AttributesMask, 16)
+	if err != nil {
+		return tcbstatus.Unknown, nil, err
+	}
+
+	if !bytes.Equal(mrSigner, qeReport.MrSigner[:]) || identity.IsvProdId != qeReport.IsvProdId {
+		return tcbstatus.Unknown, nil, fmt.Errorf("%w: MRSIGNER or ISVPRODID", ErrQeIdentityMismatch)
+	}
+
+	// the identity has MISCSELECT as a big-endian number, the attributes as the bytes of the report
+	mask := binary.BigEndian.Uint32(miscSelectMask)
+	if qeReport.MiscSelect&mask != binary.BigEndian.Uint32(miscSelect)&mask {
+		return tcbstatus.Unknown, nil, fmt.Errorf("%w: MISCSELECT", ErrQeIdentityMismatch)
+	}
+
+	var reportAttributes [16]byte
+	binary.LittleEndian.PutUint64(reportAttributes[0:], qeReport.Attributes.Flags)
+	binary.LittleEndian.PutUint64(reportAttributes[8:], qeReport.Attributes.Xfrm)
+
+	for i := range reportAttributes {
+		if reportAttributes[i]&attributesMask[i] != attributes[i]&attributesMask[i] {
+			return tcbstatus.Unknown, nil, fmt.Errorf("%w: attributes", ErrQeIdentityMismatch)
+		}
+	}
+
+	for _, level := range identity.TcbLevels {
+		if qeReport.IsvSvn >= level.Tcb.IsvSvn {
+			return parseTcbStatus(level.TcbStatus), level.AdvisoryIds, nil
+		}
+	}
+
+	return tcbstatus.Unknown, nil, fmt.Errorf("%w: no TCB level of the QE identity", ErrTcbLevelNotFound)
+}
+
+func parseTcbStatus(status string) tcbstatus.Status {
+	if parsed, ok := tcbStatuses[status]; ok {
+		return parsed
+	}
+
+	return tcbstatus.Unknown
+}
+
+// combines the TCB status of the platform with the TCB status of the quoting enclave the way the quote verification
+// library does
+func convergeTcbStatus(platform tcbstatus.Status, qe tcbstatus.Status) tcbstatus.Status {
+	switch qe {
+	case tcbstatus.Revoked:
+		return tcbstatus.Revoked
+	case tcbstatus.OutOfDate:
+		switch platform {
+		case tcbstatus.UpToDate, tcbstatus.SWHardeningNeeded:
+			return tcbstatus.OutOfDate
+		case tcbstatus.ConfigurationNeeded, tcbstatus.ConfigurationAndSWHardeningNeeded:
+			return tcbstatus.OutOfDateConfigurationNeeded
+		}
+	}
+
+	return platform
+}
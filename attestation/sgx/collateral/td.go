@@ -0,0 +1,168 @@
+package collateral
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+var ErrTdxModuleMismatch = errors.New("collateral: the TDX module doesn't match the TCB info")
+
+// the identity of a TDX module
+type tdxModule struct {
+	MrSigner       string `json:"mrsigner"`
+	Attributes     string `json:"attributes"`
+	AttributesMask string `json:"attributesMask"`
+}
+
+// the identity and the TCB levels of the TDX modules of a major version, from TDX 1.5 on
+type tdxModuleIdentity struct {
+	Id string `json:"id"`
+	tdxModule
+	TcbLevels []struct {
+		Tcb struct {
+			IsvSvn uint8 `json:"isvsvn"`
+		} `json:"tcb"`
+		TcbStatus   string   `json:"tcbStatus"`
+		AdvisoryIds []string `json:"advisoryIDs"`
+	} `json:"tcbLevels"`
+}
+
+// TdResult is the result of verifying a TD quote. The quote is valid, the TCB status still has to be checked.
+type TdResult struct {
+	Quote     *quote.TdQuote
+	Pck       *PckCertificate
+	TcbStatus tcbstatus.Status
+	// the advisories of the platform, the TDX module and the quoting enclave TCB levels
+	TcbAdvisories []string
+	// the SHA-256 fingerprint of the root CA certificate the quote was verified against, hex-encoded
+	RootCaFingerprint string
+	// the TCB info, the QE identity or a CRL is past its next update
+	Expired bool
+}
+
+// checks the MRSIGNER and the attributes of the TDX module that created a report
+func (module *tdxModule) match(reportBody *quote.TdReportBody) error {
+	mrSigner, err := decodeHex(module.MrSigner, 48)
+	if err != nil {
+		return err
+	}
+
+	attributes, err := decodeHex(module.Attributes, 8)
+	if err != nil {
+		return err
+	}
+
+	attributesMask, err := decodeHex(module.AttributesMask, 8)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(mrSigner, reportBody.MrSignerSeam[:]) {
+		return fmt.Errorf("%w: MRSIGNERSEAM", ErrTdxModuleMismatch)
+	}
+
+	var seamAttributes [8]byte
+	binary.LittleEndian.PutUint64(seamAttributes[:], reportBody.SeamAttributes)
+
+	for i := range seamAttributes {
+		if seamAttributes[i]&attributesMask[i] != attributes[i]&attributesMask[i] {
+			return fmt.Errorf("%w: SEAM attributes", ErrTdxModuleMismatch)
+		}
+	}
+
+	return nil
+}
+
+// checks the TDX module that created a report against the TCB info and returns the TCB status and advisories of the
+// module. The TDX module of TDX 1.0 has no TCB levels of its own, it's up to date if it matches.
+func (info *tcbInfo) matchTdxModule(reportBody *quote.TdReportBody) (tcbstatus.Status, []string, error) {
+	svn, majorVersion := reportBody.TeeTcbSvn[0], reportBody.TeeTcbSvn[1]
+
+	if majorVersion == 0 {
+		if info.TdxModule == nil {
+			return tcbstatus.Unknown, nil, fmt.Errorf("%w: the TCB info has no TDX module", ErrMalformedCollateral)
+		}
+
+		if err := info.TdxModule.match(reportBody); err != nil {
+			return tcbstatus.Unknown, nil, err
+		}
+
+		return tcbstatus.UpToDate, nil, nil
+	}
+
+	id := fmt.Sprintf("TDX_%02X", majorVersion)
+	for _, identity := range info.TdxModuleIdentities {
+		if !strings.EqualFold(identity.Id, id) {
+			continue
+		}
+
+		if err := identity.match(reportBody); err != nil {
+			return tcbstatus.Unknown, nil, err
+		}
+
+		for _, level := range identity.TcbLevels {
+			if svn >= level.Tcb.IsvSvn {
+				return parseTcbStatus(level.TcbStatus), level.AdvisoryIds, nil
+			}
+		}
+
+		return tcbstatus.Unknown, nil, fmt.Errorf("%w: no TCB level of TDX module %s", ErrTcbLevelNotFound, id)
+	}
+
+	return tcbstatus.Unknown, nil, fmt.Errorf("%w: no identity of TDX module %s", ErrTdxModuleMismatch, id)
+}
+
+// VerifyTd verifies a TD quote against the TDX collateral of its platform, the same way Verify verifies SGX quotes. The
+// TCB status combines the TCB levels of the platform, the TDX module and the quoting enclave.
+func VerifyTd(reportBytes []byte, collateral *Collateral, pin RootCaPin, now time.Time) (*TdResult, error) {
+	parsedQuote, err := quote.ParseTd(reportBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedQuote.Header.AttestationKeyType != quote.ATTESTATION_KEY_TYPE_ECDSA_P256 {
+		return nil, fmt.Errorf("%w: attestation key type %d", ErrUnsupportedQuote, parsedQuote.Header.AttestationKeyType)
+	}
+
+	signatureData, err := quote.ParseTdSignatureData(parsedQuote.SignatureData)
+	if err != nil {
+		return nil, err
+	}
+
+	verified, err := verifyQuote(parsedQuote.SignedData, signatureData, collateral, pin, now, TDX_TCB_INFO_ID, TD_QE_IDENTITY_ID)
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := verified.info.match(verified.pck, parsedQuote.ReportBody.TeeTcbSvn[:])
+	if err != nil {
+		return nil, err
+	}
+
+	moduleStatus, moduleAdvisories, err := verified.info.matchTdxModule(&parsedQuote.ReportBody)
+	if err != nil {
+		return nil, err
+	}
+
+	status := convergeTcbStatus(parseTcbStatus(level.TcbStatus), moduleStatus)
+
+	advisories := append([]string{}, level.AdvisoryIds...)
+	advisories = append(advisories, moduleAdvisories...)
+	advisories = append(advisories, verified.qeAdvisories...)
+
+	return &TdResult{
+		Quote:             parsedQuote,
+		Pck:               verified.pck,
+		TcbStatus:         convergeTcbStatus(status, verified.qeStatus),
+		TcbAdvisories:     advisories,
+		RootCaFingerprint: Fingerprint(verified.v.root),
+		Expired:           verified.v.expired,
+	}, nil
+}
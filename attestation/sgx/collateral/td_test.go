@@ -0,0 +1,271 @@
+package collateral
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+// the MRSIGNERSEAM of the Intel TDX modules
+var testTdxModuleMrSigner = strings.Repeat("00", 48)
+
+// a TCB level of a TDX platform, the TDX components are at tdxSvn except for the TDX module major version
+func testTdTcbLevel(svn int, pceSvn int, tdxSvn int, status string, advisories ...string) string {
+	components := make([]string, 16)
+	for i := range components {
+		components[i] = `{"svn":` + strconv.Itoa(tdxSvn) + `}`
+	}
+	components[1] = `{"svn":0}`
+
+	level := testTcbLevel(svn, pceSvn, status, advisories...)
+
+	return strings.Replace(level, `,"pcesvn"`, `,"tdxtcbcomponents":[`+strings.Join(components, ",")+`],"pcesvn"`, 1)
+}
+
+// the TDX collateral of a test platform, the TDX module identity of TDX 1.5 has the TCB levels of the module SVN
+func (p *testPki) tdCollateral(t *testing.T, options testCollateralOptions) *Collateral {
+	if options.tcbLevels == "" {
+		options.tcbLevels = `[` + testTdTcbLevel(3, 13, 3, "UpToDate") + `,` + testTdTcbLevel(3, 13, 2, "OutOfDate", "INTEL-SA-00837") + `]`
+	}
+
+	// the SGX collateral with the TDX TCB info and the TD QE identity
+	sgxCollateral := p.collateral(t, options)
+
+	var signedTcbInfo, signedQeIdentity signedBody
+	json.Unmarshal([]byte(sgxCollateral.TcbInfo), &signedTcbInfo)
+	json.Unmarshal([]byte(sgxCollateral.QeIdentity), &signedQeIdentity)
+
+	module := `"mrsigner":"` + testTdxModuleMrSigner + `","attributes":"0000000000000000","attributesMask":"FFFFFFFFFFFFFFFF"`
+	tcbInfo := strings.Replace(string(signedTcbInfo.TcbInfo), `{"id":"SGX"`, `{"id":"TDX"`, 1)
+	tcbInfo = strings.TrimSuffix(tcbInfo, `}`) + `,"tdxModule":{` + module + `},"tdxModuleIdentities":[{"id":"TDX_01",` + module +
+		`,"tcbLevels":[{"tcb":{"isvsvn":4},"tcbDate":"2024-03-13T00:00:00Z","tcbStatus":"UpToDate"},` +
+		`{"tcb":{"isvsvn":2},"tcbDate":"2023-08-09T00:00:00Z","tcbStatus":"OutOfDate","advisoryIDs":["INTEL-SA-00960"]}]}]}`
+	qeIdentity := strings.Replace(string(signedQeIdentity.EnclaveIdentity), `{"id":"QE"`, `{"id":"TD_QE"`, 1)
+
+	sgxCollateral.TcbInfo = newSignedBody(t, p.tcbSigningKey, "tcbInfo", tcbInfo)
+	sgxCollateral.QeIdentity = newSignedBody(t, p.tcbSigningKey, "enclaveIdentity", qeIdentity)
+
+	return sgxCollateral
+}
+
+// creates a TD quote, signed by the TD quoting enclave of the platform
+func (p *testPki) tdQuote(t *testing.T, reportBody quote.TdReportBody) []byte {
+	attestationKey, publicKey, qeReportCertificationData := p.qeReportCertificationData(t)
+
+	signed := new(bytes.Buffer)
+	binary.Write(signed, binary.LittleEndian, quote.Header{
+		Version:            quote.QUOTE_VERSION_4,
+		AttestationKeyType: quote.ATTESTATION_KEY_TYPE_ECDSA_P256,
+		TeeType:            quote.TEE_TYPE_TDX,
+		PceSvn:             13,
+	})
+	binary.Write(signed, binary.LittleEndian, reportBody)
+
+	signatureData := new(bytes.Buffer)
+	signatureData.Write(sign(t, attestationKey, signed.Bytes()))
+	signatureData.Write(publicKey[:])
+	binary.Write(signatureData, binary.LittleEndian, uint16(quote.CERTIFICATION_DATA_QE_REPORT))
+	binary.Write(signatureData, binary.LittleEndian, uint32(len(qeReportCertificationData)))
+	signatureData.Write(qeReportCertificationData)
+
+	buf := bytes.NewBuffer(signed.Bytes())
+	binary.Write(buf, binary.LittleEndian, uint32(signatureData.Len()))
+	buf.Write(signatureData.Bytes())
+
+	return buf.Bytes()
+}
+
+// a TD report of a TDX module with the SVN and the major version, the other TDX components are at tdxSvn
+func testTdReportBody(moduleSvn byte, majorVersion byte, tdxSvn byte) quote.TdReportBody {
+	reportBody := quote.TdReportBody{}
+	for i := range reportBody.TeeTcbSvn {
+		reportBody.TeeTcbSvn[i] = tdxSvn
+	}
+	reportBody.TeeTcbSvn[0], reportBody.TeeTcbSvn[1] = moduleSvn, majorVersion
+
+	copy(reportBody.MrTd[:], bytes.Repeat([]byte{0xaa}, 48))
+	copy(reportBody.ReportData[:], []byte("report data"))
+
+	return reportBody
+}
+
+func TestVerifyTd(t *testing.T) {
+	pki := newTestPki(t, 3, 13)
+	report := pki.tdQuote(t, testTdReportBody(4, 1, 3))
+
+	otherModule := testTdReportBody(4, 1, 3)
+	otherModule.MrSignerSeam[0] = 0xff
+
+	tamperedReport := bytes.Clone(report)
+	tamperedReport[quote.HEADER_SIZE+quote.TD_REPORT_BODY_SIZE-1] ^= 0xff
+
+	intelPin := RootCaPin{}
+
+	tests := []struct {
+		name           string
+		report         []byte
+		collateral     *Collateral
+		pin            *RootCaPin
+		wantErr        error
+		wantStatus     tcbstatus.Status
+		wantAdvisories []string
+	}{
+		{
+			name:       "up to date",
+			report:     report,
+			collateral: pki.tdCollateral(t, testCollateralOptions{}),
+			wantStatus: tcbstatus.UpToDate,
+		},
+		{
+			name:       "TDX 1.0 module",
+			report:     pki.tdQuote(t, testTdReportBody(3, 0, 3)),
+			collateral: pki.tdCollateral(t, testCollateralOptions{}),
+			wantStatus: tcbstatus.UpToDate,
+		},
+		{
+			name:           "out of date TDX module",
+			report:         pki.tdQuote(t, testTdReportBody(2, 1, 3)),
+			collateral:     pki.tdCollateral(t, testCollateralOptions{}),
+			wantStatus:     tcbstatus.OutOfDate,
+			wantAdvisories: []string{"INTEL-SA-00960"},
+		},
+		{
+			name:           "out of date TDX components",
+			report:         pki.tdQuote(t, testTdReportBody(4, 1, 2)),
+			collateral:     pki.tdCollateral(t, testCollateralOptions{}),
+			wantStatus:     tcbstatus.OutOfDate,
+			wantAdvisories: []string{"INTEL-SA-00837"},
+		},
+		{
+			name:       "no matching TCB level",
+			report:     pki.tdQuote(t, testTdReportBody(4, 1, 1)),
+			collateral: pki.tdCollateral(t, testCollateralOptions{}),
+			wantErr:    ErrTcbLevelNotFound,
+		},
+		{
+			name:       "other TDX module",
+			report:     pki.tdQuote(t, otherModule),
+			collateral: pki.tdCollateral(t, testCollateralOptions{}),
+			wantErr:    ErrTdxModuleMismatch,
+		},
+		{
+			name:       "unknown TDX module major version",
+			report:     pki.tdQuote(t, testTdReportBody(4, 2, 3)),
+			collateral: pki.tdCollateral(t, testCollateralOptions{}),
+			wantErr:    ErrTdxModuleMismatch,
+		},
+		{
+			name:       "SGX collateral",
+			report:     report,
+			collateral: pki.collateral(t, testCollateralOptions{}),
+			wantErr:    ErrUnsupportedCollateral,
+		},
+		{
+			name:       "tampered quote",
+			report:     tamperedReport,
+			collateral: pki.tdCollateral(t, testCollateralOptions{}),
+			wantErr:    ErrInvalidSignature,
+		},
+		{
+			name:       "other quoting enclave",
+			report:     report,
+			collateral: pki.tdCollateral(t, testCollateralOptions{qeMrSigner: strings.Repeat("ff", 32)}),
+			wantErr:    ErrQeIdentityMismatch,
+		},
+		{
+			name:       "root CA isn't Intel's",
+			report:     report,
+			collateral: pki.tdCollateral(t, testCollateralOptions{}),
+			pin:        &intelPin,
+			wantErr:    ErrUntrustedRootCa,
+		},
+		{
+			name:       "SGX quote",
+			report:     pki.quote(t, testReportBody()),
+			collateral: pki.tdCollateral(t, testCollateralOptions{}),
+			wantErr:    quote.ErrUnsupportedVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pin := pki.pin()
+			if tt.pin != nil {
+				pin = *tt.pin
+			}
+
+			got, err := VerifyTd(tt.report, tt.collateral, pin, time.Now())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyTd() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.TcbStatus != tt.wantStatus || strings.Join(got.TcbAdvisories, ",") != strings.Join(tt.wantAdvisories, ",") || got.Expired {
+				t.Errorf("VerifyTd() = %+v", got)
+			}
+			if got.Pck.Fmspc != testFmspc || got.Quote.ReportBody.MrTd != testTdReportBody(4, 1, 3).MrTd {
+				t.Errorf("VerifyTd() PCK = %+v, quote = %+v", got.Pck, got.Quote)
+			}
+		})
+	}
+}
+
+func TestCache_td(t *testing.T) {
+	pki := newTestPki(t, 3, 13)
+	report := pki.tdQuote(t, testTdReportBody(4, 1, 3))
+
+	cache := NewCache(t.TempDir())
+
+	// the SGX collateral of the platform isn't used for TD quotes
+	if err := cache.Store(testFmspc, pki.collateral(t, testCollateralOptions{})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.VerifyTd(report, pki.pin(), time.Now()); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("VerifyTd() error = %v, want %v", err, ErrNotCached)
+	}
+
+	if err := cache.StoreTd(testFmspc, pki.tdCollateral(t, testCollateralOptions{})); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := cache.VerifyTd(report, pki.pin(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TcbStatus != tcbstatus.UpToDate {
+		t.Errorf("VerifyTd() = %+v", result)
+	}
+}
+
+func TestFetchTd(t *testing.T) {
+	pki := newTestPki(t, 3, 13)
+	collateral := pki.tdCollateral(t, testCollateralOptions{})
+
+	rootCaCrl, _ := pem.Decode([]byte(collateral.RootCaCrl))
+
+	server := newTestCollateralServer(t, "/tdx/certification/v4", collateral, rootCaCrl.Bytes)
+
+	fetched, err := FetchTd(context.Background(), server.Client(), server.URL+"/tdx/certification/v4", server.URL+"/sgx/certification/v4", server.URL+"/IntelSGXRootCA.der", testFmspc, PCK_CA_PROCESSOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *fetched != *collateral {
+		t.Errorf("FetchTd() = %+v, want %+v", fetched, collateral)
+	}
+
+	if _, err := VerifyTd(pki.tdQuote(t, testTdReportBody(4, 1, 3)), fetched, pki.pin(), time.Now()); err != nil {
+		t.Errorf("VerifyTd() error = %v", err)
+	}
+}
@@ -0,0 +1,343 @@
+// Package quote parses SGX and TDX DCAP quotes without verifying them, so the contents of a report can be inspected
+// even if it fails verification.
+package quote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	HEADER_SIZE      = 48
+	REPORT_BODY_SIZE = 384
+
+	// the supported version of SGX quotes, see td.go for TD quotes
+	QUOTE_VERSION_3 = 3
+	// ECDSA-256-with-P-256 attestation key
+	ATTESTATION_KEY_TYPE_ECDSA_P256 = 2
+
+	// the certification data is the PCK certificate chain in PEM
+	CERTIFICATION_DATA_PCK_CERT_CHAIN = 5
+
+	ATTRIBUTE_INIT   = 0x01
+	ATTRIBUTE_DEBUG  = 0x02
+	ATTRIBUTE_MODE64 = 0x04
+
+	// the Open Enclave report header of the remote reports created by EGo
+	oeReportHeaderSize    = 16
+	oeReportHeaderVersion = 1
+	oeReportTypeRemote    = 2
+)
+
+var (
+	ErrTooShort           = errors.New("quote: the quote is too short")
+	ErrUnsupportedVersion = errors.New("quote: unsupported quote version")
+	ErrMalformed          = errors.New("quote: malformed quote")
+)
+
+// Header is the quote header
+type Header struct {
+	Version            uint16
+	AttestationKeyType uint16
+	TeeType            uint32
+	QeSvn              uint16
+	PceSvn             uint16
+	QeVendorId         [16]byte
+	UserData           [20]byte
+}
+
+// Attributes are the enclave attributes
+type Attributes struct {
+	Flags uint64
+	Xfrm  uint64
+}
+
+// ReportBody is the report of the attested enclave
+type ReportBody struct {
+	CpuSvn       [16]byte
+	MiscSelect   uint32
+	_            [12]byte
+	IsvExtProdId [16]byte
+	Attributes   Attributes
+	// MRENCLAVE, the unique ID
+	MrEnclave [32]byte
+	_         [32]byte
+	// MRSIGNER, the signer ID
+	MrSigner    [32]byte
+	_           [32]byte
+	ConfigId    [64]byte
+	IsvProdId   uint16
+	IsvSvn      uint16
+	ConfigSvn   uint16
+	_           [42]byte
+	IsvFamilyId [16]byte
+	ReportData  [64]byte
+}
+
+// Quote is a parsed DCAP quote
+type Quote struct {
+	Header     Header
+	ReportBody ReportBody
+	// the quote signature, the attestation key, the QE report and the certification data
+	SignatureData []byte
+	// the header and the report body as signed with the attestation key
+	SignedData []byte
+}
+
+// SignatureData is the signature data of a quote with an ECDSA-256-with-P-256 attestation key
+type SignatureData struct {
+	// the quote signature, r and s big-endian
+	Signature [64]byte
+	// the attestation public key, x and y big-endian
+	AttestationKey [64]byte
+	// the report of the quoting enclave, signed with the PCK
+	QeReport    ReportBody
+	RawQeReport []byte
+	// the QE report signature, r and s big-endian
+	QeReportSignature     [64]byte
+	QeAuthData            []byte
+	CertificationDataType uint16
+	CertificationData     []byte
+}
+
+// Debug reports whether the enclave is a debug enclave
+func (a Attributes) Debug() bool {
+	return a.Flags&ATTRIBUTE_DEBUG != 0
+}
+
+// reads the Open Enclave report header, returns the size of the report that follows it
+func readReportHeader(reportBytes []byte) (uint64, bool) {
+	if len(reportBytes) < oeReportHeaderSize {
+		return 0, false
+	}
+
+	version := binary.LittleEndian.Uint32(reportBytes[0:])
+	reportType := binary.LittleEndian.Uint32(reportBytes[4:])
+	reportSize := binary.LittleEndian.Uint64(reportBytes[8:])
+
+	if version != oeReportHeaderVersion || reportType != oeReportTypeRemote {
+		return 0, false
+	}
+
+	return reportSize, true
+}
+
+// strips the Open Enclave report header if the quote has one
+func stripReportHeader(reportBytes []byte) []byte {
+	reportSize, ok := readReportHeader(reportBytes)
+	if !ok || reportSize != uint64(len(reportBytes)-oeReportHeaderSize) {
+		return reportBytes
+	}
+
+	return reportBytes[oeReportHeaderSize:]
+}
+
+// Trim removes the bytes that follow a DCAP quote, either bare or in an EGo remote report, e.g. the padding of a report
+// stored on chain. The trimmed report is validated with Parse.
+func Trim(reportBytes []byte) ([]byte, error) {
+	var reportLen uint64
+	if reportSize, ok := readReportHeader(reportBytes); ok {
+		reportLen = oeReportHeaderSize + reportSize
+	} else {
+		if len(reportBytes) < HEADER_SIZE+REPORT_BODY_SIZE+4 {
+			return nil, ErrTooShort
+		}
+		signatureDataLength := binary.LittleEndian.Uint32(reportBytes[HEADER_SIZE+REPORT_BODY_SIZE:])
+		reportLen = HEADER_SIZE + REPORT_BODY_SIZE + 4 + uint64(signatureDataLength)
+	}
+
+	if reportLen > uint64(len(reportBytes)) {
+		return nil, ErrTooShort
+	}
+
+	trimmed := reportBytes[:reportLen]
+	if _, err := Parse(trimmed); err != nil {
+		return nil, err
+	}
+
+	return trimmed, nil
+}
+
+// Parse parses a DCAP quote, either bare or in an EGo remote report
+func Parse(reportBytes []byte) (*Quote, error) {
+	buf := stripReportHeader(reportBytes)
+
+	if len(buf) < HEADER_SIZE+REPORT_BODY_SIZE+4 {
+		return nil, ErrTooShort
+	}
+
+	quote := new(Quote)
+
+	reader := bytes.NewReader(buf)
+	if err := binary.Read(reader, binary.LittleEndian, &quote.Header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if quote.Header.Version != QUOTE_VERSION_3 {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, quote.Header.Version)
+	}
+
+	if err := binary.Read(reader, binary.LittleEndian, &quote.ReportBody); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	var signatureDataLength uint32
+	if err := binary.Read(reader, binary.LittleEndian, &signatureDataLength); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if uint64(signatureDataLength) != uint64(reader.Len()) {
+		return nil, fmt.Errorf("%w: signature data is %d bytes, expected %d", ErrMalformed, reader.Len(), signatureDataLength)
+	}
+
+	quote.SignatureData = buf[len(buf)-reader.Len():]
+	quote.SignedData = buf[:HEADER_SIZE+REPORT_BODY_SIZE]
+
+	return quote, nil
+}
+
+// ParseSignatureData parses the signature data of a quote with an ECDSA-256-with-P-256 attestation key
+func ParseSignatureData(signatureData []byte) (*SignatureData, error) {
+	data := new(SignatureData)
+
+	reader := bytes.NewReader(signatureData)
+	if _, err := io.ReadFull(reader, data.Signature[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if _, err := io.ReadFull(reader, data.AttestationKey[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if err := parseQeReportCertificationData(reader, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// parses the QE report, its signature, the QE authentication data and the certification data of the PCK, which follow
+// the attestation key in quotes of version 3 and are nested in the certification data in quotes of version 4
+func parseQeReportCertificationData(reader *bytes.Reader, data *SignatureData) error {
+	data.RawQeReport = make([]byte, REPORT_BODY_SIZE)
+	if _, err := io.ReadFull(reader, data.RawQeReport); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if err := binary.Read(bytes.NewReader(data.RawQeReport), binary.LittleEndian, &data.QeReport); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if _, err := io.ReadFull(reader, data.QeReportSignature[:]); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	var qeAuthDataLength uint16
+	if err := binary.Read(reader, binary.LittleEndian, &qeAuthDataLength); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	data.QeAuthData = make([]byte, qeAuthDataLength)
+	if _, err := io.ReadFull(reader, data.QeAuthData); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	var certificationDataLength uint32
+	if err := binary.Read(reader, binary.LittleEndian, &data.CertificationDataType); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if err := binary.Read(reader, binary.LittleEndian, &certificationDataLength); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if uint64(certificationDataLength) > uint64(reader.Len()) {
+		return fmt.Errorf("%w: certification data is %d bytes, expected %d", ErrMalformed, reader.Len(), certificationDataLength)
+	}
+
+	data.CertificationData = make([]byte, certificationDataLength)
+	reader.Read(data.CertificationData)
+
+	return nil
+}
+
+func (h Header) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Version            uint16 `json:"version"`
+		AttestationKeyType uint16 `json:"attestationKeyType"`
+		TeeType            uint32 `json:"teeType"`
+		QeSvn              uint16 `json:"qeSvn"`
+		PceSvn             uint16 `json:"pceSvn"`
+		QeVendorId         string `json:"qeVendorId"`
+		UserData           string `json:"userData"`
+	}{
+		Version:            h.Version,
+		AttestationKeyType: h.AttestationKeyType,
+		TeeType:            h.TeeType,
+		QeSvn:              h.QeSvn,
+		PceSvn:             h.PceSvn,
+		QeVendorId:         hex.EncodeToString(h.QeVendorId[:]),
+		UserData:           hex.EncodeToString(h.UserData[:]),
+	})
+}
+
+func (a Attributes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Flags  string `json:"flags"`
+		Xfrm   string `json:"xfrm"`
+		Debug  bool   `json:"debug"`
+		Mode64 bool   `json:"mode64bit"`
+	}{
+		Flags:  fmt.Sprintf("%016x", a.Flags),
+		Xfrm:   fmt.Sprintf("%016x", a.Xfrm),
+		Debug:  a.Debug(),
+		Mode64: a.Flags&ATTRIBUTE_MODE64 != 0,
+	})
+}
+
+func (r ReportBody) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		CpuSvn       string     `json:"cpuSvn"`
+		MiscSelect   uint32     `json:"miscSelect"`
+		IsvExtProdId string     `json:"isvExtProdId"`
+		Attributes   Attributes `json:"attributes"`
+		MrEnclave    string     `json:"mrEnclave"`
+		MrSigner     string     `json:"mrSigner"`
+		ConfigId     string     `json:"configId"`
+		IsvProdId    uint16     `json:"isvProdId"`
+		IsvSvn       uint16     `json:"isvSvn"`
+		ConfigSvn    uint16     `json:"configSvn"`
+		IsvFamilyId  string     `json:"isvFamilyId"`
+		ReportData   string     `json:"reportData"`
+	}{
+		CpuSvn:       hex.EncodeToString(r.CpuSvn[:]),
+		MiscSelect:   r.MiscSelect,
+		IsvExtProdId: hex.EncodeToString(r.IsvExtProdId[:]),
+		Attributes:   r.Attributes,
+		MrEnclave:    hex.EncodeToString(r.MrEnclave[:]),
+		MrSigner:     hex.EncodeToString(r.MrSigner[:]),
+		ConfigId:     hex.EncodeToString(r.ConfigId[:]),
+		IsvProdId:    r.IsvProdId,
+		IsvSvn:       r.IsvSvn,
+		ConfigSvn:    r.ConfigSvn,
+		IsvFamilyId:  hex.EncodeToString(r.IsvFamilyId[:]),
+		ReportData:   hex.EncodeToString(r.ReportData[:]),
+	})
+}
+
+func (q *Quote) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Header              Header     `json:"header"`
+		ReportBody          ReportBody `json:"reportBody"`
+		SignatureDataLength int        `json:"signatureDataLength"`
+	}{
+		Header:              q.Header,
+		ReportBody:          q.ReportBody,
+		SignatureDataLength: len(q.SignatureData),
+	})
+}
@@ -0,0 +1,159 @@
+package quote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// creates a quote with the report body and some signature data
+func newTestQuote(version uint16, reportBody ReportBody, signatureData []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, Header{
+		Version:            version,
+		AttestationKeyType: ATTESTATION_KEY_TYPE_ECDSA_P256,
+		QeSvn:              8,
+		PceSvn:             13,
+	})
+	binary.Write(buf, binary.LittleEndian, reportBody)
+	binary.Write(buf, binary.LittleEndian, uint32(len(signatureData)))
+	buf.Write(signatureData)
+
+	return buf.Bytes()
+}
+
+// wraps the quote in the Open Enclave report header of EGo remote reports
+func withReportHeader(quote []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint32(oeReportHeaderVersion))
+	binary.Write(buf, binary.LittleEndian, uint32(oeReportTypeRemote))
+	binary.Write(buf, binary.LittleEndian, uint64(len(quote)))
+	buf.Write(quote)
+
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	if binary.Size(Header{}) != HEADER_SIZE || binary.Size(ReportBody{}) != REPORT_BODY_SIZE {
+		t.Fatalf("header is %d bytes, report body is %d bytes", binary.Size(Header{}), binary.Size(ReportBody{}))
+	}
+
+	reportBody := ReportBody{
+		Attributes: Attributes{Flags: ATTRIBUTE_INIT | ATTRIBUTE_DEBUG | ATTRIBUTE_MODE64, Xfrm: 0xe7},
+		IsvProdId:  1,
+		IsvSvn:     2,
+	}
+	copy(reportBody.MrEnclave[:], bytes.Repeat([]byte{0xaa}, 32))
+	copy(reportBody.MrSigner[:], bytes.Repeat([]byte{0xbb}, 32))
+	copy(reportBody.ReportData[:], []byte("report data"))
+
+	signatureData := bytes.Repeat([]byte{0xcc}, 100)
+	quote := newTestQuote(QUOTE_VERSION_3, reportBody, signatureData)
+
+	tests := []struct {
+		name    string
+		report  []byte
+		wantErr error
+	}{
+		{name: "bare quote", report: quote},
+		{name: "EGo remote report", report: withReportHeader(quote)},
+		{name: "too short", report: quote[:HEADER_SIZE], wantErr: ErrTooShort},
+		{name: "truncated signature data", report: quote[:len(quote)-1], wantErr: ErrMalformed},
+		{name: "unsupported version", report: newTestQuote(4, reportBody, signatureData), wantErr: ErrUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.report)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.ReportBody != reportBody || !bytes.Equal(got.SignatureData, signatureData) || got.Header.PceSvn != 13 {
+				t.Errorf("Parse() = %+v", got)
+			}
+			if !got.ReportBody.Attributes.Debug() {
+				t.Error("Parse() lost the debug attribute")
+			}
+
+			encoded, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{`"mrEnclave":"aaaa`, `"mrSigner":"bbbb`, `"debug":true`, `"isvSvn":2`, `"signatureDataLength":100`} {
+				if !strings.Contains(string(encoded), want) {
+					t.Errorf("json.Marshal() = %s, missing %s", encoded, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	quote := newTestQuote(QUOTE_VERSION_3, ReportBody{}, bytes.Repeat([]byte{0xcc}, 100))
+	remoteReport := withReportHeader(quote)
+	padding := make([]byte, 64)
+
+	tests := []struct {
+		name    string
+		report  []byte
+		want    []byte
+		wantErr error
+	}{
+		{name: "bare quote", report: append(bytes.Clone(quote), padding...), want: quote},
+		{name: "EGo remote report", report: append(bytes.Clone(remoteReport), padding...), want: remoteReport},
+		{name: "unpadded", report: remoteReport, want: remoteReport},
+		{name: "truncated quote", report: quote[:len(quote)-1], wantErr: ErrTooShort},
+		{name: "truncated remote report", report: remoteReport[:len(remoteReport)-1], wantErr: ErrTooShort},
+		{name: "unsupported version", report: newTestQuote(4, ReportBody{}, nil), wantErr: ErrUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Trim(tt.report)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Trim() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Trim() = %d bytes, want %d bytes", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseSignatureData(t *testing.T) {
+	qeAuthData := []byte("QE authentication data")
+	certificationData := []byte("-----BEGIN CERTIFICATE-----")
+
+	buf := new(bytes.Buffer)
+	buf.Write(bytes.Repeat([]byte{0x01}, 64))
+	buf.Write(bytes.Repeat([]byte{0x02}, 64))
+	binary.Write(buf, binary.LittleEndian, ReportBody{IsvProdId: 1, IsvSvn: 8})
+	buf.Write(bytes.Repeat([]byte{0x03}, 64))
+	binary.Write(buf, binary.LittleEndian, uint16(len(qeAuthData)))
+	buf.Write(qeAuthData)
+	binary.Write(buf, binary.LittleEndian, uint16(CERTIFICATION_DATA_PCK_CERT_CHAIN))
+	binary.Write(buf, binary.LittleEndian, uint32(len(certificationData)))
+	buf.Write(certificationData)
+	signatureData := buf.Bytes()
+
+	got, err := ParseSignatureData(signatureData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Signature[0] != 0x01 || got.AttestationKey[0] != 0x02 || got.QeReportSignature[0] != 0x03 || got.QeReport.IsvSvn != 8 || len(got.RawQeReport) != REPORT_BODY_SIZE {
+		t.Errorf("ParseSignatureData() = %+v", got)
+	}
+	if !bytes.Equal(got.QeAuthData, qeAuthData) || !bytes.Equal(got.CertificationData, certificationData) || got.CertificationDataType != CERTIFICATION_DATA_PCK_CERT_CHAIN {
+		t.Errorf("ParseSignatureData() = %+v", got)
+	}
+
+	if _, err := ParseSignatureData(signatureData[:len(signatureData)-1]); !errors.Is(err, ErrMalformed) {
+		t.Errorf("ParseSignatureData() error = %v, want %v", err, ErrMalformed)
+	}
+}
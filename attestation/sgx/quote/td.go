@@ -0,0 +1,197 @@
+package quote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	TD_REPORT_BODY_SIZE = 584
+
+	// the quote version and TEE type of TD quotes
+	QUOTE_VERSION_4 = 4
+	TEE_TYPE_TDX    = 0x81
+
+	// the certification data is the QE report certification data, with the PCK certificate chain nested in it
+	CERTIFICATION_DATA_QE_REPORT = 6
+
+	// TUD.DEBUG of the TD attributes
+	TD_ATTRIBUTE_DEBUG = 0x01
+)
+
+// TdReportBody is the report of the attested trust domain
+type TdReportBody struct {
+	// the TCB SVN of the TDX module, the SVN first and the major version second
+	TeeTcbSvn      [16]byte
+	MrSeam         [48]byte
+	MrSignerSeam   [48]byte
+	SeamAttributes uint64
+	TdAttributes   uint64
+	Xfam           uint64
+	// MRTD, the measurement of the initial TD contents
+	MrTd          [48]byte
+	MrConfigId    [48]byte
+	MrOwner       [48]byte
+	MrOwnerConfig [48]byte
+	// the runtime measurement registers
+	Rtmrs      [4][48]byte
+	ReportData [64]byte
+}
+
+// TdQuote is a parsed TD quote
+type TdQuote struct {
+	Header     Header
+	ReportBody TdReportBody
+	// the quote signature, the attestation key and the QE report certification data
+	SignatureData []byte
+	// the header and the report body as signed with the attestation key
+	SignedData []byte
+}
+
+// Debug reports whether the TD is a debug TD
+func (r TdReportBody) Debug() bool {
+	return r.TdAttributes&TD_ATTRIBUTE_DEBUG != 0
+}
+
+// IsTd reports whether a quote is a TD quote, without parsing it
+func IsTd(reportBytes []byte) bool {
+	if len(reportBytes) < HEADER_SIZE {
+		return false
+	}
+
+	version := binary.LittleEndian.Uint16(reportBytes[0:])
+	teeType := binary.LittleEndian.Uint32(reportBytes[4:])
+
+	return version == QUOTE_VERSION_4 && teeType == TEE_TYPE_TDX
+}
+
+// ParseTd parses a TD quote of version 4
+func ParseTd(reportBytes []byte) (*TdQuote, error) {
+	if len(reportBytes) < HEADER_SIZE+TD_REPORT_BODY_SIZE+4 {
+		return nil, ErrTooShort
+	}
+
+	quote := new(TdQuote)
+
+	reader := bytes.NewReader(reportBytes)
+	if err := binary.Read(reader, binary.LittleEndian, &quote.Header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if quote.Header.Version != QUOTE_VERSION_4 || quote.Header.TeeType != TEE_TYPE_TDX {
+		return nil, fmt.Errorf("%w: %d, TEE type %#x", ErrUnsupportedVersion, quote.Header.Version, quote.Header.TeeType)
+	}
+
+	if err := binary.Read(reader, binary.LittleEndian, &quote.ReportBody); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	var signatureDataLength uint32
+	if err := binary.Read(reader, binary.LittleEndian, &signatureDataLength); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if uint64(signatureDataLength) != uint64(reader.Len()) {
+		return nil, fmt.Errorf("%w: signature data is %d bytes, expected %d", ErrMalformed, reader.Len(), signatureDataLength)
+	}
+
+	quote.SignatureData = reportBytes[len(reportBytes)-reader.Len():]
+	quote.SignedData = reportBytes[:HEADER_SIZE+TD_REPORT_BODY_SIZE]
+
+	return quote, nil
+}
+
+// ParseTdSignatureData parses the signature data of a TD quote. The QE report and the PCK certification data are read
+// from the QE report certification data, the certification data type is the one of the PCK certification data.
+func ParseTdSignatureData(signatureData []byte) (*SignatureData, error) {
+	data := new(SignatureData)
+
+	reader := bytes.NewReader(signatureData)
+	if _, err := io.ReadFull(reader, data.Signature[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if _, err := io.ReadFull(reader, data.AttestationKey[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	var certificationDataType uint16
+	if err := binary.Read(reader, binary.LittleEndian, &certificationDataType); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if certificationDataType != CERTIFICATION_DATA_QE_REPORT {
+		return nil, fmt.Errorf("%w: certification data type %d, expected the QE report certification data", ErrMalformed, certificationDataType)
+	}
+
+	var certificationDataLength uint32
+	if err := binary.Read(reader, binary.LittleEndian, &certificationDataLength); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if uint64(certificationDataLength) > uint64(reader.Len()) {
+		return nil, fmt.Errorf("%w: certification data is %d bytes, expected %d", ErrMalformed, reader.Len(), certificationDataLength)
+	}
+
+	certificationData := make([]byte, certificationDataLength)
+	reader.Read(certificationData)
+
+	if err := parseQeReportCertificationData(bytes.NewReader(certificationData), data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r TdReportBody) MarshalJSON() ([]byte, error) {
+	var rtmrs [4]string
+	for idx, rtmr := range r.Rtmrs {
+		rtmrs[idx] = hex.EncodeToString(rtmr[:])
+	}
+
+	return json.Marshal(struct {
+		TeeTcbSvn      string    `json:"teeTcbSvn"`
+		MrSeam         string    `json:"mrSeam"`
+		MrSignerSeam   string    `json:"mrSignerSeam"`
+		SeamAttributes string    `json:"seamAttributes"`
+		TdAttributes   string    `json:"tdAttributes"`
+		Debug          bool      `json:"debug"`
+		Xfam           string    `json:"xfam"`
+		MrTd           string    `json:"mrTd"`
+		MrConfigId     string    `json:"mrConfigId"`
+		MrOwner        string    `json:"mrOwner"`
+		MrOwnerConfig  string    `json:"mrOwnerConfig"`
+		Rtmrs          [4]string `json:"rtmrs"`
+		ReportData     string    `json:"reportData"`
+	}{
+		TeeTcbSvn:      hex.EncodeToString(r.TeeTcbSvn[:]),
+		MrSeam:         hex.EncodeToString(r.MrSeam[:]),
+		MrSignerSeam:   hex.EncodeToString(r.MrSignerSeam[:]),
+		SeamAttributes: fmt.Sprintf("%016x", r.SeamAttributes),
+		TdAttributes:   fmt.Sprintf("%016x", r.TdAttributes),
+		Debug:          r.Debug(),
+		Xfam:           fmt.Sprintf("%016x", r.Xfam),
+		MrTd:           hex.EncodeToString(r.MrTd[:]),
+		MrConfigId:     hex.EncodeToString(r.MrConfigId[:]),
+		MrOwner:        hex.EncodeToString(r.MrOwner[:]),
+		MrOwnerConfig:  hex.EncodeToString(r.MrOwnerConfig[:]),
+		Rtmrs:          rtmrs,
+		ReportData:     hex.EncodeToString(r.ReportData[:]),
+	})
+}
+
+func (q *TdQuote) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Header              Header       `json:"header"`
+		ReportBody          TdReportBody `json:"reportBody"`
+		SignatureDataLength int          `json:"signatureDataLength"`
+	}{
+		Header:              q.Header,
+		ReportBody:          q.ReportBody,
+		SignatureDataLength: len(q.SignatureData),
+	})
+}
@@ -0,0 +1,131 @@
+package quote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// creates a TD quote with the report body and some signature data
+func newTestTdQuote(teeType uint32, reportBody TdReportBody, signatureData []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, Header{
+		Version:            QUOTE_VERSION_4,
+		AttestationKeyType: ATTESTATION_KEY_TYPE_ECDSA_P256,
+		TeeType:            teeType,
+		QeSvn:              4,
+		PceSvn:             13,
+	})
+	binary.Write(buf, binary.LittleEndian, reportBody)
+	binary.Write(buf, binary.LittleEndian, uint32(len(signatureData)))
+	buf.Write(signatureData)
+
+	return buf.Bytes()
+}
+
+func TestParseTd(t *testing.T) {
+	if binary.Size(TdReportBody{}) != TD_REPORT_BODY_SIZE {
+		t.Fatalf("TD report body is %d bytes", binary.Size(TdReportBody{}))
+	}
+
+	reportBody := TdReportBody{TdAttributes: TD_ATTRIBUTE_DEBUG}
+	copy(reportBody.MrTd[:], bytes.Repeat([]byte{0xaa}, 48))
+	copy(reportBody.Rtmrs[3][:], bytes.Repeat([]byte{0xbb}, 48))
+	copy(reportBody.ReportData[:], []byte("report data"))
+
+	signatureData := bytes.Repeat([]byte{0xcc}, 100)
+	quote := newTestTdQuote(TEE_TYPE_TDX, reportBody, signatureData)
+
+	tests := []struct {
+		name    string
+		report  []byte
+		wantErr error
+	}{
+		{name: "TD quote", report: quote},
+		{name: "too short", report: quote[:HEADER_SIZE+REPORT_BODY_SIZE], wantErr: ErrTooShort},
+		{name: "truncated signature data", report: quote[:len(quote)-1], wantErr: ErrMalformed},
+		{name: "SGX quote of version 4", report: newTestTdQuote(0, reportBody, signatureData), wantErr: ErrUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTd(tt.report)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseTd() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if IsTd(tt.report) && errors.Is(err, ErrUnsupportedVersion) {
+					t.Error("IsTd() = true for an unsupported quote")
+				}
+				return
+			}
+
+			if !IsTd(tt.report) {
+				t.Error("IsTd() = false")
+			}
+			if got.ReportBody != reportBody || !bytes.Equal(got.SignatureData, signatureData) || len(got.SignedData) != HEADER_SIZE+TD_REPORT_BODY_SIZE {
+				t.Errorf("ParseTd() = %+v", got)
+			}
+
+			encoded, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{`"mrTd":"aaaa`, `"debug":true`, `"teeType":129`, `"signatureDataLength":100`} {
+				if !strings.Contains(string(encoded), want) {
+					t.Errorf("json.Marshal() = %s, missing %s", encoded, want)
+				}
+			}
+		})
+	}
+
+	if IsTd(newTestQuote(QUOTE_VERSION_3, ReportBody{}, signatureData)) {
+		t.Error("IsTd() = true for an SGX quote")
+	}
+}
+
+func TestParseTdSignatureData(t *testing.T) {
+	qeAuthData := []byte("QE authentication data")
+	pckCertificationData := []byte("-----BEGIN CERTIFICATE-----")
+
+	qeReportCertificationData := new(bytes.Buffer)
+	binary.Write(qeReportCertificationData, binary.LittleEndian, ReportBody{IsvProdId: 2, IsvSvn: 4})
+	qeReportCertificationData.Write(bytes.Repeat([]byte{0x03}, 64))
+	binary.Write(qeReportCertificationData, binary.LittleEndian, uint16(len(qeAuthData)))
+	qeReportCertificationData.Write(qeAuthData)
+	binary.Write(qeReportCertificationData, binary.LittleEndian, uint16(CERTIFICATION_DATA_PCK_CERT_CHAIN))
+	binary.Write(qeReportCertificationData, binary.LittleEndian, uint32(len(pckCertificationData)))
+	qeReportCertificationData.Write(pckCertificationData)
+
+	buf := new(bytes.Buffer)
+	buf.Write(bytes.Repeat([]byte{0x01}, 64))
+	buf.Write(bytes.Repeat([]byte{0x02}, 64))
+	binary.Write(buf, binary.LittleEndian, uint16(CERTIFICATION_DATA_QE_REPORT))
+	binary.Write(buf, binary.LittleEndian, uint32(qeReportCertificationData.Len()))
+	buf.Write(qeReportCertificationData.Bytes())
+	signatureData := buf.Bytes()
+
+	got, err := ParseTdSignatureData(signatureData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Signature[0] != 0x01 || got.AttestationKey[0] != 0x02 || got.QeReportSignature[0] != 0x03 || got.QeReport.IsvSvn != 4 {
+		t.Errorf("ParseTdSignatureData() = %+v", got)
+	}
+	if !bytes.Equal(got.QeAuthData, qeAuthData) || !bytes.Equal(got.CertificationData, pckCertificationData) || got.CertificationDataType != CERTIFICATION_DATA_PCK_CERT_CHAIN {
+		t.Errorf("ParseTdSignatureData() = %+v", got)
+	}
+
+	if _, err := ParseTdSignatureData(signatureData[:len(signatureData)-1]); !errors.Is(err, ErrMalformed) {
+		t.Errorf("ParseTdSignatureData() error = %v, want %v", err, ErrMalformed)
+	}
+
+	// the layout of version 3
+	v3 := append(bytes.Clone(signatureData[:128]), qeReportCertificationData.Bytes()...)
+	if _, err := ParseTdSignatureData(v3); !errors.Is(err, ErrMalformed) {
+		t.Errorf("ParseTdSignatureData() error = %v, want %v", err, ErrMalformed)
+	}
+}
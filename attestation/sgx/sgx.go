@@ -1,13 +1,18 @@
 package sgx
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/edgelesssys/ego/attestation"
 	"github.com/edgelesssys/ego/attestation/tcbstatus"
 	"github.com/edgelesssys/ego/eclient"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/collateral"
+	"github.com/venture23-aleo/oracle-verification-backend/u128"
 )
 
 var allowedAdvisories = map[string]bool{
@@ -15,9 +20,146 @@ var allowedAdvisories = map[string]bool{
 	"INTEL-SA-00615": true,
 }
 
+// AllowedAdvisory reports whether a TCB advisory is allowed under current policy
+func AllowedAdvisory(id string) bool {
+	return allowedAdvisories[id]
+}
+
+// the collateral cache for offline verification, reports are verified with the quote provider if it's not set
+var collateralCache *collateral.Cache
+
+// the root CA that collateral must be issued by, the Intel SGX root CA unless Init pins another one
+var rootCaPin collateral.RootCaPin
+
+// whether collateral past its next update is accepted, see Init
+var allowExpiredCollateral bool
+
+// Init sets up offline verification with the collateral in the cache directory instead of the quote provider.
+// Collateral must be issued by the Intel SGX root CA, including collateral supplied by the caller, or by the root CA
+// with the fingerprint if it's set, e.g. a test root CA. Collateral past its next update is rejected unless allowExpired
+// is set, since it may miss revoked PCK certificates and TCB recoveries.
+func Init(collateralCacheDir string, trustedRootCaFingerprint string, allowExpired bool) {
+	if collateralCacheDir != "" {
+		collateralCache = collateral.NewCache(collateralCacheDir)
+	}
+
+	rootCaPin = collateral.NewRootCaPin(trustedRootCaFingerprint)
+	allowExpiredCollateral = allowExpired
+}
+
+// ReportVerifier verifies the signature of a remote report and parses it. The errors are the ones of
+// eclient.VerifyRemoteReport.
+type ReportVerifier interface {
+	VerifyRemoteReport(reportBytes []byte) (attestation.Report, error)
+}
+
+// the quote provider, see eclient.VerifyRemoteReport
+type quoteProvider struct{}
+
+func (quoteProvider) VerifyRemoteReport(reportBytes []byte) (attestation.Report, error) {
+	return eclient.VerifyRemoteReport(reportBytes)
+}
+
+// the verifier of the reports that aren't verified with the collateral cache
+var reportVerifier ReportVerifier = quoteProvider{}
+
+// SetReportVerifier replaces the quote provider, e.g. with a fake one in tests. A nil verifier restores the quote
+// provider.
+func SetReportVerifier(verifier ReportVerifier) {
+	if verifier == nil {
+		verifier = quoteProvider{}
+	}
+
+	reportVerifier = verifier
+}
+
+// VerifiesOffline reports whether reports are verified with the collateral cache
+func VerifiesOffline() bool {
+	return collateralCache != nil
+}
+
+// converts the result of offline verification to a report like the one of the quote provider
+func offlineReport(result *collateral.Result) (attestation.Report, error) {
+	if result.Expired {
+		if !allowExpiredCollateral {
+			return attestation.Report{}, fmt.Errorf("%w: SGX collateral for FMSPC %s", collateral.ErrExpiredCollateral, result.Pck.Fmspc)
+		}
+		log.Printf("WARNING: SGX collateral for FMSPC %s is past its next update", result.Pck.Fmspc)
+	}
+
+	body := result.Quote.ReportBody
+
+	productId := make([]byte, 16)
+	binary.LittleEndian.PutUint16(productId, body.IsvProdId)
+
+	report := attestation.Report{
+		Data:            body.ReportData[:],
+		SecurityVersion: uint(body.IsvSvn),
+		Debug:           body.Attributes.Debug(),
+		UniqueID:        body.MrEnclave[:],
+		SignerID:        body.MrSigner[:],
+		ProductID:       productId,
+		TCBStatus:       result.TcbStatus,
+		TCBAdvisories:   result.TcbAdvisories,
+	}
+
+	// same as the quote provider, the report is returned with the error
+	if report.TCBStatus != tcbstatus.UpToDate {
+		return report, attestation.ErrTCBLevelInvalid
+	}
+
+	return report, nil
+}
+
+// VerifyRemoteReport verifies a remote report with the collateral cache if Init set one up, otherwise with the quote
+// provider or the verifier that replaces it, see SetReportVerifier. The errors are the ones of
+// eclient.VerifyRemoteReport.
+func VerifyRemoteReport(reportBytes []byte) (attestation.Report, error) {
+	if collateralCache == nil {
+		return reportVerifier.VerifyRemoteReport(reportBytes)
+	}
+
+	report, _, err := VerifyRemoteReportWithCollateral(reportBytes, nil)
+	return report, err
+}
+
+// VerifyRemoteReportWithCollateral verifies a remote report offline with the collateral, or with the collateral cache
+// if it's nil. The collateral must be issued by the pinned root CA, see Init. Also returns whether the collateral is
+// past its next update, which fails the verification with collateral.ErrExpiredCollateral unless Init allows it.
+// The other errors are the ones of eclient.VerifyRemoteReport.
+func VerifyRemoteReportWithCollateral(reportBytes []byte, bundle *collateral.Collateral) (attestation.Report, bool, error) {
+	var result *collateral.Result
+	var err error
+	switch {
+	case bundle != nil:
+		result, err = collateral.Verify(reportBytes, bundle, rootCaPin, time.Now())
+	case collateralCache != nil:
+		result, err = collateralCache.Verify(reportBytes, rootCaPin, time.Now())
+	default:
+		return attestation.Report{}, false, errors.New("no SGX collateral cache is configured")
+	}
+	if err != nil {
+		return attestation.Report{}, false, err
+	}
+
+	report, err := offlineReport(result)
+	return report, result.Expired, err
+}
+
 func VerifySgxReport(reportBytes []byte, targetUniqueId string) (*attestation.Report, error) {
-	report, err := eclient.VerifyRemoteReport(reportBytes)
+	report, err := VerifyRemoteReport(reportBytes)
+	return checkReport(report, err, targetUniqueId)
+}
 
+// VerifySgxReportWithCollateral is VerifySgxReport with the collateral supplied by the caller instead of the
+// configured source
+func VerifySgxReportWithCollateral(reportBytes []byte, bundle *collateral.Collateral, targetUniqueId string) (*attestation.Report, error) {
+	report, _, err := VerifyRemoteReportWithCollateral(reportBytes, bundle)
+	return checkReport(report, err, targetUniqueId)
+}
+
+// checks the TCB status and the measurements of a remote report, err is the error of verifying it
+func checkReport(report attestation.Report, err error, targetUniqueId string) (*attestation.Report, error) {
 	if err == attestation.ErrTCBLevelInvalid {
 		switch report.TCBStatus {
 		case tcbstatus.ConfigurationNeeded, tcbstatus.ConfigurationAndSWHardeningNeeded:
@@ -61,3 +203,17 @@ func VerifySgxReport(reportBytes []byte, targetUniqueId string) (*attestation.Re
 
 	return &report, nil
 }
+
+// FormatMeasurement formats a 32-byte SGX measurement, i.e. the unique ID or the signer ID, as the Aleo struct
+// that's stored in the oracle program
+func FormatMeasurement(measurement [32]byte) string {
+	// struct UniqueID {
+	//   chunk_1: u128,
+	//   chunk_2: u128
+	// }
+
+	chunk1, _ := u128.SliceToU128(measurement[0:16])
+	chunk2, _ := u128.SliceToU128(measurement[16:32])
+
+	return fmt.Sprintf("{ chunk_1: %su128, chunk_2: %su128 }", chunk1.String(), chunk2.String())
+}
@@ -0,0 +1,158 @@
+// Package sigstruct reads the SGX enclave signature structure (SIGSTRUCT) from an enclave binary signed with EGo.
+// The SIGSTRUCT carries the measurements the enclave reports when it runs, so they can be reproduced without SGX hardware.
+package sigstruct
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+)
+
+const (
+	SIGSTRUCT_SIZE = 1808
+	// the ELF section with the Open Enclave enclave properties, which EGo fills in when signing the enclave
+	ENCLAVE_PROPERTIES_SECTION = ".oeinfo"
+)
+
+// SIGSTRUCT field offsets, see Intel SDM Vol. 3D, 38.13
+const (
+	headerOffset        = 0
+	header2Offset       = 24
+	modulusOffset       = 128
+	modulusSize         = 384
+	exponentOffset      = 512
+	signatureOffset     = 516
+	signatureSize       = 384
+	miscSelectOffset    = 900
+	attributesOffset    = 928
+	enclaveHashOffset   = 960
+	isvProdIdOffset     = 1024
+	isvSvnOffset        = 1026
+	signedBodyEnd       = 1028
+	attributesDebugFlag = 0x02
+	measurementSize     = 32
+)
+
+var (
+	sigstructHeader  = []byte{0x06, 0x00, 0x00, 0x00, 0xe1, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00}
+	sigstructHeader2 = []byte{0x01, 0x01, 0x00, 0x00, 0x60, 0x00, 0x00, 0x00, 0x60, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00}
+)
+
+var (
+	ErrNoEnclaveProperties = errors.New("sigstruct: the binary has no " + ENCLAVE_PROPERTIES_SECTION + " section, it's not an EGo enclave")
+	ErrNotSigned           = errors.New("sigstruct: the enclave is not signed")
+	ErrMalformed           = errors.New("sigstruct: malformed SIGSTRUCT")
+	ErrInvalidSignature    = errors.New("sigstruct: the SIGSTRUCT signature is invalid")
+)
+
+// SigStruct has the measurements of a signed enclave
+type SigStruct struct {
+	// MRENCLAVE
+	UniqueId [32]byte
+	// MRSIGNER, the SHA-256 of the signing key modulus
+	SignerId        [32]byte
+	ProductId       uint16
+	SecurityVersion uint16
+	Debug           bool
+}
+
+// converts a little-endian SIGSTRUCT integer to a big-endian one
+func reversed(buf []byte) []byte {
+	result := slices.Clone(buf)
+	slices.Reverse(result)
+	return result
+}
+
+// Parse parses a SIGSTRUCT and verifies that it's signed by the key in it
+func Parse(buf []byte) (*SigStruct, error) {
+	if len(buf) != SIGSTRUCT_SIZE {
+		return nil, ErrMalformed
+	}
+
+	if !bytes.Equal(buf[headerOffset:headerOffset+len(sigstructHeader)], sigstructHeader) ||
+		!bytes.Equal(buf[header2Offset:header2Offset+len(sigstructHeader2)], sigstructHeader2) {
+		return nil, ErrMalformed
+	}
+
+	modulus := buf[modulusOffset : modulusOffset+modulusSize]
+
+	publicKey := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(reversed(modulus)),
+		E: int(binary.LittleEndian.Uint32(buf[exponentOffset:])),
+	}
+
+	// the signature covers the header and the enclave body fields
+	signedBody := sha256.New()
+	signedBody.Write(buf[:modulusOffset])
+	signedBody.Write(buf[miscSelectOffset:signedBodyEnd])
+
+	signature := reversed(buf[signatureOffset : signatureOffset+signatureSize])
+
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, signedBody.Sum(nil), signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	result := &SigStruct{
+		SignerId:        sha256.Sum256(modulus),
+		ProductId:       binary.LittleEndian.Uint16(buf[isvProdIdOffset:]),
+		SecurityVersion: binary.LittleEndian.Uint16(buf[isvSvnOffset:]),
+		Debug:           buf[attributesOffset]&attributesDebugFlag != 0,
+	}
+	copy(result.UniqueId[:], buf[enclaveHashOffset:enclaveHashOffset+measurementSize])
+
+	return result, nil
+}
+
+// FromELF finds and parses the SIGSTRUCT in the enclave properties of a signed enclave binary
+func FromELF(r io.ReaderAt) (*SigStruct, error) {
+	enclave, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	section := enclave.Section(ENCLAVE_PROPERTIES_SECTION)
+	if section == nil {
+		return nil, ErrNoEnclaveProperties
+	}
+
+	properties, err := section.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	// the position of the SIGSTRUCT in the enclave properties depends on the Open Enclave version,
+	// an unsigned enclave has it zeroed
+	for offset := 0; ; {
+		idx := bytes.Index(properties[offset:], sigstructHeader)
+		if idx < 0 {
+			return nil, ErrNotSigned
+		}
+		offset += idx
+
+		if len(properties)-offset >= SIGSTRUCT_SIZE &&
+			bytes.Equal(properties[offset+header2Offset:offset+header2Offset+len(sigstructHeader2)], sigstructHeader2) {
+			return Parse(properties[offset : offset+SIGSTRUCT_SIZE])
+		}
+
+		offset++
+	}
+}
+
+// ReadFile reads the SIGSTRUCT from a signed enclave binary
+func ReadFile(path string) (*SigStruct, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return FromELF(file)
+}
@@ -0,0 +1,161 @@
+package sigstruct
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// creates a SIGSTRUCT signed with key
+func newTestSigStruct(t *testing.T, key *rsa.PrivateKey, uniqueId [32]byte, productId, securityVersion uint16, debug bool) []byte {
+	buf := make([]byte, SIGSTRUCT_SIZE)
+	copy(buf[headerOffset:], sigstructHeader)
+	copy(buf[header2Offset:], sigstructHeader2)
+
+	modulus := reversed(key.N.FillBytes(make([]byte, modulusSize)))
+	copy(buf[modulusOffset:], modulus)
+	binary.LittleEndian.PutUint32(buf[exponentOffset:], uint32(key.E))
+
+	if debug {
+		buf[attributesOffset] |= attributesDebugFlag
+	}
+	copy(buf[enclaveHashOffset:], uniqueId[:])
+	binary.LittleEndian.PutUint16(buf[isvProdIdOffset:], productId)
+	binary.LittleEndian.PutUint16(buf[isvSvnOffset:], securityVersion)
+
+	signedBody := sha256.New()
+	signedBody.Write(buf[:modulusOffset])
+	signedBody.Write(buf[miscSelectOffset:signedBodyEnd])
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, signedBody.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(buf[signatureOffset:], reversed(signature))
+
+	return buf
+}
+
+// creates a minimal ELF file with the section
+func newTestELF(sectionName string, sectionData []byte) []byte {
+	shstrtab := append([]byte("\x00.shstrtab\x00"+sectionName), 0)
+
+	const headerSize = 64
+	dataOffset := uint64(headerSize)
+	shstrtabOffset := dataOffset + uint64(len(sectionData))
+	sectionHeadersOffset := shstrtabOffset + uint64(len(shstrtab))
+
+	buf := new(bytes.Buffer)
+
+	header := elf.Header64{
+		Type:      uint16(elf.ET_DYN),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     sectionHeadersOffset,
+		Ehsize:    headerSize,
+		Phentsize: 56,
+		Shentsize: 64,
+		Shnum:     3,
+		Shstrndx:  2,
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	binary.Write(buf, binary.LittleEndian, header)
+	buf.Write(sectionData)
+	buf.Write(shstrtab)
+
+	sections := []elf.Section64{
+		{},
+		{Name: 11, Type: uint32(elf.SHT_PROGBITS), Off: dataOffset, Size: uint64(len(sectionData)), Addralign: 1},
+		{Name: 1, Type: uint32(elf.SHT_STRTAB), Off: shstrtabOffset, Size: uint64(len(shstrtab)), Addralign: 1},
+	}
+	for _, section := range sections {
+		binary.Write(buf, binary.LittleEndian, section)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFromELF(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, modulusSize*8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uniqueId := sha256.Sum256([]byte("enclave"))
+	signerId := sha256.Sum256(reversed(key.N.FillBytes(make([]byte, modulusSize))))
+
+	sigstruct := newTestSigStruct(t, key, uniqueId, 1, 2, false)
+
+	tampered := bytes.Clone(sigstruct)
+	tampered[enclaveHashOffset] ^= 0xff
+
+	// the enclave properties around the SIGSTRUCT
+	properties := func(sigstruct []byte) []byte {
+		return append(append(bytes.Repeat([]byte{0x01}, 136), sigstruct...), make([]byte, 8)...)
+	}
+
+	tests := []struct {
+		name    string
+		binary  []byte
+		want    *SigStruct
+		wantErr error
+	}{
+		{
+			name:   "signed",
+			binary: newTestELF(ENCLAVE_PROPERTIES_SECTION, properties(sigstruct)),
+			want: &SigStruct{
+				UniqueId:        uniqueId,
+				SignerId:        signerId,
+				ProductId:       1,
+				SecurityVersion: 2,
+			},
+		},
+		{
+			name:   "debug",
+			binary: newTestELF(ENCLAVE_PROPERTIES_SECTION, properties(newTestSigStruct(t, key, uniqueId, 1, 2, true))),
+			want: &SigStruct{
+				UniqueId:        uniqueId,
+				SignerId:        signerId,
+				ProductId:       1,
+				SecurityVersion: 2,
+				Debug:           true,
+			},
+		},
+		{
+			name:    "tampered",
+			binary:  newTestELF(ENCLAVE_PROPERTIES_SECTION, properties(tampered)),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "not signed",
+			binary:  newTestELF(ENCLAVE_PROPERTIES_SECTION, properties(make([]byte, SIGSTRUCT_SIZE))),
+			wantErr: ErrNotSigned,
+		},
+		{
+			name:    "not an enclave",
+			binary:  newTestELF(".data", properties(sigstruct)),
+			wantErr: ErrNoEnclaveProperties,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromELF(bytes.NewReader(tt.binary))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FromELF() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && *got != *tt.want {
+				t.Errorf("FromELF() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package sgx
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgelesssys/ego/attestation"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/collateral"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/nonce"
+)
+
+const (
+	// the report type of SGX reports
+	TEE_TYPE = "sgx"
+
+	// the measurements of SGX reports
+	MEASUREMENT_UNIQUE_ID = "uniqueId"
+	MEASUREMENT_SIGNER_ID = "signerId"
+
+	// the size of the unique ID, MRENCLAVE
+	UNIQUE_ID_SIZE = 32
+
+	// SGX reports don't have a separate nonce, the nonce follows the Poseidon8 hash in the report data
+	REPORT_DATA_NONCE_OFFSET = 16
+)
+
+// MeasurementInfo is an SGX measurement in the encodings of /info
+type MeasurementInfo struct {
+	Hex    string `json:"hexEncoded"`
+	Base64 string `json:"base64Encoded"`
+	Aleo   string `json:"aleoEncoded"`
+}
+
+// PolicyConfig is the "sgx" policy block of a profile
+type PolicyConfig struct {
+	// hex- or base64-encoded
+	UniqueIdTarget string `json:"uniqueIdTarget"`
+}
+
+// Verifier verifies SGX reports, the policy has the target unique ID
+type Verifier struct{}
+
+func init() {
+	tee.Register(Verifier{})
+}
+
+func (Verifier) Type() string {
+	return TEE_TYPE
+}
+
+// Policy parses a PolicyConfig
+func (Verifier) Policy(block json.RawMessage) (tee.Policy, error) {
+	var policyConfig PolicyConfig
+	if err := tee.DecodePolicy(block, &policyConfig); err != nil {
+		return tee.Policy{}, err
+	}
+
+	uniqueId := policyConfig.UniqueIdTarget
+	if uniqueId != "" {
+		var ok bool
+		if uniqueId, ok = tee.NormalizeMeasurement(uniqueId, UNIQUE_ID_SIZE); !ok {
+			return tee.Policy{}, fmt.Errorf("\"uniqueIdTarget\" must be %d bytes hex- or base64-encoded", UNIQUE_ID_SIZE)
+		}
+	}
+
+	return tee.Policy{
+		Measurements: map[string]string{
+			MEASUREMENT_UNIQUE_ID: uniqueId,
+		},
+	}, nil
+}
+
+// Verify verifies an SGX report. The nonce is read from the report data, the claimed nonce isn't used.
+func (Verifier) Verify(reportBytes []byte, _ string, policy tee.Policy) (*tee.Result, error) {
+	report, err := VerifySgxReport(reportBytes, policy.Measurements[MEASUREMENT_UNIQUE_ID])
+	if err != nil {
+		return nil, err
+	}
+
+	return newResult(report), nil
+}
+
+// VerifyWithCollateral verifies an SGX report against a collateral.Collateral, in the format of the files written by
+// export-collateral
+func (Verifier) VerifyWithCollateral(reportBytes []byte, _ string, policy tee.Policy, block json.RawMessage) (*tee.Result, error) {
+	var bundle collateral.Collateral
+	if err := json.Unmarshal(block, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %w", collateral.ErrMalformedCollateral, err)
+	}
+
+	report, err := VerifySgxReportWithCollateral(reportBytes, &bundle, policy.Measurements[MEASUREMENT_UNIQUE_ID])
+	if err != nil {
+		return nil, err
+	}
+
+	return newResult(report), nil
+}
+
+func newResult(report *attestation.Report) *tee.Result {
+	result := &tee.Result{
+		Type: TEE_TYPE,
+		Measurements: map[string]string{
+			MEASUREMENT_UNIQUE_ID: hex.EncodeToString(report.UniqueID),
+			MEASUREMENT_SIGNER_ID: hex.EncodeToString(report.SignerID),
+		},
+		UserData: report.Data,
+		Report:   report,
+	}
+
+	if len(report.Data) >= REPORT_DATA_NONCE_OFFSET+nonce.NONCE_SIZE {
+		result.Nonce = report.Data[REPORT_DATA_NONCE_OFFSET : REPORT_DATA_NONCE_OFFSET+nonce.NONCE_SIZE]
+	}
+
+	return result
+}
+
+// DescribePolicy describes the target unique ID as a MeasurementInfo
+func (Verifier) DescribePolicy(policy tee.Policy) (any, error) {
+	uniqueIdHex := policy.Measurements[MEASUREMENT_UNIQUE_ID]
+
+	uniqueIdBytes, err := hex.DecodeString(uniqueIdHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hex-decode unique ID: %w", err)
+	}
+	if len(uniqueIdBytes) < 32 {
+		return nil, fmt.Errorf("unique ID is shorter than 32 bytes")
+	}
+
+	var uniqueId [32]byte
+	copy(uniqueId[:], uniqueIdBytes)
+
+	return MeasurementInfo{
+		Hex:    uniqueIdHex,
+		Base64: base64.StdEncoding.EncodeToString(uniqueIdBytes),
+		Aleo:   FormatMeasurement(uniqueId),
+	}, nil
+}
@@ -0,0 +1,220 @@
+package snp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// the files in the cache directory with the ASK and the ARK of a product line, as served by the AMD KDS
+const CERT_CHAIN_FILE_PATTERN = "cert_chain*.pem"
+
+// the VCEK extensions, see the AMD KDS specification
+var (
+	oidBlSpl    = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 3, 1}
+	oidTeeSpl   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 3, 2}
+	oidSnpSpl   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 3, 3}
+	oidUcodeSpl = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 3, 8}
+	oidHwId     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 3704, 1, 4}
+)
+
+// Fingerprint is the SHA-256 fingerprint of a certificate, hex-encoded
+func Fingerprint(certificate *x509.Certificate) string {
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return hex.EncodeToString(fingerprint[:])
+}
+
+// parses certificates, either PEM or a single DER certificate
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		certificate, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedCertificates, err)
+		}
+
+		return []*x509.Certificate{certificate}, nil
+	}
+
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedCertificates, err)
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	return certificates, nil
+}
+
+// Cache is a directory with the certificates of the reporting platforms, i.e. the VCEK of every chip and TCB version,
+// and the ASK and the ARK of every product line
+type Cache struct {
+	dir string
+}
+
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// VcekPath is the path of the VCEK of a chip at a TCB version, the VCEK is PEM or DER
+func (c *Cache) VcekPath(chipId [64]byte, tcb TcbVersion) string {
+	return filepath.Join(c.dir, fmt.Sprintf("vcek_%x_%016x.der", chipId, uint64(tcb)))
+}
+
+// Complete adds the certificates of a report that are missing from the supplied ones
+func (c *Cache) Complete(report *Report, certificates *Certificates) error {
+	if certificates.Vcek == nil {
+		content, err := os.ReadFile(c.VcekPath(report.ChipId, report.ReportedTcb))
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrMissingCertificates
+		}
+		if err != nil {
+			return err
+		}
+
+		vcek, err := parseCertificates(content)
+		if err != nil {
+			return err
+		}
+		if len(vcek) != 1 {
+			return fmt.Errorf("%w: expected one VCEK, got %d certificates", ErrMalformedCertificates, len(vcek))
+		}
+
+		certificates.Vcek = vcek[0]
+	}
+
+	if certificates.Ask != nil && certificates.Ark != nil {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(c.dir, CERT_CHAIN_FILE_PATTERN))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		chain, err := parseCertificates(content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if len(chain) != 2 {
+			return fmt.Errorf("%w: %s: expected the ASK and the ARK, got %d certificates", ErrMalformedCertificates, filepath.Base(path), len(chain))
+		}
+
+		// the chain of another product line
+		ask := certificates.Ask
+		if ask == nil {
+			ask = chain[0]
+		}
+		if certificates.Vcek.CheckSignatureFrom(ask) != nil || ask.CheckSignatureFrom(chain[1]) != nil {
+			continue
+		}
+
+		certificates.Ask = ask
+		if certificates.Ark == nil {
+			certificates.Ark = chain[1]
+		}
+
+		return nil
+	}
+
+	return ErrMissingCertificates
+}
+
+// verifies the VCEK certificate chain up to the ARK, the ARK must be trusted by the caller
+func verifyChain(certificates *Certificates, now time.Time) error {
+	roots := x509.NewCertPool()
+	roots.AddCert(certificates.Ark)
+
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(certificates.Ask)
+
+	_, err := certificates.Vcek.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCertificateChain, err)
+	}
+
+	return nil
+}
+
+// checks that the VCEK is the one of the reporting chip at the reported TCB version
+func checkVcek(vcek *x509.Certificate, report *Report) error {
+	spls := map[string]uint8{
+		oidBlSpl.String():    report.ReportedTcb.BootLoader(),
+		oidTeeSpl.String():   report.ReportedTcb.Tee(),
+		oidSnpSpl.String():   report.ReportedTcb.Snp(),
+		oidUcodeSpl.String(): report.ReportedTcb.Microcode(),
+	}
+
+	for _, extension := range vcek.Extensions {
+		id := extension.Id.String()
+
+		if want, ok := spls[id]; ok {
+			var spl int
+			if _, err := asn1.Unmarshal(extension.Value, &spl); err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrMalformedCertificates, id, err)
+			}
+			if spl != int(want) {
+				return fmt.Errorf("%w: SPL %s is %d, the report has %d", ErrVcekMismatch, id, spl, want)
+			}
+
+			delete(spls, id)
+			continue
+		}
+
+		// the chip ID is zero if the report masks it
+		if extension.Id.Equal(oidHwId) && report.Flags&FLAG_MASK_CHIP_KEY == 0 {
+			var hwId []byte
+			if _, err := asn1.Unmarshal(extension.Value, &hwId); err != nil {
+				// some VCEKs have the raw hardware ID
+				hwId = extension.Value
+			}
+			if !bytes.Equal(hwId, report.ChipId[:]) {
+				return fmt.Errorf("%w: the hardware ID isn't the chip ID", ErrVcekMismatch)
+			}
+		}
+	}
+
+	for id := range spls {
+		return fmt.Errorf("%w: the VCEK has no SPL %s", ErrMalformedCertificates, id)
+	}
+
+	publicKey, ok := vcek.PublicKey.(*ecdsa.PublicKey)
+	if !ok || publicKey.Curve != elliptic.P384() {
+		return fmt.Errorf("%w: the VCEK key isn't an ECDSA P-384 key", ErrMalformedCertificates)
+	}
+
+	return nil
+}
@@ -0,0 +1,276 @@
+package snp
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+const (
+	REPORT_SIZE = 0x4A0
+	// the part of the report that's signed with the VCEK
+	SIGNED_SIZE = 0x2A0
+
+	// the oldest supported report version
+	MIN_REPORT_VERSION = 2
+
+	// ECDSA P-384 with SHA-384
+	SIGNATURE_ALGO_ECDSA_P384_SHA384 = 1
+
+	// the guest policy bits
+	POLICY_SMT           = 1 << 16
+	POLICY_MIGRATE_MA    = 1 << 18
+	POLICY_DEBUG         = 1 << 19
+	POLICY_SINGLE_SOCKET = 1 << 20
+
+	// the report flags, the signing key is in bits 2-4
+	FLAG_AUTHOR_KEY_EN  = 1 << 0
+	FLAG_MASK_CHIP_KEY  = 1 << 1
+	flagSigningKeyShift = 2
+	flagSigningKeyMask  = 0x7
+
+	// the signing keys
+	SIGNING_KEY_VCEK = 0
+	SIGNING_KEY_VLEK = 1
+	SIGNING_KEY_NONE = 7
+
+	// the size of r and s in the signature
+	signatureComponentLen = 72
+)
+
+// the GUIDs of the certificate table entries of extended reports, in the byte order of the table
+var (
+	guidArk  = guidBytes(0xc0b406a4, 0xa803, 0x4952, [8]byte{0x97, 0x43, 0x3f, 0xb6, 0x01, 0x4c, 0xd0, 0xae})
+	guidAsk  = guidBytes(0x4ab7b379, 0xbbac, 0x4fe4, [8]byte{0xa0, 0x2f, 0x05, 0xae, 0xf3, 0x27, 0xc7, 0x82})
+	guidVcek = guidBytes(0x63da758d, 0xe664, 0x4564, [8]byte{0xad, 0xc5, 0xf4, 0xb9, 0x3b, 0xe8, 0xac, 0xcd})
+)
+
+func guidBytes(a uint32, b uint16, c uint16, d [8]byte) [16]byte {
+	var guid [16]byte
+	binary.LittleEndian.PutUint32(guid[0:], a)
+	binary.LittleEndian.PutUint16(guid[4:], b)
+	binary.LittleEndian.PutUint16(guid[6:], c)
+	copy(guid[8:], d[:])
+
+	return guid
+}
+
+// TcbVersion is a TCB version in the Milan and Genoa layout
+type TcbVersion uint64
+
+func (t TcbVersion) BootLoader() uint8 {
+	return uint8(t)
+}
+
+func (t TcbVersion) Tee() uint8 {
+	return uint8(t >> 8)
+}
+
+func (t TcbVersion) Snp() uint8 {
+	return uint8(t >> 48)
+}
+
+func (t TcbVersion) Microcode() uint8 {
+	return uint8(t >> 56)
+}
+
+// Signature is the report signature, r and s little-endian and zero-extended
+type Signature struct {
+	R [signatureComponentLen]byte
+	S [signatureComponentLen]byte
+	_ [368]byte
+}
+
+// Report is an SNP attestation report, see the SEV-SNP firmware ABI specification
+type Report struct {
+	Version         uint32
+	GuestSvn        uint32
+	Policy          uint64
+	FamilyId        [16]byte
+	ImageId         [16]byte
+	Vmpl            uint32
+	SignatureAlgo   uint32
+	CurrentTcb      TcbVersion
+	PlatformInfo    uint64
+	Flags           uint32
+	_               uint32
+	ReportData      [64]byte
+	Measurement     [48]byte
+	HostData        [32]byte
+	IdKeyDigest     [48]byte
+	AuthorKeyDigest [48]byte
+	ReportId        [32]byte
+	ReportIdMa      [32]byte
+	ReportedTcb     TcbVersion
+	_               [24]byte
+	ChipId          [64]byte
+	CommittedTcb    TcbVersion
+	CurrentBuild    uint8
+	CurrentMinor    uint8
+	CurrentMajor    uint8
+	_               uint8
+	CommittedBuild  uint8
+	CommittedMinor  uint8
+	CommittedMajor  uint8
+	_               uint8
+	LaunchTcb       TcbVersion
+	_               [168]byte
+	Signature       Signature
+}
+
+// Debug reports whether the guest policy allows debugging
+func (r *Report) Debug() bool {
+	return r.Policy&POLICY_DEBUG != 0
+}
+
+// SigningKey is the key the report is signed with, SIGNING_KEY_VCEK, SIGNING_KEY_VLEK or SIGNING_KEY_NONE
+func (r *Report) SigningKey() uint32 {
+	return (r.Flags >> flagSigningKeyShift) & flagSigningKeyMask
+}
+
+func littleEndianInt(buf []byte) *big.Int {
+	reversed := make([]byte, len(buf))
+	for idx, b := range buf {
+		reversed[len(buf)-1-idx] = b
+	}
+
+	return new(big.Int).SetBytes(reversed)
+}
+
+// SignatureValues returns r and s of the report signature
+func (r *Report) SignatureValues() (*big.Int, *big.Int) {
+	return littleEndianInt(r.Signature.R[:]), littleEndianInt(r.Signature.S[:])
+}
+
+// Certificates are the certificates supplied with a report, any of them can be missing
+type Certificates struct {
+	Vcek *x509.Certificate
+	Ask  *x509.Certificate
+	Ark  *x509.Certificate
+}
+
+// ParseReport parses an SNP attestation report without verifying it, the report can be followed by its certificates
+func ParseReport(reportBytes []byte) (*Report, error) {
+	if len(reportBytes) < REPORT_SIZE {
+		return nil, ErrTooShort
+	}
+
+	report := new(Report)
+	if err := binary.Read(bytes.NewReader(reportBytes[:REPORT_SIZE]), binary.LittleEndian, report); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if report.Version < MIN_REPORT_VERSION {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, report.Version)
+	}
+
+	return report, nil
+}
+
+// ParseCertificates parses the certificates following a report, either the certificate table of an extended report,
+// or PEM in the order VCEK, ASK, ARK. The certificates are empty if the report isn't followed by any.
+func ParseCertificates(reportBytes []byte) (*Certificates, error) {
+	if len(reportBytes) < REPORT_SIZE {
+		return nil, ErrTooShort
+	}
+
+	rest := reportBytes[REPORT_SIZE:]
+	switch {
+	case len(bytes.TrimRight(rest, "\x00")) == 0:
+		return new(Certificates), nil
+	case bytes.HasPrefix(bytes.TrimSpace(rest), []byte("-----BEGIN")):
+		return parsePemCertificates(rest)
+	default:
+		return parseCertificateTable(rest)
+	}
+}
+
+// parses the certificates in the order VCEK, ASK, ARK
+func parsePemCertificates(data []byte) (*Certificates, error) {
+	var chain []*x509.Certificate
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedCertificates, err)
+		}
+
+		chain = append(chain, certificate)
+	}
+
+	if len(chain) == 0 || len(chain) > 3 {
+		return nil, fmt.Errorf("%w: expected 1 to 3 certificates, got %d", ErrMalformedCertificates, len(chain))
+	}
+
+	certificates := &Certificates{Vcek: chain[0]}
+	if len(chain) > 1 {
+		certificates.Ask = chain[1]
+	}
+	if len(chain) > 2 {
+		certificates.Ark = chain[2]
+	}
+
+	return certificates, nil
+}
+
+// parses the certificate table of an extended report, the entries are the GUID, the offset and the length of the
+// certificate, followed by an all-zero entry. The offsets are from the start of the table.
+func parseCertificateTable(table []byte) (*Certificates, error) {
+	const entrySize = 24
+
+	certificates := new(Certificates)
+
+	for offset := 0; ; offset += entrySize {
+		if offset+entrySize > len(table) {
+			return nil, fmt.Errorf("%w: the certificate table has no terminator", ErrMalformedCertificates)
+		}
+
+		var guid [16]byte
+		copy(guid[:], table[offset:])
+		certOffset := binary.LittleEndian.Uint32(table[offset+16:])
+		certLength := binary.LittleEndian.Uint32(table[offset+20:])
+
+		if guid == ([16]byte{}) && certOffset == 0 && certLength == 0 {
+			break
+		}
+
+		if uint64(certOffset)+uint64(certLength) > uint64(len(table)) {
+			return nil, fmt.Errorf("%w: certificate table entry %d is out of bounds", ErrMalformedCertificates, offset/entrySize)
+		}
+
+		var target **x509.Certificate
+		switch guid {
+		case guidVcek:
+			target = &certificates.Vcek
+		case guidAsk:
+			target = &certificates.Ask
+		case guidArk:
+			target = &certificates.Ark
+		default:
+			// e.g. the VLEK or vendor-specific certificates
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(table[certOffset : certOffset+certLength])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedCertificates, err)
+		}
+
+		*target = certificate
+	}
+
+	return certificates, nil
+}
@@ -0,0 +1,132 @@
+// Package snp verifies AMD SEV-SNP attestation reports against the VCEK certificate chain of the reporting chip.
+package snp
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTooShort              = errors.New("snp: the report is too short")
+	ErrUnsupportedVersion    = errors.New("snp: unsupported report version")
+	ErrMalformed             = errors.New("snp: malformed report")
+	ErrMalformedCertificates = errors.New("snp: malformed report certificates")
+	ErrUnsupportedSignature  = errors.New("snp: unsupported report signature algorithm or signing key")
+	ErrMissingCertificates   = errors.New("snp: the report certificates are neither supplied nor cached")
+	ErrCertificateChain      = errors.New("snp: invalid VCEK certificate chain")
+	ErrUntrustedArk          = errors.New("snp: the ARK isn't a trusted ARK")
+	ErrVcekMismatch          = errors.New("snp: the VCEK isn't the one of the reporting chip and TCB")
+	ErrInvalidSignature      = errors.New("snp: invalid report signature")
+)
+
+// the cache of the certificates that don't follow the reports
+var certificateCache *Cache
+
+// the SHA-256 fingerprints of the trusted ARKs, no report is trusted if it's empty
+var arkFingerprints = make(map[string]bool)
+
+// Init sets up the certificate cache directory and the trusted ARKs, e.g. the ones of Milan and Genoa
+func Init(certCacheDir string, trustedArkFingerprints []string) {
+	if certCacheDir != "" {
+		certificateCache = NewCache(certCacheDir)
+	}
+
+	arkFingerprints = make(map[string]bool)
+	for _, fingerprint := range trustedArkFingerprints {
+		arkFingerprints[strings.ToLower(fingerprint)] = true
+	}
+}
+
+// Verify verifies the signature of a report with its VCEK and the VCEK certificate chain up to a trusted ARK. The
+// certificates that don't follow the report are read from the cache.
+func Verify(reportBytes []byte, now time.Time) (*Report, error) {
+	return verify(reportBytes, certificateCache, arkFingerprints, now)
+}
+
+func verify(reportBytes []byte, cache *Cache, trustedArks map[string]bool, now time.Time) (*Report, error) {
+	report, err := ParseReport(reportBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.SignatureAlgo != SIGNATURE_ALGO_ECDSA_P384_SHA384 || report.SigningKey() != SIGNING_KEY_VCEK {
+		return nil, fmt.Errorf("%w: algorithm %d, key %d", ErrUnsupportedSignature, report.SignatureAlgo, report.SigningKey())
+	}
+
+	certificates, err := ParseCertificates(reportBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if certificates.Vcek == nil || certificates.Ask == nil || certificates.Ark == nil {
+		if cache == nil {
+			return nil, ErrMissingCertificates
+		}
+		if err := cache.Complete(report, certificates); err != nil {
+			return nil, err
+		}
+	}
+
+	if fingerprint := Fingerprint(certificates.Ark); !trustedArks[fingerprint] {
+		return nil, fmt.Errorf("%w: %s", ErrUntrustedArk, fingerprint)
+	}
+
+	if err := verifyChain(certificates, now); err != nil {
+		return nil, err
+	}
+
+	if err := checkVcek(certificates.Vcek, report); err != nil {
+		return nil, err
+	}
+
+	hash := sha512.Sum384(reportBytes[:SIGNED_SIZE])
+	r, s := report.SignatureValues()
+	if !ecdsa.Verify(certificates.Vcek.PublicKey.(*ecdsa.PublicKey), hash[:], r, s) {
+		return nil, ErrInvalidSignature
+	}
+
+	return report, nil
+}
+
+// VerifySnpReport verifies a report and checks it against the target launch measurement and, if it's set, the target
+// guest policy. Reports with a guest policy that allows debugging are rejected.
+func VerifySnpReport(reportBytes []byte, targetMeasurement string, targetPolicy string) (*Report, error) {
+	report, err := Verify(reportBytes, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Debug() {
+		log.Printf("SNP report guest policy allows debugging")
+		return nil, errors.New("report guest policy allows debugging")
+	}
+
+	if targetMeasurement == "" {
+		return nil, errors.New("no target SNP measurement is configured")
+	}
+
+	measurement := hex.EncodeToString(report.Measurement[:])
+	if measurement != targetMeasurement {
+		log.Printf("reporting guest measurement doesn't match the expected one, expected=%s, got=%s", targetMeasurement, measurement)
+		return nil, errors.New("report measurement doesn't match target")
+	}
+
+	policy := FormatPolicy(report.Policy)
+	if targetPolicy != "" && policy != targetPolicy {
+		log.Printf("reporting guest policy doesn't match the expected one, expected=%s, got=%s", targetPolicy, policy)
+		return nil, errors.New("report guest policy doesn't match target")
+	}
+
+	return report, nil
+}
+
+// FormatPolicy formats a guest policy the way the target policy is configured, as 16 hex digits
+func FormatPolicy(policy uint64) string {
+	return fmt.Sprintf("%016x", policy)
+}
@@ -0,0 +1,386 @@
+package snp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the reported TCB of the test reports, boot loader 3, TEE 0, SNP 14, microcode 209
+const testTcb = TcbVersion(3 | 0<<8 | 14<<48 | 209<<56)
+
+var testChipId = [64]byte{1, 2, 3, 4}
+
+type testPki struct {
+	arkKey  *rsa.PrivateKey
+	askKey  *rsa.PrivateKey
+	vcekKey *ecdsa.PrivateKey
+
+	ark  *x509.Certificate
+	ask  *x509.Certificate
+	vcek *x509.Certificate
+}
+
+func newCertificate(t *testing.T, template *x509.Certificate, publicKey any, issuer *x509.Certificate, issuerKey crypto.Signer) *x509.Certificate {
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().AddDate(10, 0, 0)
+	// same as the AMD certificates
+	template.SignatureAlgorithm = x509.SHA384WithRSAPSS
+
+	if issuer == nil {
+		issuer = template
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, publicKey, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return certificate
+}
+
+// creates the extensions of a VCEK for the chip ID at the TCB version
+func newVcekExtensions(t *testing.T, chipId [64]byte, tcb TcbVersion) []pkix.Extension {
+	marshal := func(value any) []byte {
+		encoded, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded
+	}
+
+	return []pkix.Extension{
+		{Id: oidBlSpl, Value: marshal(int(tcb.BootLoader()))},
+		{Id: oidTeeSpl, Value: marshal(int(tcb.Tee()))},
+		{Id: oidSnpSpl, Value: marshal(int(tcb.Snp()))},
+		{Id: oidUcodeSpl, Value: marshal(int(tcb.Microcode()))},
+		{Id: oidHwId, Value: marshal(chipId[:])},
+	}
+}
+
+func newTestPki(t *testing.T, chipId [64]byte, tcb TcbVersion) *testPki {
+	var err error
+
+	p := new(testPki)
+	if p.arkKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	if p.askKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	if p.vcekKey, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+
+	p.ark = newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test ARK"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, &p.arkKey.PublicKey, nil, p.arkKey)
+
+	p.ask = newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test ASK"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, &p.askKey.PublicKey, p.ark, p.arkKey)
+
+	p.vcek = newCertificate(t, &x509.Certificate{
+		Subject:         pkix.Name{CommonName: "Test VCEK"},
+		ExtraExtensions: newVcekExtensions(t, chipId, tcb),
+	}, &p.vcekKey.PublicKey, p.ask, p.askKey)
+
+	return p
+}
+
+func testReport() *Report {
+	report := &Report{
+		Version:       3,
+		Policy:        0x30000,
+		SignatureAlgo: SIGNATURE_ALGO_ECDSA_P384_SHA384,
+		ReportedTcb:   testTcb,
+		ChipId:        testChipId,
+	}
+	copy(report.Measurement[:], bytes.Repeat([]byte{0xab}, 48))
+	copy(report.ReportData[:], "user data hash..")
+
+	return report
+}
+
+// encodes and signs a report with the VCEK key
+func (p *testPki) sign(t *testing.T, report *Report) []byte {
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, report); err != nil {
+		t.Fatal(err)
+	}
+	reportBytes := buf.Bytes()
+
+	hash := sha512.Sum384(reportBytes[:SIGNED_SIZE])
+	r, s, err := ecdsa.Sign(rand.Reader, p.vcekKey, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// little-endian
+	for idx, b := range r.FillBytes(make([]byte, 48)) {
+		reportBytes[SIGNED_SIZE+47-idx] = b
+	}
+	for idx, b := range s.FillBytes(make([]byte, 48)) {
+		reportBytes[SIGNED_SIZE+signatureComponentLen+47-idx] = b
+	}
+
+	return reportBytes
+}
+
+// creates the certificate table of an extended report
+func certificateTable(certificates map[[16]byte]*x509.Certificate) []byte {
+	const entrySize = 24
+
+	guids := [][16]byte{guidArk, guidAsk, guidVcek}
+
+	offset := uint32((len(certificates) + 1) * entrySize)
+	entries := new(bytes.Buffer)
+	data := new(bytes.Buffer)
+	for _, guid := range guids {
+		certificate, ok := certificates[guid]
+		if !ok {
+			continue
+		}
+
+		entries.Write(guid[:])
+		binary.Write(entries, binary.LittleEndian, offset)
+		binary.Write(entries, binary.LittleEndian, uint32(len(certificate.Raw)))
+		data.Write(certificate.Raw)
+		offset += uint32(len(certificate.Raw))
+	}
+	entries.Write(make([]byte, entrySize))
+
+	return append(entries.Bytes(), data.Bytes()...)
+}
+
+func encodeCertificates(certificates ...*x509.Certificate) []byte {
+	buf := new(bytes.Buffer)
+	for _, certificate := range certificates {
+		pem.Encode(buf, &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	}
+
+	return buf.Bytes()
+}
+
+func TestParseCertificates(t *testing.T) {
+	pki := newTestPki(t, testChipId, testTcb)
+	reportBytes := pki.sign(t, testReport())
+
+	tests := []struct {
+		name     string
+		suffix   []byte
+		wantVcek bool
+		wantAsk  bool
+		wantArk  bool
+		wantErr  error
+	}{
+		{name: "no certificates", suffix: nil},
+		{name: "zero padding", suffix: make([]byte, 64)},
+		{name: "certificate table", suffix: certificateTable(map[[16]byte]*x509.Certificate{guidVcek: pki.vcek, guidAsk: pki.ask, guidArk: pki.ark}), wantVcek: true, wantAsk: true, wantArk: true},
+		{name: "VCEK in the certificate table", suffix: certificateTable(map[[16]byte]*x509.Certificate{guidVcek: pki.vcek}), wantVcek: true},
+		{name: "PEM", suffix: encodeCertificates(pki.vcek, pki.ask, pki.ark), wantVcek: true, wantAsk: true, wantArk: true},
+		{name: "unterminated certificate table", suffix: certificateTable(map[[16]byte]*x509.Certificate{guidVcek: pki.vcek})[:24], wantErr: ErrMalformedCertificates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certificates, err := ParseCertificates(append(append([]byte{}, reportBytes...), tt.suffix...))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCertificates() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if (certificates.Vcek != nil) != tt.wantVcek || (certificates.Ask != nil) != tt.wantAsk || (certificates.Ark != nil) != tt.wantArk {
+				t.Errorf("ParseCertificates() = %+v", certificates)
+			}
+			if tt.wantVcek && !certificates.Vcek.Equal(pki.vcek) {
+				t.Error("ParseCertificates() VCEK isn't the VCEK")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	pki := newTestPki(t, testChipId, testTcb)
+	otherPki := newTestPki(t, testChipId, testTcb)
+	staleVcekPki := newTestPki(t, testChipId, testTcb-1)
+
+	trusted := map[string]bool{Fingerprint(pki.ark): true, Fingerprint(staleVcekPki.ark): true}
+
+	cacheDir := t.TempDir()
+	cache := NewCache(cacheDir)
+	if err := os.WriteFile(cache.VcekPath(testChipId, testTcb), pki.vcek.Raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// the chain of another product line, then the chain of the VCEK
+	if err := os.WriteFile(filepath.Join(cacheDir, "cert_chain_other.pem"), encodeCertificates(otherPki.ask, otherPki.ark), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, "cert_chain.pem"), encodeCertificates(pki.ask, pki.ark), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fullTable := certificateTable(map[[16]byte]*x509.Certificate{guidVcek: pki.vcek, guidAsk: pki.ask, guidArk: pki.ark})
+
+	tampered := pki.sign(t, testReport())
+	tampered[0x90] ^= 1
+
+	vlekReport := testReport()
+	vlekReport.Flags = SIGNING_KEY_VLEK << flagSigningKeyShift
+
+	otherChipReport := testReport()
+	otherChipReport.ChipId = [64]byte{5, 6, 7, 8}
+
+	maskedChipReport := testReport()
+	maskedChipReport.ChipId = [64]byte{}
+	maskedChipReport.Flags = FLAG_MASK_CHIP_KEY
+
+	tests := []struct {
+		name        string
+		reportBytes []byte
+		cache       *Cache
+		wantErr     error
+	}{
+		{name: "certificate table", reportBytes: append(pki.sign(t, testReport()), fullTable...)},
+		{name: "PEM certificates", reportBytes: append(pki.sign(t, testReport()), encodeCertificates(pki.vcek, pki.ask, pki.ark)...)},
+		{name: "cached certificates", reportBytes: pki.sign(t, testReport()), cache: cache},
+		{name: "VCEK in the report, chain in the cache", reportBytes: append(pki.sign(t, testReport()), encodeCertificates(pki.vcek)...), cache: cache},
+		{name: "masked chip ID", reportBytes: append(pki.sign(t, maskedChipReport), fullTable...)},
+		{name: "no certificates", reportBytes: pki.sign(t, testReport()), wantErr: ErrMissingCertificates},
+		{name: "not cached", reportBytes: otherPki.sign(t, otherChipReport), cache: cache, wantErr: ErrMissingCertificates},
+		{name: "untrusted ARK", reportBytes: append(otherPki.sign(t, testReport()), encodeCertificates(otherPki.vcek, otherPki.ask, otherPki.ark)...), wantErr: ErrUntrustedArk},
+		{name: "VCEK of another chain", reportBytes: append(otherPki.sign(t, testReport()), encodeCertificates(otherPki.vcek, pki.ask, pki.ark)...), wantErr: ErrCertificateChain},
+		{name: "VCEK of another TCB", reportBytes: append(staleVcekPki.sign(t, testReport()), encodeCertificates(staleVcekPki.vcek, staleVcekPki.ask, staleVcekPki.ark)...), wantErr: ErrVcekMismatch},
+		{name: "VCEK of another chip", reportBytes: append(pki.sign(t, otherChipReport), fullTable...), wantErr: ErrVcekMismatch},
+		{name: "tampered report", reportBytes: append(tampered, fullTable...), wantErr: ErrInvalidSignature},
+		{name: "signed by another VCEK", reportBytes: append(otherPki.sign(t, testReport()), fullTable...), wantErr: ErrInvalidSignature},
+		{name: "VLEK", reportBytes: append(pki.sign(t, vlekReport), fullTable...), wantErr: ErrUnsupportedSignature},
+		{name: "too short", reportBytes: pki.sign(t, testReport())[:REPORT_SIZE-1], wantErr: ErrTooShort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := verify(tt.reportBytes, tt.cache, trusted, time.Now())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && report.Measurement != testReport().Measurement {
+				t.Errorf("verify() measurement = %x", report.Measurement)
+			}
+		})
+	}
+}
+
+func TestVerifySnpReport(t *testing.T) {
+	pki := newTestPki(t, testChipId, testTcb)
+	Init("", []string{Fingerprint(pki.ark)})
+	defer Init("", nil)
+
+	certificates := encodeCertificates(pki.vcek, pki.ask, pki.ark)
+
+	debugReport := testReport()
+	debugReport.Policy |= POLICY_DEBUG
+
+	measurement := hex.EncodeToString(testReport().Measurement[:])
+
+	tests := []struct {
+		name              string
+		report            *Report
+		targetMeasurement string
+		targetPolicy      string
+		wantErr           bool
+	}{
+		{name: "any policy", report: testReport(), targetMeasurement: measurement},
+		{name: "target policy", report: testReport(), targetMeasurement: measurement, targetPolicy: "0000000000030000"},
+		{name: "other measurement", report: testReport(), targetMeasurement: hex.EncodeToString(make([]byte, 48)), wantErr: true},
+		{name: "no target measurement", report: testReport(), wantErr: true},
+		{name: "other policy", report: testReport(), targetMeasurement: measurement, targetPolicy: "0000000000010000", wantErr: true},
+		{name: "debug policy", report: debugReport, targetMeasurement: measurement, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifySnpReport(append(pki.sign(t, tt.report), certificates...), tt.targetMeasurement, tt.targetPolicy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySnpReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifier_Policy(t *testing.T) {
+	measurement := strings.Repeat("ab", 48)
+	base64Measurement := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xab}, 48))
+
+	tests := []struct {
+		name            string
+		block           string
+		wantMeasurement string
+		wantPolicy      string
+		wantErr         bool
+	}{
+		{name: "no block"},
+		{name: "hex measurement", block: `{"measurementTarget": "` + measurement + `"}`, wantMeasurement: measurement},
+		{name: "base64 measurement", block: `{"measurementTarget": "` + base64Measurement + `"}`, wantMeasurement: measurement},
+		{name: "short measurement", block: `{"measurementTarget": "abcd"}`, wantErr: true},
+		{name: "hex policy", block: `{"measurementTarget": "` + measurement + `", "policyTarget": "0x30000"}`, wantMeasurement: measurement, wantPolicy: "0000000000030000"},
+		{name: "decimal policy", block: `{"measurementTarget": "` + measurement + `", "policyTarget": "196608"}`, wantMeasurement: measurement, wantPolicy: "0000000000030000"},
+		{name: "invalid policy", block: `{"policyTarget": "debug"}`, wantErr: true},
+		{name: "unknown field", block: `{"measurement": "` + measurement + `"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var block json.RawMessage
+			if tt.block != "" {
+				block = json.RawMessage(tt.block)
+			}
+
+			policy, err := Verifier{}.Policy(block)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Policy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if policy.Measurements[MEASUREMENT_MEASUREMENT] != tt.wantMeasurement || policy.Measurements[MEASUREMENT_POLICY] != tt.wantPolicy {
+				t.Errorf("Policy() = %+v", policy)
+			}
+		})
+	}
+}
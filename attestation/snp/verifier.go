@@ -0,0 +1,129 @@
+package snp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/nonce"
+)
+
+const (
+	// the report type of SNP attestation reports
+	TEE_TYPE = "snp"
+
+	// the measurements of SNP reports, the policy is formatted with FormatPolicy
+	MEASUREMENT_MEASUREMENT = "measurement"
+	MEASUREMENT_POLICY      = "policy"
+
+	// the size of the launch measurement
+	MEASUREMENT_SIZE = 48
+
+	// same as SGX, the nonce follows the Poseidon8 hash in the report data
+	REPORT_DATA_NONCE_OFFSET = 16
+)
+
+// MeasurementInfo is the launch measurement in the encodings of /info
+type MeasurementInfo struct {
+	Hex    string `json:"hexEncoded"`
+	Base64 string `json:"base64Encoded"`
+}
+
+// TargetInfo is the SNP policy in /info
+type TargetInfo struct {
+	Measurement MeasurementInfo `json:"measurement"`
+	Policy      string          `json:"policy,omitempty"`
+}
+
+// PolicyConfig is the "snp" policy block of a profile. SNP reports are rejected if the measurement isn't set.
+type PolicyConfig struct {
+	// the launch measurement, 48 bytes hex- or base64-encoded
+	MeasurementTarget string `json:"measurementTarget"`
+	// the guest policy, e.g. "0x30000" as in the launch tooling. If not set, any policy that doesn't allow debugging
+	// is accepted.
+	PolicyTarget string `json:"policyTarget"`
+}
+
+// Verifier verifies SNP attestation reports, the policy has the target launch measurement and guest policy
+type Verifier struct{}
+
+func init() {
+	tee.Register(Verifier{})
+}
+
+func (Verifier) Type() string {
+	return TEE_TYPE
+}
+
+// Policy parses a PolicyConfig
+func (Verifier) Policy(block json.RawMessage) (tee.Policy, error) {
+	var policyConfig PolicyConfig
+	if err := tee.DecodePolicy(block, &policyConfig); err != nil {
+		return tee.Policy{}, err
+	}
+
+	measurement := policyConfig.MeasurementTarget
+	if measurement != "" {
+		var ok bool
+		if measurement, ok = tee.NormalizeMeasurement(measurement, MEASUREMENT_SIZE); !ok {
+			return tee.Policy{}, fmt.Errorf("\"measurementTarget\" must be %d bytes hex- or base64-encoded", MEASUREMENT_SIZE)
+		}
+	}
+
+	guestPolicy := ""
+	if policyConfig.PolicyTarget != "" {
+		policy, err := strconv.ParseUint(policyConfig.PolicyTarget, 0, 64)
+		if err != nil {
+			return tee.Policy{}, errors.New("\"policyTarget\" must be a 64-bit number, e.g. \"0x30000\"")
+		}
+		guestPolicy = FormatPolicy(policy)
+	}
+
+	return tee.Policy{
+		Measurements: map[string]string{
+			MEASUREMENT_MEASUREMENT: measurement,
+			MEASUREMENT_POLICY:      guestPolicy,
+		},
+	}, nil
+}
+
+// Verify verifies an SNP report. The nonce is read from the report data, the claimed nonce isn't used.
+func (Verifier) Verify(reportBytes []byte, _ string, policy tee.Policy) (*tee.Result, error) {
+	report, err := VerifySnpReport(reportBytes, policy.Measurements[MEASUREMENT_MEASUREMENT], policy.Measurements[MEASUREMENT_POLICY])
+	if err != nil {
+		return nil, err
+	}
+
+	return &tee.Result{
+		Type: TEE_TYPE,
+		Measurements: map[string]string{
+			MEASUREMENT_MEASUREMENT: hex.EncodeToString(report.Measurement[:]),
+			MEASUREMENT_POLICY:      FormatPolicy(report.Policy),
+		},
+		UserData: report.ReportData[:],
+		Nonce:    report.ReportData[REPORT_DATA_NONCE_OFFSET : REPORT_DATA_NONCE_OFFSET+nonce.NONCE_SIZE],
+		Report:   report,
+	}, nil
+}
+
+// DescribePolicy describes the target launch measurement and guest policy as TargetInfo
+func (Verifier) DescribePolicy(policy tee.Policy) (any, error) {
+	measurementHex := policy.Measurements[MEASUREMENT_MEASUREMENT]
+
+	measurement, err := hex.DecodeString(measurementHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hex-decode SNP measurement: %w", err)
+	}
+
+	return TargetInfo{
+		Measurement: MeasurementInfo{
+			Hex:    measurementHex,
+			Base64: base64.StdEncoding.EncodeToString(measurement),
+		},
+		Policy: policy.Measurements[MEASUREMENT_POLICY],
+	}, nil
+}
@@ -0,0 +1,163 @@
+// Package tdx verifies Intel TDX quotes offline, against the TDX collateral of the platforms in the SGX collateral
+// cache.
+package tdx
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/collateral"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+var ErrNoCollateralCache = errors.New("tdx: TD quotes are only verified offline, no collateral cache is configured")
+
+// the collateral cache with the TDX collateral of the platforms
+var collateralCache *collateral.Cache
+
+// the root CA that collateral must be issued by, the Intel SGX root CA unless Init pins another one
+var rootCaPin collateral.RootCaPin
+
+// whether collateral past its next update is accepted, see Init
+var allowExpiredCollateral bool
+
+// Init sets up the collateral cache with the TDX collateral of the platforms, see collateral.Cache.StoreTd. Same as
+// for SGX, collateral must be issued by the Intel SGX root CA, or by the root CA with the fingerprint if it's set, and
+// collateral past its next update is rejected unless allowExpired is set.
+func Init(collateralCacheDir string, trustedRootCaFingerprint string, allowExpired bool) {
+	if collateralCacheDir != "" {
+		collateralCache = collateral.NewCache(collateralCacheDir)
+	}
+
+	rootCaPin = collateral.NewRootCaPin(trustedRootCaFingerprint)
+	allowExpiredCollateral = allowExpired
+}
+
+// Verify verifies a TD quote against the cached TDX collateral of its platform
+func Verify(reportBytes []byte, now time.Time) (*collateral.TdResult, error) {
+	if collateralCache == nil {
+		return nil, ErrNoCollateralCache
+	}
+
+	result, err := collateralCache.VerifyTd(reportBytes, rootCaPin, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkExpiry(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// VerifyWithCollateral verifies a TD quote against the TDX collateral supplied by the caller instead of the cache
+func VerifyWithCollateral(reportBytes []byte, bundle *collateral.Collateral, now time.Time) (*collateral.TdResult, error) {
+	result, err := collateral.VerifyTd(reportBytes, bundle, rootCaPin, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkExpiry(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// rejects a result verified with collateral past its next update, unless Init allows it
+func checkExpiry(result *collateral.TdResult) error {
+	if !result.Expired {
+		return nil
+	}
+
+	if !allowExpiredCollateral {
+		return fmt.Errorf("%w: TDX collateral for FMSPC %s", collateral.ErrExpiredCollateral, result.Pck.Fmspc)
+	}
+
+	log.Printf("WARNING: TDX collateral for FMSPC %s is past its next update", result.Pck.Fmspc)
+	return nil
+}
+
+// VerifyTdxReport verifies a TD quote and checks it against the target MRTD and the RTMR targets that are set. TDs
+// that allow debugging are rejected. Platforms that aren't up to date are rejected unless they only need configuration
+// and all of their advisories are allowed.
+func VerifyTdxReport(reportBytes []byte, targetMrTd string, targetRtmrs [4]string) (*quote.TdQuote, error) {
+	result, err := Verify(reportBytes, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkReport(result, targetMrTd, targetRtmrs); err != nil {
+		return nil, err
+	}
+
+	return result.Quote, nil
+}
+
+// VerifyTdxReportWithCollateral is VerifyTdxReport with the TDX collateral supplied by the caller
+func VerifyTdxReportWithCollateral(reportBytes []byte, bundle *collateral.Collateral, targetMrTd string, targetRtmrs [4]string) (*quote.TdQuote, error) {
+	result, err := VerifyWithCollateral(reportBytes, bundle, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkReport(result, targetMrTd, targetRtmrs); err != nil {
+		return nil, err
+	}
+
+	return result.Quote, nil
+}
+
+// checks the TCB status and the measurements of a verified TD quote
+func checkReport(result *collateral.TdResult, targetMrTd string, targetRtmrs [4]string) error {
+	switch result.TcbStatus {
+	case tcbstatus.UpToDate:
+	case tcbstatus.ConfigurationNeeded, tcbstatus.ConfigurationAndSWHardeningNeeded:
+		// tolerate these under current policy, if the advisories are allowed
+		for _, adv := range result.TcbAdvisories {
+			if !sgx.AllowedAdvisory(adv) {
+				return errors.New("report has disallowed TCB advisory: " + adv)
+			}
+		}
+	default:
+		log.Printf("TDX platform has TCB status %s", result.TcbStatus)
+		return errors.New("report has invalid TCB level")
+	}
+
+	body := &result.Quote.ReportBody
+
+	if body.Debug() {
+		log.Printf("TD attributes allow debugging")
+		return errors.New("report TD attributes allow debugging")
+	}
+
+	if targetMrTd == "" {
+		return errors.New("no target TDX MRTD is configured")
+	}
+
+	mrTd := hex.EncodeToString(body.MrTd[:])
+	if mrTd != targetMrTd {
+		log.Printf("reporting TD MRTD doesn't match the expected one, expected=%s, got=%s", targetMrTd, mrTd)
+		return errors.New("report MRTD doesn't match target")
+	}
+
+	for idx, targetRtmr := range targetRtmrs {
+		if targetRtmr == "" {
+			continue
+		}
+
+		rtmr := hex.EncodeToString(body.Rtmrs[idx][:])
+		if rtmr != targetRtmr {
+			log.Printf("reporting TD RTMR%d doesn't match the expected one, expected=%s, got=%s", idx, targetRtmr, rtmr)
+			return fmt.Errorf("report RTMR%d doesn't match target", idx)
+		}
+	}
+
+	return nil
+}
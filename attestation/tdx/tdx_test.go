@@ -0,0 +1,130 @@
+package tdx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/collateral"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+// a verified TD quote with the TCB status, MRTD 0xaa... and RTMR1 0xbb...
+func testResult(status tcbstatus.Status, advisories ...string) *collateral.TdResult {
+	body := quote.TdReportBody{}
+	copy(body.MrTd[:], bytes.Repeat([]byte{0xaa}, 48))
+	copy(body.Rtmrs[1][:], bytes.Repeat([]byte{0xbb}, 48))
+
+	return &collateral.TdResult{
+		Quote:         &quote.TdQuote{ReportBody: body},
+		TcbStatus:     status,
+		TcbAdvisories: advisories,
+	}
+}
+
+func TestCheckReport(t *testing.T) {
+	mrTd := strings.Repeat("aa", 48)
+	rtmr1 := strings.Repeat("bb", 48)
+
+	debug := testResult(tcbstatus.UpToDate)
+	debug.Quote.ReportBody.TdAttributes |= quote.TD_ATTRIBUTE_DEBUG
+
+	tests := []struct {
+		name        string
+		result      *collateral.TdResult
+		targetMrTd  string
+		targetRtmrs [4]string
+		wantErr     string
+	}{
+		{name: "up to date", result: testResult(tcbstatus.UpToDate), targetMrTd: mrTd},
+		{name: "RTMR target", result: testResult(tcbstatus.UpToDate), targetMrTd: mrTd, targetRtmrs: [4]string{"", rtmr1}},
+		{name: "configuration needed", result: testResult(tcbstatus.ConfigurationNeeded, "INTEL-SA-00615"), targetMrTd: mrTd},
+		{name: "disallowed advisory", result: testResult(tcbstatus.ConfigurationNeeded, "INTEL-SA-00837"), targetMrTd: mrTd, wantErr: "disallowed TCB advisory"},
+		{name: "out of date", result: testResult(tcbstatus.OutOfDate), targetMrTd: mrTd, wantErr: "invalid TCB level"},
+		{name: "debug", result: debug, targetMrTd: mrTd, wantErr: "debugging"},
+		{name: "no target MRTD", result: testResult(tcbstatus.UpToDate), wantErr: "no target TDX MRTD"},
+		{name: "other MRTD", result: testResult(tcbstatus.UpToDate), targetMrTd: strings.Repeat("cc", 48), wantErr: "MRTD doesn't match"},
+		{name: "other RTMR", result: testResult(tcbstatus.UpToDate), targetMrTd: mrTd, targetRtmrs: [4]string{"", "", "", rtmr1}, wantErr: "RTMR3 doesn't match"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReport(tt.result, tt.targetMrTd, tt.targetRtmrs)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkReport() error = %v, wantErr %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify_noCollateralCache(t *testing.T) {
+	if _, err := Verify(nil, time.Now()); !errors.Is(err, ErrNoCollateralCache) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrNoCollateralCache)
+	}
+}
+
+func TestCheckExpiry(t *testing.T) {
+	t.Cleanup(func() {
+		allowExpiredCollateral = false
+	})
+
+	expired := testResult(tcbstatus.UpToDate)
+	expired.Pck = &collateral.PckCertificate{Fmspc: "00806f050000"}
+	expired.Expired = true
+
+	if err := checkExpiry(testResult(tcbstatus.UpToDate)); err != nil {
+		t.Errorf("checkExpiry() error = %v", err)
+	}
+	if err := checkExpiry(expired); !errors.Is(err, collateral.ErrExpiredCollateral) {
+		t.Errorf("checkExpiry() error = %v, want %v", err, collateral.ErrExpiredCollateral)
+	}
+
+	allowExpiredCollateral = true
+	if err := checkExpiry(expired); err != nil {
+		t.Errorf("checkExpiry() with expired collateral allowed error = %v", err)
+	}
+}
+
+func TestVerifier_Policy(t *testing.T) {
+	mrTd := strings.Repeat("aa", 48)
+	rtmr1 := strings.Repeat("bb", 48)
+
+	base64Rtmr1 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xbb}, 48))
+
+	for _, block := range []string{
+		`{"mrTdTarget": "abcd"}`,
+		`{"mrTdTarget": "` + mrTd + `", "rtmrTargets": ["abcd"]}`,
+		`{"mrTdTarget": "` + mrTd + `", "rtmrTargets": ["", "", "", "", ""]}`,
+		`{"mrTd": "` + mrTd + `"}`,
+	} {
+		if _, err := (Verifier{}).Policy(json.RawMessage(block)); err == nil {
+			t.Errorf("Policy(%s) didn't fail", block)
+		}
+	}
+
+	policy, err := Verifier{}.Policy(json.RawMessage(`{"mrTdTarget": "` + mrTd + `", "rtmrTargets": ["", "` + base64Rtmr1 + `"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Measurements[MEASUREMENT_MRTD] != mrTd || policy.Measurements["rtmr1"] != rtmr1 || policy.Measurements["rtmr3"] != "" || len(policy.Measurements) != 5 {
+		t.Fatalf("Policy() = %+v", policy)
+	}
+
+	described, err := Verifier{}.DescribePolicy(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := described.(TargetInfo)
+	if info.MrTd.Hex != mrTd || info.Rtmrs[0] != nil || info.Rtmrs[1] == nil || info.Rtmrs[1].Hex != rtmr1 {
+		t.Errorf("DescribePolicy() = %+v", info)
+	}
+
+	if info.Rtmrs[1].Base64 != base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xbb}, 48)) {
+		t.Errorf("DescribePolicy() RTMR1 = %+v", info.Rtmrs[1])
+	}
+}
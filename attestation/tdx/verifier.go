@@ -0,0 +1,195 @@
+package tdx
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/collateral"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/nonce"
+)
+
+const (
+	// the report type of TD quotes
+	TEE_TYPE = "tdx"
+
+	// the measurement of the initial TD contents, see MEASUREMENT_RTMRS for the runtime measurements
+	MEASUREMENT_MRTD = "mrTd"
+
+	// the size of MRTD and the RTMRs
+	MEASUREMENT_SIZE = 48
+
+	// same as SGX, the nonce follows the Poseidon8 hash in REPORTDATA
+	REPORT_DATA_NONCE_OFFSET = 16
+)
+
+// the runtime measurement registers RTMR0-3
+var MEASUREMENT_RTMRS = [4]string{"rtmr0", "rtmr1", "rtmr2", "rtmr3"}
+
+// MeasurementInfo is a TDX measurement in the encodings of /info
+type MeasurementInfo struct {
+	Hex    string `json:"hexEncoded"`
+	Base64 string `json:"base64Encoded"`
+}
+
+// TargetInfo is the TDX policy in /info, RTMRs without a target are null
+type TargetInfo struct {
+	MrTd  MeasurementInfo     `json:"mrTd"`
+	Rtmrs [4]*MeasurementInfo `json:"rtmrs"`
+}
+
+// PolicyConfig is the "tdx" policy block of a profile. TD quotes are rejected if the MRTD isn't set.
+type PolicyConfig struct {
+	// 48 bytes hex- or base64-encoded
+	MrTdTarget string `json:"mrTdTarget"`
+	// RTMR0-3, 48 bytes hex- or base64-encoded. Empty or missing values aren't checked.
+	RtmrTargets []string `json:"rtmrTargets"`
+}
+
+// Verifier verifies TD quotes, the policy has the target MRTD and the RTMR targets
+type Verifier struct{}
+
+func init() {
+	tee.Register(Verifier{})
+}
+
+func (Verifier) Type() string {
+	return TEE_TYPE
+}
+
+// Policy parses a PolicyConfig
+func (Verifier) Policy(block json.RawMessage) (tee.Policy, error) {
+	var policyConfig PolicyConfig
+	if err := tee.DecodePolicy(block, &policyConfig); err != nil {
+		return tee.Policy{}, err
+	}
+
+	if len(policyConfig.RtmrTargets) > len(MEASUREMENT_RTMRS) {
+		return tee.Policy{}, errors.New("\"rtmrTargets\" must have at most 4 values, for RTMR0-3")
+	}
+
+	measurements := map[string]string{
+		MEASUREMENT_MRTD: policyConfig.MrTdTarget,
+	}
+	if policyConfig.MrTdTarget != "" {
+		mrTd, ok := tee.NormalizeMeasurement(policyConfig.MrTdTarget, MEASUREMENT_SIZE)
+		if !ok {
+			return tee.Policy{}, fmt.Errorf("\"mrTdTarget\" must be %d bytes hex- or base64-encoded", MEASUREMENT_SIZE)
+		}
+		measurements[MEASUREMENT_MRTD] = mrTd
+	}
+
+	for idx, name := range MEASUREMENT_RTMRS {
+		measurements[name] = ""
+		// RTMRs that aren't checked
+		if idx >= len(policyConfig.RtmrTargets) || policyConfig.RtmrTargets[idx] == "" {
+			continue
+		}
+
+		rtmr, ok := tee.NormalizeMeasurement(policyConfig.RtmrTargets[idx], MEASUREMENT_SIZE)
+		if !ok {
+			return tee.Policy{}, fmt.Errorf("\"rtmrTargets\" values must be empty or %d bytes hex- or base64-encoded", MEASUREMENT_SIZE)
+		}
+		measurements[name] = rtmr
+	}
+
+	return tee.Policy{
+		Measurements: measurements,
+	}, nil
+}
+
+// Verify verifies a TD quote. The nonce is read from REPORTDATA, the claimed nonce isn't used.
+func (Verifier) Verify(reportBytes []byte, _ string, policy tee.Policy) (*tee.Result, error) {
+	mrTd, rtmrs := targets(policy)
+
+	tdQuote, err := VerifyTdxReport(reportBytes, mrTd, rtmrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResult(tdQuote), nil
+}
+
+// VerifyWithCollateral verifies a TD quote against the TDX collateral.Collateral, in the format of the files written
+// by export-collateral
+func (Verifier) VerifyWithCollateral(reportBytes []byte, _ string, policy tee.Policy, block json.RawMessage) (*tee.Result, error) {
+	var bundle collateral.Collateral
+	if err := json.Unmarshal(block, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %w", collateral.ErrMalformedCollateral, err)
+	}
+
+	mrTd, rtmrs := targets(policy)
+
+	tdQuote, err := VerifyTdxReportWithCollateral(reportBytes, &bundle, mrTd, rtmrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResult(tdQuote), nil
+}
+
+// the target MRTD and RTMRs of the policy
+func targets(policy tee.Policy) (string, [4]string) {
+	var targetRtmrs [4]string
+	for idx, name := range MEASUREMENT_RTMRS {
+		targetRtmrs[idx] = policy.Measurements[name]
+	}
+
+	return policy.Measurements[MEASUREMENT_MRTD], targetRtmrs
+}
+
+func newResult(tdQuote *quote.TdQuote) *tee.Result {
+	body := &tdQuote.ReportBody
+
+	measurements := map[string]string{
+		MEASUREMENT_MRTD: hex.EncodeToString(body.MrTd[:]),
+	}
+	for idx, name := range MEASUREMENT_RTMRS {
+		measurements[name] = hex.EncodeToString(body.Rtmrs[idx][:])
+	}
+
+	return &tee.Result{
+		Type:         TEE_TYPE,
+		Measurements: measurements,
+		UserData:     body.ReportData[:],
+		Nonce:        body.ReportData[REPORT_DATA_NONCE_OFFSET : REPORT_DATA_NONCE_OFFSET+nonce.NONCE_SIZE],
+		Report:       tdQuote,
+	}
+}
+
+func measurementInfo(measurementHex string) (*MeasurementInfo, error) {
+	measurement, err := hex.DecodeString(measurementHex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MeasurementInfo{
+		Hex:    measurementHex,
+		Base64: base64.StdEncoding.EncodeToString(measurement),
+	}, nil
+}
+
+// DescribePolicy describes the target MRTD and the RTMR targets as TargetInfo
+func (Verifier) DescribePolicy(policy tee.Policy) (any, error) {
+	mrTd, err := measurementInfo(policy.Measurements[MEASUREMENT_MRTD])
+	if err != nil {
+		return nil, fmt.Errorf("failed to hex-decode TDX MRTD: %w", err)
+	}
+
+	info := TargetInfo{MrTd: *mrTd}
+	for idx, name := range MEASUREMENT_RTMRS {
+		if policy.Measurements[name] == "" {
+			continue
+		}
+
+		if info.Rtmrs[idx], err = measurementInfo(policy.Measurements[name]); err != nil {
+			return nil, fmt.Errorf("failed to hex-decode TDX RTMR%d: %w", idx, err)
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,186 @@
+// Package tee defines the report verifiers of the trusted execution environments and the registry they register into,
+// so that a TEE can be added as a separate package. A TEE package registers its verifier in init.
+package tee
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnsupportedReportType  = errors.New("unsupported report type")
+	ErrCollateralNotSupported = errors.New("the report type doesn't support collateral supplied with the report")
+)
+
+// Policy is what a report is checked against after its signature is verified
+type Policy struct {
+	// the target measurements by name, hex-encoded, e.g. "uniqueId" for SGX
+	Measurements map[string]string
+}
+
+// Policies are the policies of a profile by report type
+type Policies map[string]Policy
+
+// Result is the normalized result of verifying a report
+type Result struct {
+	Type string
+	// the measurements of the attested enclave by name, hex-encoded
+	Measurements map[string]string
+	// the report data, starting with the hash of the attested data
+	UserData []byte
+	// the nonce bound to the report, nil if the report has none
+	Nonce []byte
+	// when the report was created, zero if the report has no timestamp
+	Timestamp time.Time
+	// the TEE-specific verified report
+	Report any
+}
+
+// Verifier verifies the reports of one TEE
+type Verifier interface {
+	// Type is the report type, e.g. "sgx"
+	Type() string
+	// Policy parses and validates the policy block of a profile, e.g. the target measurements. block is nil if the
+	// profile has no block for the report type.
+	Policy(block json.RawMessage) (Policy, error)
+	// Verify verifies a report and checks it against the policy. nonce is the hex-encoded nonce the report is claimed to
+	// be bound to, if any.
+	Verify(report []byte, nonce string, policy Policy) (*Result, error)
+	// DescribePolicy describes the target measurements of a policy for /info
+	DescribePolicy(policy Policy) (any, error)
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]Verifier)
+)
+
+// Register registers a verifier, it panics if a verifier for the same report type is registered
+func Register(verifier Verifier) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := registry[verifier.Type()]; ok {
+		panic(fmt.Sprintf("tee: verifier for %q is already registered", verifier.Type()))
+	}
+
+	registry[verifier.Type()] = verifier
+}
+
+// Get returns the verifier for the report type
+func Get(reportType string) (Verifier, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	verifier, ok := registry[reportType]
+	return verifier, ok
+}
+
+// All returns the registered verifiers sorted by report type
+func All() []Verifier {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	verifiers := make([]Verifier, 0, len(registry))
+	for _, verifier := range registry {
+		verifiers = append(verifiers, verifier)
+	}
+
+	sort.Slice(verifiers, func(i, j int) bool {
+		return verifiers[i].Type() < verifiers[j].Type()
+	})
+
+	return verifiers
+}
+
+// ProfilePolicies creates the policies of every registered verifier from the policy blocks of a profile by report type.
+// A block for a report type without a verifier is rejected.
+func ProfilePolicies(blocks map[string]json.RawMessage) (Policies, error) {
+	for reportType := range blocks {
+		if _, ok := Get(reportType); !ok {
+			return nil, fmt.Errorf("%w: policy for %q", ErrUnsupportedReportType, reportType)
+		}
+	}
+
+	policies := make(Policies)
+	for _, verifier := range All() {
+		policy, err := verifier.Policy(blocks[verifier.Type()])
+		if err != nil {
+			return nil, fmt.Errorf("%s policy: %w", verifier.Type(), err)
+		}
+		policies[verifier.Type()] = policy
+	}
+
+	return policies, nil
+}
+
+// DecodePolicy decodes a policy block into v, unknown fields are rejected. A nil block leaves v as is.
+func DecodePolicy(block json.RawMessage, v any) error {
+	if block == nil {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(block))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(v)
+}
+
+// NormalizeMeasurement decodes a hex- or base64-encoded measurement of size bytes and returns it hex-encoded
+func NormalizeMeasurement(measurement string, size int) (string, bool) {
+	measurementBytes, err := hex.DecodeString(measurement)
+	if err != nil {
+		measurementBytes, err = base64.StdEncoding.DecodeString(measurement)
+		if err != nil {
+			return "", false
+		}
+	}
+
+	if len(measurementBytes) != size {
+		return "", false
+	}
+
+	return hex.EncodeToString(measurementBytes), true
+}
+
+// Verify verifies a report with the verifier for its type against the policy for its type
+func Verify(reportType string, report []byte, nonce string, policies Policies) (*Result, error) {
+	verifier, ok := Get(reportType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedReportType, reportType)
+	}
+
+	return verifier.Verify(report, nonce, policies[reportType])
+}
+
+// CollateralVerifier is implemented by the verifiers that can verify a report against collateral supplied with it
+// instead of the configured source, e.g. by an air-gapped auditor. The collateral block is parsed by the verifier.
+type CollateralVerifier interface {
+	VerifyWithCollateral(report []byte, nonce string, policy Policy, collateral json.RawMessage) (*Result, error)
+}
+
+// VerifyWithCollateral verifies a report the same way as Verify, against the collateral block if it's not nil
+func VerifyWithCollateral(reportType string, report []byte, nonce string, policies Policies, collateral json.RawMessage) (*Result, error) {
+	if collateral == nil {
+		return Verify(reportType, report, nonce, policies)
+	}
+
+	verifier, ok := Get(reportType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedReportType, reportType)
+	}
+
+	collateralVerifier, ok := verifier.(CollateralVerifier)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrCollateralNotSupported, reportType)
+	}
+
+	return collateralVerifier.VerifyWithCollateral(report, nonce, policies[reportType], collateral)
+}
@@ -0,0 +1,122 @@
+package tee
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type testVerifier struct {
+	reportType string
+}
+
+func (v testVerifier) Type() string {
+	return v.reportType
+}
+
+func (v testVerifier) Policy(block json.RawMessage) (Policy, error) {
+	var policyConfig struct {
+		Id string `json:"id"`
+	}
+	if err := DecodePolicy(block, &policyConfig); err != nil {
+		return Policy{}, err
+	}
+
+	return Policy{Measurements: map[string]string{"id": policyConfig.Id}}, nil
+}
+
+func (v testVerifier) Verify(report []byte, nonce string, policy Policy) (*Result, error) {
+	if string(report) != policy.Measurements["id"] {
+		return nil, errors.New("report doesn't match target")
+	}
+
+	return &Result{Type: v.reportType, Measurements: map[string]string{"id": string(report)}, UserData: report}, nil
+}
+
+func (v testVerifier) DescribePolicy(policy Policy) (any, error) {
+	return policy.Measurements["id"], nil
+}
+
+func TestRegistry(t *testing.T) {
+	Register(testVerifier{reportType: "test-b"})
+	Register(testVerifier{reportType: "test-a"})
+
+	if _, ok := Get("test-a"); !ok {
+		t.Error("Get() didn't find a registered verifier")
+	}
+	if _, ok := Get("test-c"); ok {
+		t.Error("Get() found a verifier that wasn't registered")
+	}
+
+	var reportTypes []string
+	for _, verifier := range All() {
+		reportTypes = append(reportTypes, verifier.Type())
+	}
+	if len(reportTypes) != 2 || reportTypes[0] != "test-a" || reportTypes[1] != "test-b" {
+		t.Errorf("All() = %v, want [test-a test-b]", reportTypes)
+	}
+
+	policies, err := ProfilePolicies(map[string]json.RawMessage{"test-a": json.RawMessage(`{"id": "abcd"}`)})
+	if err != nil {
+		t.Fatalf("ProfilePolicies() error = %v", err)
+	}
+	if len(policies) != 2 || policies["test-a"].Measurements["id"] != "abcd" || policies["test-b"].Measurements["id"] != "" {
+		t.Errorf("ProfilePolicies() = %v", policies)
+	}
+
+	if _, err := ProfilePolicies(map[string]json.RawMessage{"test-c": json.RawMessage(`{}`)}); !errors.Is(err, ErrUnsupportedReportType) {
+		t.Errorf("ProfilePolicies() error = %v, want %v", err, ErrUnsupportedReportType)
+	}
+	if _, err := ProfilePolicies(map[string]json.RawMessage{"test-a": json.RawMessage(`{"uniqueId": "abcd"}`)}); err == nil {
+		t.Error("ProfilePolicies() accepted an unknown policy field")
+	}
+
+	result, err := Verify("test-a", []byte("abcd"), "", policies)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Type != "test-a" || !bytes.Equal(result.UserData, []byte("abcd")) {
+		t.Errorf("Verify() = %+v", result)
+	}
+
+	if _, err := Verify("test-a", []byte("dcba"), "", policies); err == nil {
+		t.Error("Verify() didn't check the report against the policy")
+	}
+	if _, err := Verify("test-c", []byte("abcd"), "", policies); !errors.Is(err, ErrUnsupportedReportType) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrUnsupportedReportType)
+	}
+
+	if _, err := VerifyWithCollateral("test-a", []byte("abcd"), "", policies, nil); err != nil {
+		t.Errorf("VerifyWithCollateral() without collateral error = %v", err)
+	}
+	if _, err := VerifyWithCollateral("test-a", []byte("abcd"), "", policies, json.RawMessage(`{}`)); !errors.Is(err, ErrCollateralNotSupported) {
+		t.Errorf("VerifyWithCollateral() error = %v, want %v", err, ErrCollateralNotSupported)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() didn't panic on a duplicate report type")
+		}
+	}()
+	Register(testVerifier{reportType: "test-a"})
+}
+
+func TestNormalizeMeasurement(t *testing.T) {
+	tests := []struct {
+		measurement string
+		want        string
+		wantOk      bool
+	}{
+		{measurement: "abcd", want: "abcd", wantOk: true},
+		{measurement: "q80=", want: "abcd", wantOk: true},
+		{measurement: "ab", wantOk: false},
+		{measurement: "not a measurement", wantOk: false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeMeasurement(tt.measurement, 2)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("NormalizeMeasurement(%q) = %q, %v, want %q, %v", tt.measurement, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package auditor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+	"github.com/venture23-aleo/oracle-verification-backend/transaction"
+)
+
+// the maximum number of blocks audited in one poll, so that catching up does not delay the status updates
+const maxBlocksPerPoll = 100
+
+const alertTimeout = time.Second * 10
+
+// Status describes the progress of the auditor
+type Status struct {
+	Running      bool   `json:"running"`
+	LastHeight   uint64 `json:"lastHeight"`
+	LatestHeight uint64 `json:"latestHeight"`
+	Audited      uint64 `json:"audited"`
+	Failed       uint64 `json:"failed"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
+// Auditor follows new blocks on the Aleo node API and verifies every oracle update of a contract
+type Auditor struct {
+	aleoWrapper     aleo.Wrapper
+	nodeClient      *node.Client
+	contractName    string
+	policies        tee.Policies
+	store           *Store
+	interval        time.Duration
+	alertWebhookUrl string
+
+	mu         sync.Mutex
+	nextHeight uint64
+	status     Status
+}
+
+// New creates an auditor. The audit starts from startHeight, or from the last block in the store, whichever is higher.
+// The last block is audited again in case it wasn't completed, skipping the oracle updates in the store.
+// Zero startHeight with an empty store starts from the latest block. alertWebhookUrl is optional.
+func New(aleoWrapper aleo.Wrapper, nodeClient *node.Client, contractName string, policies tee.Policies, store *Store, startHeight uint64, interval time.Duration, alertWebhookUrl string) *Auditor {
+	nextHeight := startHeight
+	if lastHeight := store.LastHeight(); lastHeight > nextHeight {
+		nextHeight = lastHeight
+	}
+
+	return &Auditor{
+		aleoWrapper:     aleoWrapper,
+		nodeClient:      nodeClient,
+		contractName:    contractName,
+		policies:        policies,
+		store:           store,
+		interval:        interval,
+		alertWebhookUrl: alertWebhookUrl,
+		nextHeight:      nextHeight,
+		status: Status{
+			LastHeight: store.LastHeight(),
+		},
+	}
+}
+
+// Store returns the store with the audit records
+func (a *Auditor) Store() *Store {
+	return a.store
+}
+
+// Status returns the current progress of the auditor
+func (a *Auditor) Status() Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.status
+}
+
+// Run follows the new blocks until the context is cancelled
+func (a *Auditor) Run(ctx context.Context) {
+	session, err := a.aleoWrapper.NewSession()
+	if err != nil {
+		log.Println("auditor: failed to create aleo session:", err)
+		a.setError(err)
+		return
+	}
+	defer session.Close()
+
+	a.mu.Lock()
+	a.status.Running = true
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.status.Running = false
+		a.mu.Unlock()
+	}()
+
+	log.Printf("auditor: auditing oracle updates of %s using %s\n", a.contractName, a.nodeClient.BaseUrl())
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		caughtUp, err := a.poll(ctx, session)
+		if err != nil {
+			log.Println("auditor: poll failed:", err)
+		}
+		a.setError(err)
+
+		if caughtUp || err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (a *Auditor) setError(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err != nil {
+		a.status.LastError = err.Error()
+	} else {
+		a.status.LastError = ""
+	}
+}
+
+// audits the blocks up to the latest one, returns true if there are no more blocks to audit
+func (a *Auditor) poll(ctx context.Context, session aleo.Session) (bool, error) {
+	latestHeight, err := transaction.FetchLatestHeight(ctx, a.nodeClient)
+	if err != nil {
+		return false, err
+	}
+
+	a.mu.Lock()
+	a.status.LatestHeight = latestHeight
+	if a.nextHeight == 0 {
+		a.nextHeight = latestHeight
+	}
+	nextHeight := a.nextHeight
+	a.mu.Unlock()
+
+	for audited := 0; nextHeight <= latestHeight; audited++ {
+		if audited == maxBlocksPerPoll {
+			return false, nil
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+
+		if err := a.auditBlock(ctx, session, nextHeight); err != nil {
+			return false, fmt.Errorf("block %d: %w", nextHeight, err)
+		}
+
+		nextHeight++
+
+		a.mu.Lock()
+		a.nextHeight = nextHeight
+		a.status.LastHeight = nextHeight - 1
+		a.mu.Unlock()
+	}
+
+	return true, nil
+}
+
+// verifies the oracle updates in the accepted transactions of a block
+func (a *Auditor) auditBlock(ctx context.Context, session aleo.Session, height uint64) error {
+	block, err := transaction.FetchBlock(ctx, a.nodeClient, height)
+	if err != nil {
+		return err
+	}
+
+	for idx := range block.Transactions {
+		confirmed := &block.Transactions[idx]
+		if confirmed.Status != "accepted" {
+			continue
+		}
+
+		updates := transaction.ExtractOracleUpdates(&confirmed.Transaction, a.contractName)
+		for updateIdx := range updates {
+			if a.store.Audited(height, updates[updateIdx].TransitionId) {
+				continue
+			}
+
+			result := transaction.VerifyOracleUpdate(session, confirmed.Transaction.Id, &updates[updateIdx], a.policies)
+
+			record := Record{
+				Height:    height,
+				AuditedAt: time.Now().UTC(),
+				Result:    result,
+			}
+
+			if err := a.store.Add(record); err != nil {
+				return err
+			}
+
+			a.mu.Lock()
+			a.status.Audited++
+			if !result.Valid {
+				a.status.Failed++
+			}
+			a.mu.Unlock()
+
+			if !result.Valid {
+				a.alert(&record)
+			}
+		}
+	}
+
+	return nil
+}
+
+// reports an oracle update that failed verification
+func (a *Auditor) alert(record *Record) {
+	log.Printf("auditor: ALERT: oracle update in transaction %s (transition %s, block %d) failed verification: %s\n",
+		record.Result.TransactionId, record.Result.TransitionId, record.Height, record.Result.ErrorMessage)
+
+	if a.alertWebhookUrl == "" {
+		return
+	}
+
+	body, err := json.Marshal(record)
+	if err != nil {
+		log.Println("auditor: failed to marshal alert:", err)
+		return
+	}
+
+	client := &http.Client{
+		Timeout: alertTimeout,
+	}
+
+	resp, err := client.Post(a.alertWebhookUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Println("auditor: failed to send alert:", err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Println("auditor: alert webhook responded with", resp.StatusCode)
+	}
+}
@@ -0,0 +1,236 @@
+package auditor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+	"github.com/venture23-aleo/oracle-verification-backend/transaction"
+)
+
+const testContractName = "official_oracle.aleo"
+
+// fakeSession cannot recover any message, so every oracle update fails verification
+type fakeSession struct {
+	aleo.Session
+}
+
+func (s *fakeSession) RecoverMessage(formattedMessage []byte) ([]byte, error) {
+	return nil, errors.New("unknown message")
+}
+
+func oracleTransaction(id string) transaction.Transaction {
+	tx := transaction.Transaction{
+		Type: "execute",
+		Id:   id,
+	}
+	tx.Execution = &struct {
+		Transitions []transaction.Transition `json:"transitions"`
+	}{
+		Transitions: []transaction.Transition{
+			{
+				Id:       "au1" + id,
+				Program:  testContractName,
+				Function: "set_sgx_data",
+				Inputs: []transaction.Input{
+					{Type: "public", Value: "{ c0: { f0: 1u128 } }"},
+					{Type: "public", Value: "{ c0: { f0: 2u128 } }"},
+					{Type: "public", Value: "sign1abc"},
+					{Type: "public", Value: "aleo1abc"},
+				},
+			},
+		},
+	}
+
+	return tx
+}
+
+// creates a mock Aleo node API serving the blocks up to the latest height
+func newMockNode(t *testing.T, blocks map[uint64]*transaction.Block, latestHeight uint64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/height" {
+			fmt.Fprint(w, latestHeight)
+			return
+		}
+
+		var height uint64
+		if _, err := fmt.Sscanf(r.URL.Path, "/block/%d", &height); err != nil || height > latestHeight {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		block, ok := blocks[height]
+		if !ok {
+			block = &transaction.Block{}
+		}
+		json.NewEncoder(w).Encode(block)
+	}))
+}
+
+func TestAuditorPoll(t *testing.T) {
+	blocks := map[uint64]*transaction.Block{
+		2: {
+			Transactions: []transaction.ConfirmedTransaction{
+				{Status: "accepted", Type: "execute", Transaction: oracleTransaction("at1accepted")},
+				{Status: "rejected", Type: "execute", Transaction: oracleTransaction("at1rejected")},
+			},
+		},
+	}
+
+	mockNode := newMockNode(t, blocks, 3)
+	defer mockNode.Close()
+
+	alerts := make(chan Record, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var record Record
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			t.Error(err)
+		}
+		alerts <- record
+	}))
+	defer webhook.Close()
+
+	store := NewMemoryStore(10)
+	client, err := node.NewClient([]string{mockNode.URL}, node.Options{MaxRetries: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit := New(nil, client, testContractName, nil, store, 1, time.Second, webhook.URL)
+
+	caughtUp, err := audit.poll(context.Background(), &fakeSession{})
+	if err != nil || !caughtUp {
+		t.Fatalf("poll() = %v, %v, want true, nil", caughtUp, err)
+	}
+
+	records := store.Recent(0, false)
+	if len(records) != 1 {
+		t.Fatalf("Recent() returned %d records, want 1", len(records))
+	}
+	if records[0].Height != 2 || records[0].Result.TransactionId != "at1accepted" || records[0].Result.Valid {
+		t.Errorf("Recent() = %+v, want a failed audit of at1accepted in block 2", records[0])
+	}
+
+	select {
+	case alert := <-alerts:
+		if alert.Result.TransactionId != "at1accepted" {
+			t.Errorf("alert for %s, want at1accepted", alert.Result.TransactionId)
+		}
+	default:
+		t.Error("no alert was sent")
+	}
+
+	status := audit.Status()
+	if status.LastHeight != 3 || status.LatestHeight != 3 || status.Audited != 1 || status.Failed != 1 {
+		t.Errorf("Status() = %+v", status)
+	}
+
+	// nothing new to audit
+	if _, err := audit.poll(context.Background(), &fakeSession{}); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+	if len(store.Recent(0, false)) != 1 {
+		t.Error("poll() audited the same block twice")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	store, err := NewFileStore(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for height := uint64(1); height <= 3; height++ {
+		err := store.Add(Record{
+			Height: height,
+			Result: &transaction.VerificationResult{Valid: height != 2},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if records := store.Recent(0, false); len(records) != 2 || records[0].Height != 3 || records[1].Height != 2 {
+		t.Errorf("Recent() = %+v, want the 2 newest records", records)
+	}
+	if records := store.Recent(0, true); len(records) != 1 || records[0].Height != 2 {
+		t.Errorf("Recent() failed only = %+v, want the record at height 2", records)
+	}
+	if records := store.Recent(1, false); len(records) != 1 || records[0].Height != 3 {
+		t.Errorf("Recent() limited = %+v, want the record at height 3", records)
+	}
+
+	store.Close()
+
+	// an entry without a result is skipped
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"height":5}` + "\n")
+	file.Close()
+
+	reopened, err := NewFileStore(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	if reopened.LastHeight() != 3 || len(reopened.Recent(0, false)) != 3 {
+		t.Errorf("reopened store has last height %d and %d records, want 3 and 3", reopened.LastHeight(), len(reopened.Recent(0, false)))
+	}
+	if records := reopened.Recent(0, true); len(records) != 1 {
+		t.Errorf("Recent() failed only = %+v, want the record at height 2", records)
+	}
+
+	// the audit resumes from the last stored block, which may not be completed
+	audit := New(nil, nil, testContractName, nil, reopened, 1, time.Second, "")
+	if audit.nextHeight != 3 {
+		t.Errorf("New() next height = %d, want 3", audit.nextHeight)
+	}
+}
+
+func TestAuditorResume(t *testing.T) {
+	blocks := map[uint64]*transaction.Block{
+		2: {
+			Transactions: []transaction.ConfirmedTransaction{
+				{Status: "accepted", Type: "execute", Transaction: oracleTransaction("at1first")},
+				{Status: "accepted", Type: "execute", Transaction: oracleTransaction("at1second")},
+			},
+		},
+	}
+
+	mockNode := newMockNode(t, blocks, 2)
+	defer mockNode.Close()
+
+	client, err := node.NewClient([]string{mockNode.URL}, node.Options{MaxRetries: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the auditor stopped after the first oracle update of block 2
+	store := NewMemoryStore(10)
+	store.Add(Record{Height: 2, Result: &transaction.VerificationResult{TransactionId: "at1first", TransitionId: "au1at1first"}})
+
+	audit := New(nil, client, testContractName, nil, store, 1, time.Second, "")
+
+	if _, err := audit.poll(context.Background(), &fakeSession{}); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+
+	records := store.Recent(0, false)
+	if len(records) != 2 || records[0].Result.TransactionId != "at1second" || records[1].Result.TransactionId != "at1first" {
+		t.Errorf("Recent() = %+v, want at1first once and at1second", records)
+	}
+}
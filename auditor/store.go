@@ -0,0 +1,162 @@
+package auditor
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/transaction"
+)
+
+// Record is the audit result of a single oracle update found on chain
+type Record struct {
+	Height    uint64                          `json:"height"`
+	AuditedAt time.Time                       `json:"auditedAt"`
+	Result    *transaction.VerificationResult `json:"result"`
+}
+
+// Store keeps the most recent audit records in memory. It is safe for concurrent use.
+// If the store has a file, then all records are appended to it, and the most recent ones are read on startup.
+type Store struct {
+	capacity int
+
+	mu         sync.Mutex
+	records    []Record
+	lastHeight uint64
+	// the transitions with a record at lastHeight, to resume auditing a block that wasn't completed
+	lastHeightTransitions map[string]bool
+	file                  *os.File
+}
+
+// NewMemoryStore creates a store that keeps up to capacity most recent records in memory
+func NewMemoryStore(capacity int) *Store {
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	return &Store{
+		capacity:              capacity,
+		records:               make([]Record, 0, capacity),
+		lastHeightTransitions: make(map[string]bool),
+	}
+}
+
+// NewFileStore creates a store that additionally persists all records in a file at path
+func NewFileStore(path string, capacity int) (*Store, error) {
+	s := NewMemoryStore(capacity)
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Result == nil {
+			log.Println("auditor: skipping malformed entry in", path)
+			continue
+		}
+		s.append(record)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s.file = file
+
+	return s, nil
+}
+
+// adds a record to the in-memory history, must be called with the lock held
+func (s *Store) append(record Record) {
+	if len(s.records) == s.capacity {
+		copy(s.records, s.records[1:])
+		s.records = s.records[:len(s.records)-1]
+	}
+	s.records = append(s.records, record)
+
+	if record.Height > s.lastHeight {
+		s.lastHeight = record.Height
+		clear(s.lastHeightTransitions)
+	}
+	if record.Height == s.lastHeight {
+		s.lastHeightTransitions[record.Result.TransitionId] = true
+	}
+}
+
+// Add stores an audit record
+func (s *Store) Add(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		line, err := json.Marshal(&record)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	s.append(record)
+
+	return nil
+}
+
+// Recent returns up to limit most recent records, newest first. Non-positive limit returns all records in memory.
+// If failedOnly is set, then only the records of oracle updates that failed verification are returned.
+func (s *Store) Recent(limit int, failedOnly bool) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Record, 0)
+	for idx := len(s.records) - 1; idx >= 0; idx-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		if failedOnly && s.records[idx].Result.Valid {
+			continue
+		}
+		result = append(result, s.records[idx])
+	}
+
+	return result
+}
+
+// LastHeight returns the highest block height with a stored record
+func (s *Store) LastHeight() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastHeight
+}
+
+// Audited reports whether the store has a record of the transition at the last height
+func (s *Store) Audited(height uint64, transitionId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return height == s.lastHeight && s.lastHeightTransitions[transitionId]
+}
+
+// Close closes the file of the store
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
@@ -0,0 +1,240 @@
+// Package backendinfo fetches the enclave measurements published by the notarization backend on its /info endpoint.
+package backendinfo
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	DEFAULT_SGX_INFO_URL   = "https://sgx.aleooracle.xyz/info"
+	DEFAULT_NITRO_INFO_URL = "https://nitro.aleooracle.xyz/info"
+
+	maxInfoSize = 1024 * 1024 // 1MB
+
+	uniqueIdLength = 32
+	pcrValueLength = 48
+)
+
+var (
+	ErrUnexpectedReportType = errors.New("backendinfo: unexpected report type")
+	ErrMalformedInfo        = errors.New("backendinfo: malformed info document")
+	ErrDebugEnclave         = errors.New("backendinfo: the enclave is in debug mode")
+	ErrSelfReportMismatch   = errors.New("backendinfo: the info document doesn't match its self-report")
+)
+
+// SgxInfo is the /info document of an SGX notarization backend
+type SgxInfo struct {
+	ReportType string `json:"reportType"`
+	Info       struct {
+		SecurityVersion uint   `json:"securityVersion"`
+		Debug           bool   `json:"debug"`
+		UniqueId        []byte `json:"uniqueId"`
+		SignerId        []byte `json:"signerId"`
+		ProductId       []byte `json:"productId"`
+		Aleo            struct {
+			UniqueId  string `json:"uniqueId"`
+			SignerId  string `json:"signerId"`
+			ProductId string `json:"productId"`
+		} `json:"aleo"`
+	} `json:"info"`
+	SignerPubKey string `json:"signerPubKey"`
+}
+
+// NitroDocument is the decoded Nitro attestation document in a Nitro /info document
+type NitroDocument struct {
+	ModuleID    string            `json:"moduleID"`
+	Timestamp   uint64            `json:"timestamp"`
+	Digest      string            `json:"digest"`
+	PCRs        map[string][]byte `json:"pcrs"`
+	Certificate []byte            `json:"certificate"`
+	CABundle    [][]byte          `json:"cabundle"`
+	PublicKey   []byte            `json:"publicKey"`
+	UserData    []byte            `json:"userData"`
+	Nonce       []byte            `json:"nonce"`
+}
+
+// NitroInfo is the /info document of a Nitro notarization backend. It carries the backend's own attestation document,
+// split into the decoded document, the protected COSE header and the signature.
+type NitroInfo struct {
+	ReportType string `json:"reportType"`
+	Info       struct {
+		Document      NitroDocument `json:"document"`
+		ProtectedCose []byte        `json:"protectedCose"`
+		Signature     []byte        `json:"signature"`
+		Aleo          struct {
+			Pcrs     string `json:"pcrs"`
+			UserData string `json:"userData"`
+		} `json:"aleo"`
+	} `json:"info"`
+	SignerPubKey string `json:"signerPubKey"`
+}
+
+// the attestation document payload as encoded by the Nitro Secure Module, the field order matters for the signature
+type nitroPayload struct {
+	ModuleID    string          `cbor:"module_id"`
+	Digest      string          `cbor:"digest"`
+	Timestamp   uint64          `cbor:"timestamp"`
+	PCRs        cbor.RawMessage `cbor:"pcrs"`
+	Certificate []byte          `cbor:"certificate"`
+	CABundle    [][]byte        `cbor:"cabundle"`
+	PublicKey   []byte          `cbor:"public_key"`
+	UserData    []byte          `cbor:"user_data"`
+	Nonce       []byte          `cbor:"nonce"`
+}
+
+func fetch(ctx context.Context, url string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: time.Second * 30,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("backendinfo: %s responded with %d", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxInfoSize))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// FetchSgxInfo requests the /info document of an SGX notarization backend
+func FetchSgxInfo(ctx context.Context, url string) (*SgxInfo, error) {
+	info := new(SgxInfo)
+	if err := fetch(ctx, url, info); err != nil {
+		return nil, err
+	}
+
+	if info.ReportType != attestation.TEE_TYPE_SGX {
+		return nil, fmt.Errorf("%w: expected %s, got %q", ErrUnexpectedReportType, attestation.TEE_TYPE_SGX, info.ReportType)
+	}
+
+	return info, nil
+}
+
+// FetchNitroInfo requests the /info document of a Nitro notarization backend
+func FetchNitroInfo(ctx context.Context, url string) (*NitroInfo, error) {
+	info := new(NitroInfo)
+	if err := fetch(ctx, url, info); err != nil {
+		return nil, err
+	}
+
+	if info.ReportType != attestation.TEE_TYPE_NITRO {
+		return nil, fmt.Errorf("%w: expected %s, got %q", ErrUnexpectedReportType, attestation.TEE_TYPE_NITRO, info.ReportType)
+	}
+
+	return info, nil
+}
+
+// UniqueId returns the hex-encoded SGX unique ID. The SGX /info document has no self-report, so it's only
+// checked for consistency.
+func (i *SgxInfo) UniqueId() (string, error) {
+	if i.Info.Debug {
+		return "", ErrDebugEnclave
+	}
+
+	if len(i.Info.UniqueId) != uniqueIdLength {
+		return "", fmt.Errorf("%w: unique ID must be %d bytes", ErrMalformedInfo, uniqueIdLength)
+	}
+
+	return hex.EncodeToString(i.Info.UniqueId), nil
+}
+
+// Report re-encodes the attestation document as the COSE_Sign1 structure that was signed by the Nitro Secure Module
+func (i *NitroInfo) Report() ([]byte, error) {
+	document := &i.Info.Document
+
+	pcrs := make(map[uint64][]byte, len(document.PCRs))
+	for idx, value := range document.PCRs {
+		pcrIdx, err := strconv.ParseUint(idx, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid PCR index %q", ErrMalformedInfo, idx)
+		}
+		pcrs[pcrIdx] = value
+	}
+
+	canonical, err := cbor.CanonicalEncOptions().EncMode()
+	if err != nil {
+		return nil, err
+	}
+
+	encodedPcrs, err := canonical.Marshal(pcrs)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := cbor.Marshal(&nitroPayload{
+		ModuleID:    document.ModuleID,
+		Digest:      document.Digest,
+		Timestamp:   document.Timestamp,
+		PCRs:        encodedPcrs,
+		Certificate: document.Certificate,
+		CABundle:    document.CABundle,
+		PublicKey:   document.PublicKey,
+		UserData:    document.UserData,
+		Nonce:       document.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cbor.Marshal([]interface{}{i.Info.ProtectedCose, map[interface{}]interface{}{}, payload, i.Info.Signature})
+}
+
+// PcrValues verifies the self-report of the Nitro /info document and returns the hex-encoded PCR values 0-2.
+// Requires an initialized Nitro verifier.
+func (i *NitroInfo) PcrValues() ([3]string, error) {
+	var pcrValues [3]string
+
+	report, err := i.Report()
+	if err != nil {
+		return pcrValues, err
+	}
+
+	document, err := nitro.VerifyDocument(report)
+	if err != nil {
+		return pcrValues, fmt.Errorf("%w: %w", ErrSelfReportMismatch, err)
+	}
+
+	for idx := range pcrValues {
+		pcr := document.PCRs[uint(idx)]
+		if len(pcr) != pcrValueLength {
+			return pcrValues, fmt.Errorf("%w: PCR %d must be %d bytes", ErrMalformedInfo, idx, pcrValueLength)
+		}
+
+		// the document was reconstructed from the info, this guards against a changed encoding
+		if !bytes.Equal(pcr, i.Info.Document.PCRs[strconv.Itoa(idx)]) {
+			return pcrValues, ErrSelfReportMismatch
+		}
+
+		pcrValues[idx] = hex.EncodeToString(pcr)
+	}
+
+	return pcrValues, nil
+}
@@ -0,0 +1,109 @@
+package backendinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+)
+
+const testSgxInfo = `{
+  "reportType": "sgx",
+  "info": {
+    "securityVersion": 1,
+    "debug": false,
+    "uniqueId": "RGpRmz/zATF9erKm0HQFGHjCPDRbP4XnbbxpFBMJq/w=",
+    "signerId": "9H4s7YPOeZFug8XZRRRlc+Z7Vfit98IfkZsrDpb+Dxs=",
+    "productId": "AQAAAAAAAAAAAAAAAAAAAA==",
+    "aleoProductId": "1u128"
+  },
+  "signerPubKey": "aleo1skjdmt9s743jlgf378n38hud4jdnmf4tafsymsj8ta2hqmcc5qxqeuersv"
+}`
+
+// creates a stand-in notarization backend serving the info document
+func newInfoServer(t *testing.T, info []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(info)
+	}))
+}
+
+func TestFetchSgxInfo(t *testing.T) {
+	server := newInfoServer(t, []byte(testSgxInfo))
+	defer server.Close()
+
+	info, err := FetchSgxInfo(context.Background(), server.URL+"/info")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uniqueId, err := info.UniqueId()
+	if err != nil || uniqueId != "446a519b3ff301317d7ab2a6d074051878c23c345b3f85e76dbc69141309abfc" {
+		t.Errorf("UniqueId() = %v, %v", uniqueId, err)
+	}
+
+	info.Info.Debug = true
+	if _, err := info.UniqueId(); err != ErrDebugEnclave {
+		t.Errorf("UniqueId() error = %v, want %v", err, ErrDebugEnclave)
+	}
+
+	if _, err := FetchNitroInfo(context.Background(), server.URL+"/info"); !errors.Is(err, ErrUnexpectedReportType) {
+		t.Errorf("FetchNitroInfo() error = %v, want %v", err, ErrUnexpectedReportType)
+	}
+
+	if _, err := FetchSgxInfo(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("FetchSgxInfo() succeeded for a missing document")
+	}
+}
+
+func TestNitroInfoPcrValues(t *testing.T) {
+	if err := nitro.Init("", nitro.VERIFICATION_TIME_ATTESTATION, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	infoJson, err := os.ReadFile("testdata/nitro_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newInfoServer(t, infoJson)
+	defer server.Close()
+
+	info, err := FetchNitroInfo(context.Background(), server.URL+"/info")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pcrValues, err := info.PcrValues()
+	if err != nil {
+		t.Fatalf("PcrValues() error = %v", err)
+	}
+
+	want := [3]string{
+		"89f64b1a8a814344d6fe782b28352bd7d6b1f875850dbe381a5224281baf71cccf7c12cee9b921ad394e0f7a302267e0",
+		"0343b056cd8485ca7890ddd833476d78460aed2aa161548e4e26bedf321726696257d623e8805f3f605946b3d8b0c6aa",
+		"11e1669e4aa0950351e29cfbbe56bed210f197c015dc795bf99c805619089686af903410c41e5c2562516f175a8b1ca5",
+	}
+	if pcrValues != want {
+		t.Errorf("PcrValues() = %v, want %v", pcrValues, want)
+	}
+
+	// a document that was changed after signing
+	tampered := new(NitroInfo)
+	if err := json.Unmarshal(infoJson, tampered); err != nil {
+		t.Fatal(err)
+	}
+	tampered.Info.Document.PCRs["0"][0] ^= 1
+
+	if _, err := tampered.PcrValues(); !errors.Is(err, ErrSelfReportMismatch) {
+		t.Errorf("PcrValues() of a tampered document error = %v, want %v", err, ErrSelfReportMismatch)
+	}
+}
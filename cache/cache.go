@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Stats describes the cache usage
+type Stats struct {
+	Size     int     `json:"size"`
+	Capacity int     `json:"capacity"`
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hitRatio"`
+}
+
+// StatsProvider is implemented by caches that can report their usage
+type StatsProvider interface {
+	Stats() Stats
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// LRU is a bounded cache that evicts the least recently used entries.
+// Entries expire after the TTL, so that time-dependent values aren't reused forever. It is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	capacity int
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List
+	hits    uint64
+	misses  uint64
+}
+
+// NewLRU creates a cache of up to capacity entries that expire after the TTL, 0 means the entries don't expire
+func NewLRU[K comparable, V any](capacity int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value stored under key if it hasn't expired at now
+func (c *LRU[K, V]) Get(key K, now time.Time) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok && c.ttl > 0 && !now.Before(elem.Value.(*entry[K, V]).expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*entry[K, V]).value, true
+}
+
+// Add stores value under key until the TTL after now, evicting the least recently used entry if the
+// cache is full
+func (c *LRU[K, V]) Add(key K, value V, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := now.Add(c.ttl)
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*entry[K, V]).value = value
+		elem.Value.(*entry[K, V]).expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.capacity <= 0 {
+		return
+	}
+
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[K, V]).key)
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+}
+
+// Purge removes all entries
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+func (c *LRU[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{
+		Size:     c.order.Len(),
+		Capacity: c.capacity,
+		Hits:     c.hits,
+		Misses:   c.misses,
+	}
+
+	if total := c.hits + c.misses; total != 0 {
+		stats.HitRatio = float64(c.hits) / float64(total)
+	}
+
+	return stats
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	c := NewLRU[string, int](2, 0)
+	now := time.Now()
+
+	c.Add("a", 1, now)
+	c.Add("b", 2, now)
+
+	if v, ok := c.Get("a", now); !ok || v != 1 {
+		t.Errorf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+
+	// "b" is the least recently used now
+	c.Add("c", 3, now)
+	if _, ok := c.Get("b", now); ok {
+		t.Error("Get(b) found an evicted entry")
+	}
+	if v, ok := c.Get("c", now); !ok || v != 3 {
+		t.Errorf("Get(c) = %v, %v, want 3, true", v, ok)
+	}
+
+	stats := c.Stats()
+	want := Stats{Size: 2, Capacity: 2, Hits: 2, Misses: 1, HitRatio: 2.0 / 3}
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	c := NewLRU[string, int](2, time.Minute)
+	now := time.Now()
+
+	c.Add("a", 1, now)
+
+	if v, ok := c.Get("a", now.Add(time.Minute-time.Second)); !ok || v != 1 {
+		t.Errorf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+	if _, ok := c.Get("a", now.Add(time.Minute)); ok {
+		t.Error("Get(a) found an expired entry")
+	}
+
+	// an expired entry is removed
+	if size := c.Stats().Size; size != 0 {
+		t.Errorf("Stats().Size = %d, want 0", size)
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+)
+
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{}
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+func register(name, description string, run func(args []string) error) {
+	commands[name] = command{
+		description: description,
+		run:         run,
+	}
+}
+
+// Run runs the command with the arguments
+func Run(name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		Usage()
+		return ErrUnknownCommand
+	}
+
+	return cmd.run(args)
+}
+
+// Usage prints the list of the available commands
+func Usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "Usage: %s [command] [arguments]\n\nRuns the HTTP server if no command is given.\n\nCommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-24s %s\n", name, commands[name].description)
+	}
+}
+
+func loadConfig(path string) (*config.Configuration, error) {
+	confContent, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.LoadConfig(confContent)
+}
+
+// the first 3 configured PCR values, missing values are left empty
+func targetPcrValues(pcrValuesTarget []string) [3]string {
+	var targetPcrs [3]string
+	for i := 0; i < 3 && i < len(pcrValuesTarget); i++ {
+		targetPcrs[i] = pcrValuesTarget[i]
+	}
+
+	return targetPcrs
+}
+
+// creates the Aleo node API client for a live check configuration
+func nodeClient(liveCheck *config.LiveCheck) (*node.Client, error) {
+	return node.NewClient(liveCheck.ApiBaseUrls(), node.Options{
+		Headers:    liveCheck.ApiHeaders,
+		MaxRetries: liveCheck.MaxRetries,
+	})
+}
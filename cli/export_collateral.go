@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/collateral"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+)
+
+const exportCollateralTimeout = 60 * time.Second
+
+func init() {
+	register("export-collateral", "Export the SGX or TDX collateral of a platform for offline verification", exportCollateral)
+}
+
+// reads a report from a file, base64-encoded like attestationReport or binary
+func readReportFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(content))); err == nil {
+		return decoded, nil
+	}
+
+	return content, nil
+}
+
+func exportCollateral(args []string) error {
+	flags := flag.NewFlagSet("export-collateral", flag.ExitOnError)
+	serviceUrl := flags.String("service", collateral.DEFAULT_SERVICE_URL, "URL of the PCS or PCCS collateral service")
+	tdxServiceUrl := flags.String("tdx-service", collateral.DEFAULT_TDX_SERVICE_URL, "URL of the PCS or PCCS TDX collateral service")
+	rootCaCrlUrl := flags.String("root-ca-crl", collateral.DEFAULT_ROOT_CA_CRL_URL, "URL of the root CA CRL")
+	outDir := flags.String("out", "collateral", "collateral cache directory to write to")
+	reportPath := flags.String("report", "", "file with an SGX report or a TD quote of the platform, base64-encoded or binary")
+	fmspc := flags.String("fmspc", "", "FMSPC of the platform, hex-encoded, instead of a report")
+	ca := flags.String("ca", collateral.PCK_CA_PROCESSOR, "PCK CA of the platform, \"processor\" or \"platform\", when using -fmspc")
+	tdx := flags.Bool("tdx", false, "export the TDX collateral of the platform when using -fmspc, implied by a TD quote")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: export-collateral [-service url] [-tdx-service url] [-root-ca-crl url] [-out dir] (-report file | -fmspc hex [-ca name] [-tdx])")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if (*reportPath == "") == (*fmspc == "") {
+		flags.Usage()
+		return errors.New("expected either a report or an FMSPC")
+	}
+
+	if *reportPath != "" {
+		report, err := readReportFile(*reportPath)
+		if err != nil {
+			return err
+		}
+
+		pck, err := collateral.ReadPckCertificate(report)
+		if err != nil {
+			return err
+		}
+
+		*fmspc, *ca = pck.Fmspc, pck.CA
+		*tdx = quote.IsTd(report)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportCollateralTimeout)
+	defer cancel()
+
+	cache := collateral.NewCache(*outDir)
+
+	var bundle *collateral.Collateral
+	var err error
+	if *tdx {
+		bundle, err = collateral.FetchTd(ctx, http.DefaultClient, *tdxServiceUrl, *serviceUrl, *rootCaCrlUrl, *fmspc, *ca)
+		if err == nil {
+			err = cache.StoreTd(*fmspc, bundle)
+		}
+	} else {
+		bundle, err = collateral.Fetch(ctx, http.DefaultClient, *serviceUrl, *rootCaCrlUrl, *fmspc, *ca)
+		if err == nil {
+			err = cache.Store(*fmspc, bundle)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	rootCa, err := collateral.ReadRootCa(bundle)
+	if err != nil {
+		return err
+	}
+
+	kind := "SGX"
+	if *tdx {
+		kind = "TDX"
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported the %s collateral of FMSPC %s to %s\n", kind, *fmspc, *outDir)
+	fmt.Fprintf(os.Stderr, "Root CA: %s, SHA-256 fingerprint %s\n", rootCa.Subject, collateral.Fingerprint(rootCa))
+	if err := collateral.NewRootCaPin("").Check(rootCa); err != nil {
+		fmt.Fprintln(os.Stderr, "WARNING: the root CA isn't the Intel SGX root CA, the collateral is rejected unless sgxCollateral.rootCaFingerprint pins it")
+	}
+
+	return nil
+}
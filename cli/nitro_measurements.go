@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro/eif"
+)
+
+var ErrPcrValuesMismatch = errors.New("the enclave image PCR values don't match the configured pcrValuesTarget")
+
+func init() {
+	register("nitro-measurements", "Compute the Nitro PCR values of an enclave image file (EIF)", nitroMeasurements)
+}
+
+// same encodings as the target PCR values in /info
+type pcrValuesEncodings struct {
+	Hex    [3]string `json:"hexEncoded"`
+	Base64 [3]string `json:"base64Encoded"`
+	Aleo   string    `json:"aleoEncoded"`
+}
+
+type pcrEncodings struct {
+	Hex    string `json:"hexEncoded"`
+	Base64 string `json:"base64Encoded"`
+}
+
+type nitroMeasurementsOutput struct {
+	PcrValues pcrValuesEncodings `json:"pcrValues"`
+	// the PCR of the signing certificate, only if the image is signed
+	Pcr8 *pcrEncodings `json:"pcr8,omitempty"`
+}
+
+func nitroMeasurements(args []string) error {
+	flags := flag.NewFlagSet("nitro-measurements", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to a configuration file to compare the PCR values with pcrValuesTarget")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: nitro-measurements [-config path] <enclave image file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected an enclave image file")
+	}
+
+	measurements, err := eif.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	output := new(nitroMeasurementsOutput)
+
+	var pcrBytes [3][48]byte
+	for idx, value := range measurements.PcrValues {
+		pcrBytes[idx] = value
+		output.PcrValues.Hex[idx] = hex.EncodeToString(value[:])
+		output.PcrValues.Base64[idx] = base64.StdEncoding.EncodeToString(value[:])
+	}
+	output.PcrValues.Aleo = nitro.FormatPcrValues(pcrBytes)
+
+	if measurements.Signed {
+		output.Pcr8 = &pcrEncodings{
+			Hex:    hex.EncodeToString(measurements.Pcr8[:]),
+			Base64: base64.StdEncoding.EncodeToString(measurements.Pcr8[:]),
+		}
+	}
+
+	encoded, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(encoded))
+
+	if *configPath == "" {
+		return nil
+	}
+
+	conf, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if len(conf.PcrValuesTarget) != len(output.PcrValues.Hex) || targetPcrValues(conf.PcrValuesTarget) != output.PcrValues.Hex {
+		return fmt.Errorf("%w: configured %v", ErrPcrValuesMismatch, conf.PcrValuesTarget)
+	}
+
+	fmt.Fprintln(os.Stderr, "The enclave image PCR values match pcrValuesTarget in", *configPath)
+
+	return nil
+}
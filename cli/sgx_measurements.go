@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/sigstruct"
+)
+
+var ErrUniqueIdMismatch = errors.New("the enclave unique ID doesn't match the configured uniqueIdTarget")
+
+func init() {
+	register("sgx-measurements", "Read the SGX measurements from an enclave binary signed with EGo", sgxMeasurements)
+}
+
+// same encodings as the target unique ID in /info
+type measurementEncodings struct {
+	Hex    string `json:"hexEncoded"`
+	Base64 string `json:"base64Encoded"`
+	Aleo   string `json:"aleoEncoded"`
+}
+
+type sgxMeasurementsOutput struct {
+	UniqueId        measurementEncodings `json:"uniqueId"`
+	SignerId        measurementEncodings `json:"signerId"`
+	ProductId       uint16               `json:"productId"`
+	AleoProductId   string               `json:"aleoProductId"`
+	SecurityVersion uint16               `json:"securityVersion"`
+	Debug           bool                 `json:"debug"`
+}
+
+func encodeMeasurement(measurement [32]byte) measurementEncodings {
+	return measurementEncodings{
+		Hex:    hex.EncodeToString(measurement[:]),
+		Base64: base64.StdEncoding.EncodeToString(measurement[:]),
+		Aleo:   sgx.FormatMeasurement(measurement),
+	}
+}
+
+func sgxMeasurements(args []string) error {
+	flags := flag.NewFlagSet("sgx-measurements", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to a configuration file to compare the unique ID with uniqueIdTarget")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: sgx-measurements [-config path] <signed enclave binary>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected a signed enclave binary")
+	}
+
+	measurements, err := sigstruct.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	output := &sgxMeasurementsOutput{
+		UniqueId:        encodeMeasurement(measurements.UniqueId),
+		SignerId:        encodeMeasurement(measurements.SignerId),
+		ProductId:       measurements.ProductId,
+		AleoProductId:   fmt.Sprintf("%du128", measurements.ProductId),
+		SecurityVersion: measurements.SecurityVersion,
+		Debug:           measurements.Debug,
+	}
+
+	encoded, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(encoded))
+
+	if measurements.Debug {
+		fmt.Fprintln(os.Stderr, "WARNING: the enclave is signed for debug mode, its reports are rejected by the verifier")
+	}
+
+	if *configPath == "" {
+		return nil
+	}
+
+	conf, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if conf.UniqueIdTarget != output.UniqueId.Hex {
+		return fmt.Errorf("%w: configured %s", ErrUniqueIdMismatch, conf.UniqueIdTarget)
+	}
+
+	fmt.Fprintln(os.Stderr, "The enclave unique ID matches uniqueIdTarget in", *configPath)
+
+	return nil
+}
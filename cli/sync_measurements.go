@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+	"github.com/venture23-aleo/oracle-verification-backend/backendinfo"
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+	"github.com/venture23-aleo/oracle-verification-backend/contract"
+)
+
+var ErrConfigurationOutdated = errors.New("the configured measurements differ from the notarization backend, use -write to update them")
+
+func init() {
+	register("sync-measurements", "Fetch the notarization backend measurements and diff or write the configuration", syncMeasurements)
+}
+
+func hexToBase64(value string) string {
+	buf, _ := hex.DecodeString(value)
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+func syncMeasurements(args []string) error {
+	flags := flag.NewFlagSet("sync-measurements", flag.ExitOnError)
+	configPath := flags.String("config", "config.json", "path to the configuration file")
+	sgxInfoUrl := flags.String("sgx-info-url", backendinfo.DEFAULT_SGX_INFO_URL, "URL of the SGX notarization backend /info")
+	nitroInfoUrl := flags.String("nitro-info-url", backendinfo.DEFAULT_NITRO_INFO_URL, "URL of the Nitro notarization backend /info")
+	skipLiveCheck := flags.Bool("skip-live-check", false, "don't cross-check the measurements with the ones in the Aleo program")
+	write := flags.Bool("write", false, "write the measurements to the configuration file instead of showing the differences")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: sync-measurements [-config path] [-sgx-info-url url] [-nitro-info-url url] [-skip-live-check] [-write]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	confContent, err := os.ReadFile(*configPath)
+	if err != nil {
+		return err
+	}
+
+	conf, err := config.LoadConfig(confContent)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	sgxInfo, err := backendinfo.FetchSgxInfo(ctx, *sgxInfoUrl)
+	if err != nil {
+		return err
+	}
+
+	uniqueId, err := sgxInfo.UniqueId()
+	if err != nil {
+		return err
+	}
+
+	if err := nitro.Init(conf.Nitro.RootCaPath, conf.Nitro.VerificationTime, time.Duration(conf.Nitro.GraceSeconds)*time.Second); err != nil {
+		return err
+	}
+
+	nitroInfo, err := backendinfo.FetchNitroInfo(ctx, *nitroInfoUrl)
+	if err != nil {
+		return err
+	}
+
+	pcrValues, err := nitroInfo.PcrValues()
+	if err != nil {
+		return err
+	}
+
+	if !*skipLiveCheck {
+		client, err := nodeClient(&conf.LiveCheck)
+		if err != nil {
+			return err
+		}
+
+		liveUniqueId, err := contract.GetSgxUniqueIDAssert(ctx, client, conf.LiveCheck.ContractName, conf.LiveCheck.MappingUrlTemplate, conf.LiveCheck.SgxUniqueIdMappingName, conf.LiveCheck.SgxUniqueIdMappingKey)
+		if err != nil {
+			return err
+		}
+
+		if liveUniqueId != uniqueId {
+			return fmt.Errorf("notarization backend SGX unique ID %s doesn't match %s in %s", uniqueId, liveUniqueId, conf.LiveCheck.ContractName)
+		}
+
+		livePcrValues, err := contract.GetNitroPcrValuesAssert(ctx, client, conf.LiveCheck.ContractName, conf.LiveCheck.MappingUrlTemplate, conf.LiveCheck.NitroPcrValuesMappingName, conf.LiveCheck.NitroPcrValuesMappingKey)
+		if err != nil {
+			return err
+		}
+
+		if !slices.Equal(livePcrValues, pcrValues[:]) {
+			return fmt.Errorf("notarization backend Nitro PCR values %v don't match %v in %s", pcrValues, livePcrValues, conf.LiveCheck.ContractName)
+		}
+	}
+
+	differences := 0
+
+	if conf.UniqueIdTarget != uniqueId {
+		fmt.Printf("uniqueIdTarget:\n  - %s\n  + %s\n", conf.UniqueIdTarget, uniqueId)
+		differences++
+	}
+
+	configuredPcrValues := targetPcrValues(conf.PcrValuesTarget)
+	for idx := range pcrValues {
+		if configuredPcrValues[idx] != pcrValues[idx] {
+			fmt.Printf("pcrValuesTarget[%d]:\n  - %s\n  + %s\n", idx, configuredPcrValues[idx], pcrValues[idx])
+			differences++
+		}
+	}
+
+	if differences == 0 && len(conf.PcrValuesTarget) == len(pcrValues) {
+		fmt.Println("The configured measurements are up to date")
+		return nil
+	}
+
+	if !*write {
+		return ErrConfigurationOutdated
+	}
+
+	encodedPcrValues := make([]string, 0, len(pcrValues))
+	for _, pcr := range pcrValues {
+		encodedPcrValues = append(encodedPcrValues, hexToBase64(pcr))
+	}
+
+	updated, err := config.SetMeasurementTargets(confContent, hexToBase64(uniqueId), encodedPcrValues)
+	if err != nil {
+		return err
+	}
+
+	if _, err := config.LoadConfig(updated); err != nil {
+		return err
+	}
+
+	stat, err := os.Stat(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*configPath, updated, stat.Mode().Perm()); err != nil {
+		return err
+	}
+
+	fmt.Println("Updated the measurements in", *configPath)
+
+	return nil
+}
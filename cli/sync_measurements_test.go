@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/venture23-aleo/oracle-verification-backend/backendinfo"
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+)
+
+const testSgxInfo = `{
+  "reportType": "sgx",
+  "info": {
+    "securityVersion": 1,
+    "debug": false,
+    "uniqueId": "RGpRmz/zATF9erKm0HQFGHjCPDRbP4XnbbxpFBMJq/w=",
+    "signerId": "9H4s7YPOeZFug8XZRRRlc+Z7Vfit98IfkZsrDpb+Dxs=",
+    "productId": "AQAAAAAAAAAAAAAAAAAAAA=="
+  },
+  "signerPubKey": "aleo1skjdmt9s743jlgf378n38hud4jdnmf4tafsymsj8ta2hqmcc5qxqeuersv"
+}`
+
+const testConfig = `{
+  "port": 8080,
+  "uniqueIdTarget": "",
+  "pcrValuesTarget": [],
+  "liveCheck": {
+    "apiBaseUrl": "%NODE%",
+    "contractName": "official_oracle.aleo",
+    "mappingUrlTemplate": "{apiBaseUrl}/program/{contractName}/mapping/{mappingName}/{mappingKey}",
+    "sgxUniqueIdMappingName": "sgx_unique_id",
+    "sgxUniqueIdMappingKey": "0u8",
+    "nitroPcrValuesMappingName": "nitro_pcr_values",
+    "nitroPcrValuesMappingKey": "0u8",
+    "maxRetries": -1
+  }
+}
+`
+
+// creates stand-ins for the notarization backends and the Aleo node with the given on-chain unique ID
+func newStandInServers(t *testing.T, liveUniqueId string) (*httptest.Server, *httptest.Server, *httptest.Server) {
+	nitroInfoJson, err := os.ReadFile("../backendinfo/testdata/nitro_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nitroInfo := new(backendinfo.NitroInfo)
+	if err := json.Unmarshal(nitroInfoJson, nitroInfo); err != nil {
+		t.Fatal(err)
+	}
+
+	sgx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testSgxInfo))
+	}))
+	nitro := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(nitroInfoJson)
+	}))
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/program/official_oracle.aleo/mapping/sgx_unique_id/0u8":
+			json.NewEncoder(w).Encode(liveUniqueId)
+		case "/program/official_oracle.aleo/mapping/nitro_pcr_values/0u8":
+			json.NewEncoder(w).Encode(nitroInfo.Info.Aleo.Pcrs)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return sgx, nitro, node
+}
+
+func Test_syncMeasurements(t *testing.T) {
+	const uniqueIdStruct = "{ chunk_1: 31929802673692760512905395015836068420u128, chunk_2: 335853521753947303372057454886636012152u128 }"
+
+	sgx, nitro, node := newStandInServers(t, uniqueIdStruct)
+	defer sgx.Close()
+	defer nitro.Close()
+	defer node.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(strings.Replace(testConfig, "%NODE%", node.URL, 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"-config", configPath, "-sgx-info-url", sgx.URL, "-nitro-info-url", nitro.URL}
+
+	if err := syncMeasurements(args); err != ErrConfigurationOutdated {
+		t.Fatalf("syncMeasurements() error = %v, want %v", err, ErrConfigurationOutdated)
+	}
+
+	if err := syncMeasurements(append(args, "-write")); err != nil {
+		t.Fatalf("syncMeasurements() with -write error = %v", err)
+	}
+
+	confContent, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := config.LoadConfig(confContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.UniqueIdTarget != "446a519b3ff301317d7ab2a6d074051878c23c345b3f85e76dbc69141309abfc" {
+		t.Errorf("written uniqueIdTarget = %s", conf.UniqueIdTarget)
+	}
+	if len(conf.PcrValuesTarget) != 3 || conf.PcrValuesTarget[1] != "0343b056cd8485ca7890ddd833476d78460aed2aa161548e4e26bedf321726696257d623e8805f3f605946b3d8b0c6aa" {
+		t.Errorf("written pcrValuesTarget = %v", conf.PcrValuesTarget)
+	}
+
+	// the configuration is now up to date
+	if err := syncMeasurements(args); err != nil {
+		t.Errorf("syncMeasurements() after -write error = %v", err)
+	}
+}
+
+func Test_syncMeasurementsLiveCheckMismatch(t *testing.T) {
+	sgx, nitro, node := newStandInServers(t, "{ chunk_1: 1u128, chunk_2: 2u128 }")
+	defer sgx.Close()
+	defer nitro.Close()
+	defer node.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := []byte(strings.Replace(testConfig, "%NODE%", node.URL, 1))
+	if err := os.WriteFile(configPath, original, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := syncMeasurements([]string{"-config", configPath, "-sgx-info-url", sgx.URL, "-nitro-info-url", nitro.URL, "-write"})
+	if err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Fatalf("syncMeasurements() error = %v, want an on-chain mismatch", err)
+	}
+
+	confContent, _ := os.ReadFile(configPath)
+	if string(confContent) != string(original) {
+		t.Error("syncMeasurements() changed the configuration despite the on-chain mismatch")
+	}
+
+	if err := syncMeasurements([]string{"-config", configPath, "-sgx-info-url", sgx.URL, "-nitro-info-url", nitro.URL, "-skip-live-check", "-write"}); err != nil {
+		t.Errorf("syncMeasurements() with -skip-live-check error = %v", err)
+	}
+}
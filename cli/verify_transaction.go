@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tdx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+	"github.com/venture23-aleo/oracle-verification-backend/transaction"
+)
+
+func init() {
+	register("verify-transaction", "Verify the oracle updates in an Aleo transaction", verifyTransaction)
+}
+
+func verifyTransaction(args []string) error {
+	flags := flag.NewFlagSet("verify-transaction", flag.ExitOnError)
+	configPath := flags.String("config", "config.json", "path to the configuration file")
+	profileName := flags.String("profile", config.DEFAULT_PROFILE_NAME, "configuration profile of the network and program")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: verify-transaction [-config path] [-profile name] <transaction ID>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected a transaction ID")
+	}
+	transactionId := flags.Arg(0)
+
+	conf, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	profile, ok := conf.Profile(*profileName)
+	if !ok {
+		return fmt.Errorf("unknown profile %q", *profileName)
+	}
+
+	if err := nitro.Init(conf.Nitro.RootCaPath, conf.Nitro.VerificationTime, time.Duration(conf.Nitro.GraceSeconds)*time.Second); err != nil {
+		return err
+	}
+
+	sgx.Init(conf.SgxCollateral.CacheDir, conf.SgxCollateral.RootCaFingerprint, conf.SgxCollateral.AllowExpired)
+	tdx.Init(conf.SgxCollateral.CacheDir, conf.SgxCollateral.RootCaFingerprint, conf.SgxCollateral.AllowExpired)
+
+	policies, err := tee.ProfilePolicies(profile.PolicyBlocks())
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", profile.Name, err)
+	}
+
+	aleoWrapper, closeFn, err := aleo.NewWrapper()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	// the oracle update is recovered in Go, only hashed in WASM
+	aleoSession, err := aleo.NewNativeWrapper(aleoWrapper).NewSession()
+	if err != nil {
+		return err
+	}
+	defer aleoSession.Close()
+
+	client, err := nodeClient(&profile.LiveCheck)
+	if err != nil {
+		return err
+	}
+
+	tx, err := transaction.Fetch(context.Background(), client, transactionId)
+	if err != nil {
+		return err
+	}
+
+	updates := transaction.ExtractOracleUpdates(tx, profile.LiveCheck.ContractName)
+	if len(updates) == 0 {
+		return transaction.ErrNoOracleUpdate
+	}
+
+	results := make([]*transaction.VerificationResult, 0, len(updates))
+	valid := true
+	for idx := range updates {
+		result := transaction.VerifyOracleUpdate(aleoSession, tx.Id, &updates[idx], policies)
+		valid = valid && result.Valid
+		results = append(results, result)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		return err
+	}
+
+	if !valid {
+		return errors.New("some oracle updates failed verification")
+	}
+
+	return nil
+}
@@ -2,47 +2,66 @@ package common
 
 import "github.com/venture23-aleo/oracle-verification-backend/constants"
 
+// a token with a price feed
+type priceFeedToken struct {
+	url    string
+	id     int
+	symbol string
+}
+
+// the tokens with a price feed, by the price feed URL and the token ID stored in the meta header
+var priceFeedTokens = []priceFeedToken{
+	{url: constants.PriceFeedBTCURL, id: constants.BTCTokenID, symbol: "BTC"},
+	{url: constants.PriceFeedETHURL, id: constants.ETHTokenID, symbol: "ETH"},
+	{url: constants.PriceFeedAleoURL, id: constants.AleoTokenID, symbol: "ALEO"},
+	{url: constants.PriceFeedUSDTURL, id: constants.USDTTokenID, symbol: "USDT"},
+	{url: constants.PriceFeedUSDCURL, id: constants.USDCTokenID, symbol: "USDC"},
+}
+
+const unknownTokenSymbol = "UNKNOWN"
+
+func findPriceFeedToken(match func(token priceFeedToken) bool) (priceFeedToken, bool) {
+	for _, token := range priceFeedTokens {
+		if match(token) {
+			return token, true
+		}
+	}
+
+	return priceFeedToken{}, false
+}
+
 // IsPriceFeedURL checks if the URL is a price feed URL.
 func IsPriceFeedURL(url string) bool {
-	return url == constants.PriceFeedBTCURL ||
-		url == constants.PriceFeedETHURL ||
-		url == constants.PriceFeedAleoURL || 
-		url == constants.PriceFeedUSDTURL ||
-		url == constants.PriceFeedUSDCURL
+	_, ok := findPriceFeedToken(func(token priceFeedToken) bool { return token.url == url })
+	return ok
 }
 
 // ExtractAssetFromPriceFeedURL extracts the asset name from price feed URL
 func ExtractTokenFromPriceFeedURL(url string) string {
-	switch url {
-	case constants.PriceFeedBTCURL:
-		return "BTC"
-	case constants.PriceFeedETHURL:
-		return "ETH"
-	case constants.PriceFeedAleoURL:
-		return "ALEO"
-	case constants.PriceFeedUSDTURL:
-		return "USDT"
-	case constants.PriceFeedUSDCURL:
-		return "USDC"
-	default:
-		return "UNKNOWN"
+	token, ok := findPriceFeedToken(func(token priceFeedToken) bool { return token.url == url })
+	if !ok {
+		return unknownTokenSymbol
 	}
+
+	return token.symbol
 }
 
 // GetTokenIDFromPriceFeedURL gets the token ID from price feed URL
 func GetTokenIDFromPriceFeedURL(url string) int {
-	switch url {
-	case constants.PriceFeedBTCURL:
-		return constants.BTCTokenID
-	case constants.PriceFeedETHURL:
-		return constants.ETHTokenID
-	case constants.PriceFeedAleoURL:
-		return constants.AleoTokenID
-	case constants.PriceFeedUSDTURL:
-		return constants.USDTTokenID
-	case constants.PriceFeedUSDCURL:
-		return constants.USDCTokenID
-	default:
+	token, ok := findPriceFeedToken(func(token priceFeedToken) bool { return token.url == url })
+	if !ok {
 		return 0
 	}
-}
\ No newline at end of file
+
+	return token.id
+}
+
+// GetTokenSymbolFromTokenID gets the token symbol from the token ID stored in the meta header
+func GetTokenSymbolFromTokenID(tokenID int) string {
+	token, ok := findPriceFeedToken(func(token priceFeedToken) bool { return token.id == tokenID })
+	if !ok {
+		return unknownTokenSymbol
+	}
+
+	return token.symbol
+}
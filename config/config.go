@@ -1,6 +1,8 @@
 package config
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -8,54 +10,147 @@ import (
 	"fmt"
 	"log"
 	"strings"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+	"github.com/venture23-aleo/oracle-verification-backend/replay"
 )
 
 const expectedUniqueIdLength = 32
 const expectedPcrValueLength = 48
 const MAX_REQUEST_BODY_SIZE = 1024 * 1024 * 8 // 8MB
+const defaultNonceTtlSeconds = 300
+const defaultReplayTtlSeconds = 86400
+const defaultVerificationCacheSize = 1024
+const defaultVerificationCacheTtlSeconds = 600
+const defaultAuditorPollIntervalSeconds = 10
+const defaultAuditorHistorySize = 1000
+const defaultStartupRetryIntervalSeconds = 30
 
-type Configuration struct {
-	Port            uint16   `json:"port"`
-	UseTls          bool     `json:"useTls"`
-	TlsKeyFile      string   `json:"tlsKey"`
-	TlsCertFile     string   `json:"tlsCert"`
+const (
+	// exit if the live check fails on startup
+	STARTUP_POLICY_FAIL = "fail"
+	// start without confirming the live check and keep retrying it in the background
+	STARTUP_POLICY_DEGRADED = "degraded"
+)
+
+// the name of the profile made of the top-level measurement targets and live check
+const DEFAULT_PROFILE_NAME = "default"
+
+// the report types of the policy blocks made of the live checked measurement targets
+const (
+	SGX_POLICY   = "sgx"
+	NITRO_POLICY = "nitro"
+)
+
+type LiveCheck struct {
+	Skip         bool   `json:"skip"`
+	ApiBaseUrl   string `json:"apiBaseUrl"`
+	ContractName string `json:"contractName"`
+	MappingUrlTemplate string `json:"mappingUrlTemplate"`
+	SgxUniqueIdMappingName string `json:"sgxUniqueIdMappingName"`
+	SgxUniqueIdMappingKey string `json:"sgxUniqueIdMappingKey"`
+	NitroPcrValuesMappingName string `json:"nitroPcrValuesMappingName"`
+	NitroPcrValuesMappingKey string `json:"nitroPcrValuesMappingKey"`
+	FallbackApiBaseUrls []string `json:"fallbackApiBaseUrls"`
+	ApiHeaders map[string]string `json:"apiHeaders"`
+	MaxRetries int `json:"maxRetries"`
+}
+
+// ApiBaseUrls returns the Aleo node API base URL followed by the fallback ones
+func (lc *LiveCheck) ApiBaseUrls() []string {
+	return append([]string{lc.ApiBaseUrl}, lc.FallbackApiBaseUrls...)
+}
+
+// Profile is a named set of measurement targets and the live check for a network and program
+type Profile struct {
+	Name            string   `json:"name"`
 	UniqueIdTarget  string   `json:"uniqueIdTarget"`
 	PcrValuesTarget []string `json:"pcrValuesTarget"`
-	LiveCheck       struct {
-		Skip         bool   `json:"skip"`
-		ApiBaseUrl   string `json:"apiBaseUrl"`
-		ContractName string `json:"contractName"`
-		MappingUrlTemplate string `json:"mappingUrlTemplate"`
-		SgxUniqueIdMappingName string `json:"sgxUniqueIdMappingName"`
-		SgxUniqueIdMappingKey string `json:"sgxUniqueIdMappingKey"`
-		NitroPcrValuesMappingName string `json:"nitroPcrValuesMappingName"`
-		NitroPcrValuesMappingKey string `json:"nitroPcrValuesMappingKey"`
-	} `json:"liveCheck"`
-}
-
-func validateAndNormalizeUniqueId(conf *Configuration) error {
+	// the policy blocks of the other TEEs by report type, every block is parsed by the verifier of its TEE
+	Policies  map[string]json.RawMessage `json:"policies"`
+	LiveCheck LiveCheck                  `json:"liveCheck"`
+}
+
+type Configuration struct {
+	Port            uint16    `json:"port"`
+	UseTls          bool      `json:"useTls"`
+	TlsKeyFile      string    `json:"tlsKey"`
+	TlsCertFile     string    `json:"tlsCert"`
+	UniqueIdTarget  string    `json:"uniqueIdTarget"`
+	PcrValuesTarget []string  `json:"pcrValuesTarget"`
+	LiveCheck       LiveCheck `json:"liveCheck"`
+	Profiles        []Profile `json:"profiles"`
+	Nonce struct {
+		Enabled        bool   `json:"enabled"`
+		Required       bool   `json:"required"`
+		TtlSeconds     uint   `json:"ttlSeconds"`
+		ClientIpHeader string `json:"clientIpHeader"`
+	} `json:"nonce"`
+	Replay struct {
+		Enabled    bool   `json:"enabled"`
+		Policy     string `json:"policy"`
+		Backend    string `json:"backend"`
+		Path       string `json:"path"`
+		TtlSeconds uint   `json:"ttlSeconds"`
+	} `json:"replay"`
+	VerificationCache struct {
+		Enabled    bool `json:"enabled"`
+		Size       int  `json:"size"`
+		TtlSeconds uint `json:"ttlSeconds"`
+	} `json:"verificationCache"`
+	Auditor struct {
+		Enabled             bool   `json:"enabled"`
+		StartHeight         uint64 `json:"startHeight"`
+		PollIntervalSeconds uint   `json:"pollIntervalSeconds"`
+		HistorySize         int    `json:"historySize"`
+		StorePath           string `json:"storePath"`
+		AlertWebhookUrl     string `json:"alertWebhookUrl"`
+	} `json:"auditor"`
+	Startup struct {
+		Policy               string `json:"policy"`
+		RetryIntervalSeconds uint   `json:"retryIntervalSeconds"`
+	} `json:"startup"`
+	Nitro struct {
+		RootCaPath       string `json:"rootCaPath"`
+		VerificationTime string `json:"verificationTime"`
+		GraceSeconds     uint   `json:"graceSeconds"`
+	} `json:"nitro"`
+	SgxCollateral struct {
+		CacheDir          string `json:"cacheDir"`
+		RootCaFingerprint string `json:"rootCaFingerprint"`
+		AllowExpired      bool   `json:"allowExpired"`
+	} `json:"sgxCollateral"`
+	// the policy blocks of the default profile
+	Policies map[string]json.RawMessage `json:"policies"`
+	Snp      struct {
+		CertCacheDir    string   `json:"certCacheDir"`
+		ArkFingerprints []string `json:"arkFingerprints"`
+	} `json:"snp"`
+}
+
+func validateAndNormalizeUniqueId(uniqueIdTarget *string) error {
 	// check the unique ID for correctness, if it's base64 then convert to hex
-	if len(conf.UniqueIdTarget) != 0 {
+	if len(*uniqueIdTarget) != 0 {
 		var uniqueIdBytes []byte
 		var err error
 
-		uniqueIdBytes, err = hex.DecodeString(conf.UniqueIdTarget)
+		uniqueIdBytes, err = hex.DecodeString(*uniqueIdTarget)
 		isHex := err == nil
 
 		// now try decoding as base64
 		if !isHex {
-			uniqueIdBytes, err = base64.StdEncoding.DecodeString(conf.UniqueIdTarget)
+			uniqueIdBytes, err = base64.StdEncoding.DecodeString(*uniqueIdTarget)
 			if err != nil {
-				log.Printf("config: invalid SGX Unique ID: \"%s\"\n", conf.UniqueIdTarget)
+				log.Printf("config: invalid SGX Unique ID: \"%s\"\n", *uniqueIdTarget)
 				return fmt.Errorf("config \"uniqueIdTarget\" must be %d bytes hex- or base64-encoded", expectedUniqueIdLength)
 			}
 
 			// convert the unique ID to a hex string
-			conf.UniqueIdTarget = hex.EncodeToString(uniqueIdBytes)
+			*uniqueIdTarget = hex.EncodeToString(uniqueIdBytes)
 		}
 
 		if len(uniqueIdBytes) != expectedUniqueIdLength {
-			log.Printf("config: invalid SGX Unique ID: \"%s\"\n", conf.UniqueIdTarget)
+			log.Printf("config: invalid SGX Unique ID: \"%s\"\n", *uniqueIdTarget)
 			return fmt.Errorf("config \"uniqueIdTarget\" must be %d bytes", expectedUniqueIdLength)
 		}
 	}
@@ -63,8 +158,18 @@ func validateAndNormalizeUniqueId(conf *Configuration) error {
 	return nil
 }
 
-func validateAndNormalizePcrValues(conf *Configuration) error {
-	for pcrIdx, pcr := range conf.PcrValuesTarget {
+// accepts the colon-separated format of openssl, returns the lowercase hex-encoded fingerprint
+func normalizeFingerprint(fingerprint string) (string, bool) {
+	fingerprint = strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+	if decoded, err := hex.DecodeString(fingerprint); err != nil || len(decoded) != sha256.Size {
+		return "", false
+	}
+
+	return fingerprint, true
+}
+
+func validateAndNormalizePcrValues(pcrValuesTarget []string) error {
+	for pcrIdx, pcr := range pcrValuesTarget {
 		var pcrBytes []byte
 		var err error
 
@@ -80,7 +185,7 @@ func validateAndNormalizePcrValues(conf *Configuration) error {
 			}
 
 			// convert the PCR value to a hex string
-			conf.PcrValuesTarget[pcrIdx] = hex.EncodeToString(pcrBytes)
+			pcrValuesTarget[pcrIdx] = hex.EncodeToString(pcrBytes)
 		}
 
 		if len(pcrBytes) != expectedPcrValueLength {
@@ -100,23 +205,292 @@ func LoadConfig(confContent []byte) (*Configuration, error) {
 		return nil, err
 	}
 
-	if conf.LiveCheck.ApiBaseUrl == "" || conf.LiveCheck.ContractName == "" {
-		return nil, errors.New("config \"liveCheck\" is not configured correctly, must have \"apiBaseUrl\" and \"contractName\"")
+	if err := validateAndNormalizeLiveCheck(&conf.LiveCheck); err != nil {
+		return nil, err
+	}
+
+	if conf.Nonce.Required && !conf.Nonce.Enabled {
+		return nil, errors.New("config \"nonce.required\" cannot be used without \"nonce.enabled\"")
+	}
+
+	if conf.Nonce.TtlSeconds == 0 {
+		conf.Nonce.TtlSeconds = defaultNonceTtlSeconds
+	}
+
+	if conf.Replay.Enabled {
+		switch conf.Replay.Policy {
+		case "":
+			conf.Replay.Policy = replay.POLICY_FLAG
+		case replay.POLICY_FLAG, replay.POLICY_REJECT:
+		default:
+			return nil, fmt.Errorf("config \"replay.policy\" must be \"%s\" or \"%s\"", replay.POLICY_FLAG, replay.POLICY_REJECT)
+		}
+
+		switch conf.Replay.Backend {
+		case "":
+			conf.Replay.Backend = replay.BACKEND_MEMORY
+		case replay.BACKEND_MEMORY:
+		case replay.BACKEND_FILE:
+			if conf.Replay.Path == "" {
+				return nil, errors.New("config \"replay.path\" is required for the file backend")
+			}
+		default:
+			return nil, fmt.Errorf("config \"replay.backend\" must be \"%s\" or \"%s\"", replay.BACKEND_MEMORY, replay.BACKEND_FILE)
+		}
+
+		if conf.Replay.TtlSeconds == 0 {
+			conf.Replay.TtlSeconds = defaultReplayTtlSeconds
+		}
+	}
+
+	if conf.VerificationCache.Size <= 0 {
+		conf.VerificationCache.Size = defaultVerificationCacheSize
+	}
+
+	if conf.VerificationCache.TtlSeconds == 0 {
+		conf.VerificationCache.TtlSeconds = defaultVerificationCacheTtlSeconds
+	}
+
+	if conf.Auditor.PollIntervalSeconds == 0 {
+		conf.Auditor.PollIntervalSeconds = defaultAuditorPollIntervalSeconds
+	}
+
+	if conf.Auditor.HistorySize <= 0 {
+		conf.Auditor.HistorySize = defaultAuditorHistorySize
+	}
+
+	switch conf.Startup.Policy {
+	case "":
+		conf.Startup.Policy = STARTUP_POLICY_FAIL
+	case STARTUP_POLICY_FAIL, STARTUP_POLICY_DEGRADED:
+	default:
+		return nil, errors.New("config \"startup.policy\" must be \"fail\" or \"degraded\"")
+	}
+
+	if conf.Startup.RetryIntervalSeconds == 0 {
+		conf.Startup.RetryIntervalSeconds = defaultStartupRetryIntervalSeconds
+	}
+
+	switch conf.Nitro.VerificationTime {
+	case "":
+		conf.Nitro.VerificationTime = nitro.VERIFICATION_TIME_ATTESTATION
+	case nitro.VERIFICATION_TIME_ATTESTATION, nitro.VERIFICATION_TIME_CURRENT:
+	case nitro.VERIFICATION_TIME_CURRENT_WITH_GRACE:
+		if conf.Nitro.GraceSeconds == 0 {
+			return nil, errors.New("config \"nitro.graceSeconds\" is required for the currentWithGrace verification time")
+		}
+	default:
+		return nil, errors.New("config \"nitro.verificationTime\" must be \"attestation\", \"current\" or \"currentWithGrace\"")
+	}
+
+	if conf.SgxCollateral.RootCaFingerprint != "" {
+		fingerprint, ok := normalizeFingerprint(conf.SgxCollateral.RootCaFingerprint)
+		if !ok {
+			return nil, errors.New("config \"sgxCollateral.rootCaFingerprint\" must be a hex-encoded SHA-256 fingerprint")
+		}
+		conf.SgxCollateral.RootCaFingerprint = fingerprint
 	}
 
-	if !strings.HasSuffix(conf.LiveCheck.ContractName, ".aleo") {
-		conf.LiveCheck.ContractName = conf.LiveCheck.ContractName + ".aleo"
+	for idx, fingerprint := range conf.Snp.ArkFingerprints {
+		normalized, ok := normalizeFingerprint(fingerprint)
+		if !ok {
+			return nil, errors.New("config \"snp.arkFingerprints\" must be hex-encoded SHA-256 fingerprints")
+		}
+		conf.Snp.ArkFingerprints[idx] = normalized
 	}
 
-	err = validateAndNormalizeUniqueId(conf)
+	err = validateAndNormalizeUniqueId(&conf.UniqueIdTarget)
 	if err != nil {
 		return nil, err
 	}
 
-	err = validateAndNormalizePcrValues(conf)
+	err = validateAndNormalizePcrValues(conf.PcrValuesTarget)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := validatePolicies(conf.Policies); err != nil {
+		return nil, err
+	}
+
+	profileNames := map[string]bool{DEFAULT_PROFILE_NAME: true}
+	for idx := range conf.Profiles {
+		profile := &conf.Profiles[idx]
+
+		if !validProfileName(profile.Name) {
+			return nil, fmt.Errorf("config \"profiles\" name %q must be made of lowercase letters, digits, '-' and '_'", profile.Name)
+		}
+		if profileNames[profile.Name] {
+			return nil, fmt.Errorf("config \"profiles\" name %q is used more than once", profile.Name)
+		}
+		profileNames[profile.Name] = true
+
+		if err := validateAndNormalizeLiveCheck(&profile.LiveCheck); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		if err := validateAndNormalizeUniqueId(&profile.UniqueIdTarget); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		if err := validateAndNormalizePcrValues(profile.PcrValuesTarget); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		if err := validatePolicies(profile.Policies); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+	}
+
 	return conf, nil
 }
+
+// the SGX and Nitro targets are live checked, so they can only be set with "uniqueIdTarget" and "pcrValuesTarget".
+// The blocks are validated by the verifiers.
+func validatePolicies(policies map[string]json.RawMessage) error {
+	for _, reportType := range []string{SGX_POLICY, NITRO_POLICY} {
+		if _, ok := policies[reportType]; ok {
+			return fmt.Errorf("config \"policies\" must not have %q, use \"uniqueIdTarget\" and \"pcrValuesTarget\"", reportType)
+		}
+	}
+
+	return nil
+}
+
+func validateAndNormalizeLiveCheck(liveCheck *LiveCheck) error {
+	if liveCheck.ApiBaseUrl == "" || liveCheck.ContractName == "" {
+		return errors.New("config \"liveCheck\" is not configured correctly, must have \"apiBaseUrl\" and \"contractName\"")
+	}
+
+	if !strings.HasSuffix(liveCheck.ContractName, ".aleo") {
+		liveCheck.ContractName = liveCheck.ContractName + ".aleo"
+	}
+
+	return nil
+}
+
+// profile names are used in URL paths
+func validProfileName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AllProfiles returns the default profile made of the top-level configuration, followed by the configured profiles
+func (conf *Configuration) AllProfiles() []Profile {
+	profiles := make([]Profile, 0, len(conf.Profiles)+1)
+	profiles = append(profiles, Profile{
+		Name:            DEFAULT_PROFILE_NAME,
+		UniqueIdTarget:  conf.UniqueIdTarget,
+		PcrValuesTarget: conf.PcrValuesTarget,
+		Policies:        conf.Policies,
+		LiveCheck:       conf.LiveCheck,
+	})
+
+	return append(profiles, conf.Profiles...)
+}
+
+// PolicyBlocks returns the policy blocks of the profile by report type, including the SGX and Nitro blocks made of
+// "uniqueIdTarget" and "pcrValuesTarget"
+func (p *Profile) PolicyBlocks() map[string]json.RawMessage {
+	blocks := make(map[string]json.RawMessage, len(p.Policies)+2)
+	for reportType, block := range p.Policies {
+		blocks[reportType] = block
+	}
+
+	blocks[SGX_POLICY], _ = json.Marshal(map[string]string{"uniqueIdTarget": p.UniqueIdTarget})
+	blocks[NITRO_POLICY], _ = json.Marshal(map[string][]string{"pcrValuesTarget": p.PcrValuesTarget})
+
+	return blocks
+}
+
+// Profile returns the profile with the name
+func (conf *Configuration) Profile(name string) (*Profile, bool) {
+	for _, profile := range conf.AllProfiles() {
+		if profile.Name == name {
+			return &profile, true
+		}
+	}
+
+	return nil, false
+}
+
+// finds the byte range of a top-level value in a JSON object
+func findTopLevelValue(confContent []byte, key string) (int, int, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(confContent))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return 0, 0, false, errors.New("config must be a JSON object")
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, false, err
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return 0, 0, false, err
+		}
+
+		if token == key {
+			end := int(decoder.InputOffset())
+			return end - len(value), end, true, nil
+		}
+	}
+
+	return 0, 0, false, nil
+}
+
+// SetMeasurementTargets replaces the "uniqueIdTarget" and "pcrValuesTarget" values in the configuration file content,
+// keeping the rest of the file as is. Missing keys are added at the beginning of the object.
+func SetMeasurementTargets(confContent []byte, uniqueId string, pcrValues []string) ([]byte, error) {
+	encodedUniqueId, err := json.Marshal(uniqueId)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedPcrValues, err := json.MarshalIndent(pcrValues, "  ", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	result := confContent
+	for _, kv := range []struct {
+		key   string
+		value []byte
+	}{
+		{"uniqueIdTarget", encodedUniqueId},
+		{"pcrValuesTarget", encodedPcrValues},
+	} {
+		start, end, found, err := findTopLevelValue(result, kv.key)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			// insert right after the opening brace
+			start = bytes.IndexByte(result, '{') + 1
+			end = start
+			kv.value = []byte(fmt.Sprintf("\n  %q: %s,", kv.key, kv.value))
+		}
+
+		updated := make([]byte, 0, len(result)-(end-start)+len(kv.value))
+		updated = append(updated, result[:start]...)
+		updated = append(updated, kv.value...)
+		updated = append(updated, result[end:]...)
+		result = updated
+	}
+
+	return result, nil
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+)
+
+func Test_SetMeasurementTargets(t *testing.T) {
+	pcrValues := []string{"pcr0", "pcr1", "pcr2"}
+
+	tests := []struct {
+		name        string
+		confContent string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "replaces values",
+			confContent: "{\n  \"port\": 8080,\n  \"uniqueIdTarget\": \"old\",\n  \"pcrValuesTarget\": [\n    \"a\",\n    \"b\"\n  ],\n  \"liveCheck\": { \"uniqueIdTarget\": \"nested\" }\n}\n",
+			want:        "{\n  \"port\": 8080,\n  \"uniqueIdTarget\": \"new\",\n  \"pcrValuesTarget\": [\n    \"pcr0\",\n    \"pcr1\",\n    \"pcr2\"\n  ],\n  \"liveCheck\": { \"uniqueIdTarget\": \"nested\" }\n}\n",
+		},
+		{
+			name:        "adds missing keys",
+			confContent: "{\n  \"port\": 8080\n}",
+			want:        "{\n  \"pcrValuesTarget\": [\n    \"pcr0\",\n    \"pcr1\",\n    \"pcr2\"\n  ],\n  \"uniqueIdTarget\": \"new\",\n  \"port\": 8080\n}",
+		},
+		{
+			name:        "not an object",
+			confContent: "[]",
+			wantErr:     true,
+		},
+		{
+			name:        "malformed",
+			confContent: "{ \"port\": ",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetMeasurementTargets([]byte(tt.confContent), "new", pcrValues)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetMeasurementTargets() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("SetMeasurementTargets()\nGot:\n%s\nWant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_LoadConfig_profiles(t *testing.T) {
+	const (
+		uniqueId  = "8905dac66beff0cd0bd142839de3a973d19620d0f53a72c0669658f81c19b8f2"
+		liveCheck = `{ "skip": true, "apiBaseUrl": "https://api.explorer.provable.com/v1/testnet", "contractName": "official_oracle.aleo" }`
+	)
+
+	tests := []struct {
+		name         string
+		profiles     string
+		wantProfiles []string
+		wantErr      bool
+	}{
+		{
+			name:         "no profiles",
+			profiles:     "[]",
+			wantProfiles: []string{DEFAULT_PROFILE_NAME},
+		},
+		{
+			name:         "mainnet profile",
+			profiles:     `[{ "name": "mainnet", "uniqueIdTarget": "iQXaxmvv8M0L0UKDneOpc9GWIND1OnLAZpZY+BwZuPI=", "liveCheck": ` + liveCheck + ` }]`,
+			wantProfiles: []string{DEFAULT_PROFILE_NAME, "mainnet"},
+		},
+		{
+			name:     "default name",
+			profiles: `[{ "name": "default", "liveCheck": ` + liveCheck + ` }]`,
+			wantErr:  true,
+		},
+		{
+			name:     "duplicate name",
+			profiles: `[{ "name": "mainnet", "liveCheck": ` + liveCheck + ` }, { "name": "mainnet", "liveCheck": ` + liveCheck + ` }]`,
+			wantErr:  true,
+		},
+		{
+			name:     "name not usable in a path",
+			profiles: `[{ "name": "main/net", "liveCheck": ` + liveCheck + ` }]`,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid unique ID",
+			profiles: `[{ "name": "mainnet", "uniqueIdTarget": "abcd", "liveCheck": ` + liveCheck + ` }]`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := LoadConfig([]byte(`{ "liveCheck": ` + liveCheck + `, "profiles": ` + tt.profiles + ` }`))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			var names []string
+			for _, profile := range conf.AllProfiles() {
+				names = append(names, profile.Name)
+			}
+			if !slices.Equal(names, tt.wantProfiles) {
+				t.Errorf("AllProfiles() = %v, want %v", names, tt.wantProfiles)
+			}
+
+			if profile, ok := conf.Profile("mainnet"); ok && profile.UniqueIdTarget != uniqueId {
+				t.Errorf("Profile() unique ID = %s, want the hex-encoded %s", profile.UniqueIdTarget, uniqueId)
+			}
+		})
+	}
+}
+
+func Test_LoadConfig_sgxCollateral(t *testing.T) {
+	const liveCheck = `{ "skip": true, "apiBaseUrl": "https://api.explorer.provable.com/v1/testnet", "contractName": "official_oracle.aleo" }`
+
+	fingerprint := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name            string
+		fingerprint     string
+		wantFingerprint string
+		wantErr         bool
+	}{
+		{name: "no fingerprint", fingerprint: "", wantFingerprint: ""},
+		{name: "hex fingerprint", fingerprint: strings.ToUpper(fingerprint), wantFingerprint: fingerprint},
+		{name: "openssl fingerprint", fingerprint: strings.TrimSuffix(strings.Repeat("AB:", 32), ":"), wantFingerprint: fingerprint},
+		{name: "short fingerprint", fingerprint: "abcd", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := LoadConfig([]byte(`{ "liveCheck": ` + liveCheck + `, "sgxCollateral": { "cacheDir": "collateral", "rootCaFingerprint": "` + tt.fingerprint + `" } }`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if conf.SgxCollateral.RootCaFingerprint != tt.wantFingerprint || conf.SgxCollateral.CacheDir != "collateral" {
+				t.Errorf("LoadConfig() sgxCollateral = %+v", conf.SgxCollateral)
+			}
+		})
+	}
+}
+
+func Test_LoadConfig_nitro(t *testing.T) {
+	const liveCheck = `{ "skip": true, "apiBaseUrl": "https://api.explorer.provable.com/v1/testnet", "contractName": "official_oracle.aleo" }`
+
+	tests := []struct {
+		name                 string
+		nitro                string
+		wantVerificationTime string
+		wantErr              bool
+	}{
+		{name: "default", nitro: `{}`, wantVerificationTime: nitro.VERIFICATION_TIME_ATTESTATION},
+		{name: "current time", nitro: `{ "verificationTime": "current" }`, wantVerificationTime: nitro.VERIFICATION_TIME_CURRENT},
+		{name: "grace", nitro: `{ "verificationTime": "currentWithGrace", "graceSeconds": 3600 }`, wantVerificationTime: nitro.VERIFICATION_TIME_CURRENT_WITH_GRACE},
+		{name: "no grace", nitro: `{ "verificationTime": "currentWithGrace" }`, wantErr: true},
+		{name: "unknown verification time", nitro: `{ "verificationTime": "now" }`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := LoadConfig([]byte(`{ "liveCheck": ` + liveCheck + `, "nitro": ` + tt.nitro + ` }`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if conf.Nitro.VerificationTime != tt.wantVerificationTime {
+				t.Errorf("LoadConfig() nitro = %+v", conf.Nitro)
+			}
+		})
+	}
+}
+
+func Test_LoadConfig_snp(t *testing.T) {
+	const liveCheck = `{ "skip": true, "apiBaseUrl": "https://api.explorer.provable.com/v1/testnet", "contractName": "official_oracle.aleo" }`
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		wantErr     bool
+	}{
+		{name: "ARK fingerprint", fingerprint: strings.ToUpper(strings.Repeat("cd", 32))},
+		{name: "short ARK fingerprint", fingerprint: "abcd", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := LoadConfig([]byte(`{ "liveCheck": ` + liveCheck + `, "snp": { "arkFingerprints": ["` + tt.fingerprint + `"] } }`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if conf.Snp.ArkFingerprints[0] != strings.ToLower(tt.fingerprint) {
+				t.Errorf("LoadConfig() ARK fingerprints = %v", conf.Snp.ArkFingerprints)
+			}
+		})
+	}
+}
+
+func Test_LoadConfig_policies(t *testing.T) {
+	const liveCheck = `{ "skip": true, "apiBaseUrl": "https://api.explorer.provable.com/v1/testnet", "contractName": "official_oracle.aleo" }`
+
+	uniqueId := strings.Repeat("aa", 32)
+
+	tests := []struct {
+		name     string
+		policies string
+		wantErr  bool
+	}{
+		{name: "no policies", policies: `{}`},
+		{name: "other TEE", policies: `{ "snp": { "measurementTarget": "abcd" } }`},
+		{name: "SGX", policies: `{ "sgx": { "uniqueIdTarget": "` + uniqueId + `" } }`, wantErr: true},
+		{name: "Nitro", policies: `{ "nitro": { "pcrValuesTarget": [] } }`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := LoadConfig([]byte(`{ "liveCheck": ` + liveCheck + `, "uniqueIdTarget": "` + uniqueId + `", "policies": ` + tt.policies + ` }`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			profile, _ := conf.Profile(DEFAULT_PROFILE_NAME)
+			blocks := profile.PolicyBlocks()
+
+			// the blocks of the other TEEs are passed to the verifiers as they are
+			if string(blocks["snp"]) != string(profile.Policies["snp"]) {
+				t.Errorf("PolicyBlocks() snp = %s", blocks["snp"])
+			}
+			if want := `{"uniqueIdTarget":"` + uniqueId + `"}`; string(blocks[SGX_POLICY]) != want {
+				t.Errorf("PolicyBlocks() sgx = %s, want %s", blocks[SGX_POLICY], want)
+			}
+			if _, ok := blocks[NITRO_POLICY]; !ok {
+				t.Error("PolicyBlocks() has no nitro block")
+			}
+		})
+	}
+}
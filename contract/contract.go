@@ -1,87 +1,81 @@
 package contract
 
 import (
+	"context"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
-	"io"
-	"log"
+	"fmt"
 	"math/big"
-	"net/http"
+	"slices"
 	"strings"
-	"time"
-)
 
-func bigIntStrToBytes(strBigInt string) ([]byte, error) {
-	num := new(big.Int)
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+	"github.com/venture23-aleo/oracle-verification-backend/plaintext"
+)
 
-	num, ok := num.SetString(strBigInt, 10)
-	if !ok {
-		return nil, errors.New("invalid big integer string")
+// converts a u128 value to 16 bytes in little-endian order
+func u128ToBytes(value *big.Int) ([]byte, error) {
+	if value.Sign() < 0 || value.BitLen() > 128 {
+		return nil, errors.New("big integer exceeds 128 bits")
 	}
 
 	bytes := make([]byte, 16)
-
-	// Extract bytes in little-endian order
-	for i := 0; i < 16; i++ {
-		b := byte(num.Uint64() & 0xff)
-		bytes[i] = b
-
-		num.Rsh(num, 8)
-	}
-
-	if num.Sign() != 0 {
-		return nil, errors.New("big integer exceeds 128 bits")
-	}
+	value.FillBytes(bytes)
+	slices.Reverse(bytes)
 
 	return bytes, nil
 }
 
-func requestProgramString(c *http.Client, url string, retry bool) (string, error) {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return "", err
-	}
+// reads u128 struct members in order and concatenates their little-endian bytes
+func u128MembersToBytes(value *plaintext.Value, names ...string) ([]byte, error) {
+	result := make([]byte, 0, len(names)*16)
 
-	resp, err := c.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+	for _, name := range names {
+		member, err := value.Member(name)
+		if err != nil {
+			return nil, err
+		}
 
-	if retry && (resp.StatusCode == http.StatusInternalServerError || resp.StatusCode == http.StatusNotFound) {
-		log.Printf("contract: requesting %s returned %d, trying again\n", url, resp.StatusCode)
-		time.Sleep(3 * time.Second)
-		return requestProgramString(c, url, false)
-	}
+		num, err := member.Uint128()
+		if err != nil {
+			return nil, fmt.Errorf("member %s: %w", name, err)
+		}
 
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return "", errors.New("contract: Aleo node API responded with 429 Too Many Requests, try again later")
-	}
+		buf, err := u128ToBytes(num)
+		if err != nil {
+			return nil, err
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("contract: did not get an OK response")
+		result = append(result, buf...)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
+	return result, nil
+}
 
+func requestMappingValue(ctx context.Context, client *node.Client, path string) (string, error) {
 	var result string
 
-	err = json.Unmarshal(body, &result)
+	err := client.GetJSON(ctx, path, &result)
 	if err != nil {
 		return "", err
 	}
 
-	if result == "null" {
+	if result == "" || result == "null" {
 		return "", errors.New("contract: value is not set")
 	}
 
 	return result, nil
 }
 
+// creates the mapping value path from the mapping URL template, the base URL placeholder is filled in by the node client
+func mappingPath(mappingUrlTemplate, contractName, mappingName, mappingKey string) string {
+	path := strings.Replace(mappingUrlTemplate, "{contractName}", contractName, 1)
+	path = strings.Replace(path, "{mappingName}", mappingName, 1)
+	path = strings.Replace(path, "{mappingKey}", mappingKey, 1)
+
+	return path
+}
+
 func parseSgxUniqueIdStruct(uniqueIdStructString string) (string, error) {
 	// The unique ID is stored using this type:
 	// struct Unique_id {
@@ -89,32 +83,14 @@ func parseSgxUniqueIdStruct(uniqueIdStructString string) (string, error) {
 	//   chunk_2: u128
 	// }
 
-	chunks := strings.Split(uniqueIdStructString, "\n")
-
-	uniqueId := make([]byte, 0, 32)
-
-	for _, chunk := range chunks {
-		if !strings.Contains(chunk, "chunk_") {
-			continue
-		}
-
-		valBegin := strings.Index(chunk, ": ")
-		valEnd := strings.Index(chunk, "u128")
-
-		if valBegin == -1 || valEnd == -1 {
-			return "", errors.New("unexpected type of unique ID chunks in sgx_unique_id mapping")
-		}
-
-		uniqueIdPart := chunk[valBegin+2 : valEnd]
-		uniqueIdBytes, err := bigIntStrToBytes(uniqueIdPart)
-		if err != nil {
-			return "", err
-		}
-		uniqueId = append(uniqueId, uniqueIdBytes...)
+	value, err := plaintext.Parse(uniqueIdStructString)
+	if err != nil {
+		return "", err
 	}
 
-	if len(uniqueId) != 32 {
-		return "", errors.New("malformed unique id in the contract")
+	uniqueId, err := u128MembersToBytes(value, "chunk_1", "chunk_2")
+	if err != nil {
+		return "", fmt.Errorf("unexpected type of unique ID in sgx_unique_id mapping: %w", err)
 	}
 
 	return hex.EncodeToString(uniqueId), nil
@@ -134,39 +110,21 @@ func parseNitroPcrValues(nitroPcrStructString string) ([]string, error) {
 	//   pcr_2_chunk_3: u128
 	// }
 
-	chunks := strings.Split(nitroPcrStructString, "\n")
-
-	pcrValues := make([][]byte, 0, 9)
-	for _, chunk := range chunks {
-		if !strings.Contains(chunk, "pcr_") {
-			continue
-		}
-
-		valBegin := strings.Index(chunk, ": ")
-		valEnd := strings.Index(chunk, "u128")
-
-		if valBegin == -1 || valEnd == -1 {
-			return nil, errors.New("unexpected type of PCR value chunks in nitro_pcr_values mapping")
-		}
-
-		pcrValuePart := chunk[valBegin+2 : valEnd]
-		pcrValueBytes, err := bigIntStrToBytes(pcrValuePart)
-		if err != nil {
-			return nil, err
-		}
-		pcrValues = append(pcrValues, pcrValueBytes)
-	}
-
-	if len(pcrValues) != 9 {
-		return nil, errors.New("unexpected type of PCR values struct in nitro_pcr_values mapping")
+	value, err := plaintext.Parse(nitroPcrStructString)
+	if err != nil {
+		return nil, err
 	}
 
 	pcrs := make([]string, 3)
 	for pcrIdx := 0; pcrIdx < 3; pcrIdx++ {
-		pcr := make([]byte, 0, 48)
-		pcr = append(pcr, pcrValues[pcrIdx*3]...)
-		pcr = append(pcr, pcrValues[pcrIdx*3+1]...)
-		pcr = append(pcr, pcrValues[pcrIdx*3+2]...)
+		pcr, err := u128MembersToBytes(value,
+			fmt.Sprintf("pcr_%d_chunk_1", pcrIdx),
+			fmt.Sprintf("pcr_%d_chunk_2", pcrIdx),
+			fmt.Sprintf("pcr_%d_chunk_3", pcrIdx),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected type of PCR values struct in nitro_pcr_values mapping: %w", err)
+		}
 
 		pcrs[pcrIdx] = hex.EncodeToString(pcr)
 	}
@@ -176,19 +134,10 @@ func parseNitroPcrValues(nitroPcrStructString string) ([]string, error) {
 
 // Retrieves the SGX unique ID from the contract that it uses to verify reports.
 // The contract must have a mapping called sgx_unique_id, where the value us stored as a struct under the "0u8" key.
-func GetSgxUniqueIDAssert(apiBaseUrl, contractName, mappingUrlTemplate, sgxUniqueIdMappingName, sgxUniqueIdMappingKey string) (string, error) {
-	apiBaseUrl = strings.TrimSuffix(apiBaseUrl, "/")
-
-	requestUrl := strings.Replace(mappingUrlTemplate, "{apiBaseUrl}", apiBaseUrl, 1)
-	requestUrl = strings.Replace(requestUrl, "{contractName}", contractName, 1)
-	requestUrl = strings.Replace(requestUrl, "{mappingName}", sgxUniqueIdMappingName, 1)
-	requestUrl = strings.Replace(requestUrl, "{mappingKey}", sgxUniqueIdMappingKey, 1)
-
-	client := &http.Client{
-		Timeout: time.Second * 30,
-	}
+func GetSgxUniqueIDAssert(ctx context.Context, client *node.Client, contractName, mappingUrlTemplate, sgxUniqueIdMappingName, sgxUniqueIdMappingKey string) (string, error) {
+	requestPath := mappingPath(mappingUrlTemplate, contractName, sgxUniqueIdMappingName, sgxUniqueIdMappingKey)
 
-	uniqueIdStructString, err := requestProgramString(client, requestUrl, true)
+	uniqueIdStructString, err := requestMappingValue(ctx, client, requestPath)
 	if err != nil {
 		return "", err
 	}
@@ -198,19 +147,10 @@ func GetSgxUniqueIDAssert(apiBaseUrl, contractName, mappingUrlTemplate, sgxUniqu
 
 // Retrieves the Nitro PCR values from the contract that it uses to verify reports.
 // The contract must have a mapping called nitro_pcr_values, where the value us stored as a struct under the "0u8" key.
-func GetNitroPcrValuesAssert(apiBaseUrl, contractName, mappingUrlTemplate, nitroPcrValuesMappingName, nitroPcrValuesMappingKey string) ([]string, error) {
-	apiBaseUrl = strings.TrimSuffix(apiBaseUrl, "/")
-
-	requestUrl := strings.Replace(mappingUrlTemplate, "{apiBaseUrl}", apiBaseUrl, 1)
-	requestUrl = strings.Replace(requestUrl, "{contractName}", contractName, 1)
-	requestUrl = strings.Replace(requestUrl, "{mappingName}", nitroPcrValuesMappingName, 1)
-	requestUrl = strings.Replace(requestUrl, "{mappingKey}", nitroPcrValuesMappingKey, 1)
-
-	client := &http.Client{
-		Timeout: time.Second * 30,
-	}
+func GetNitroPcrValuesAssert(ctx context.Context, client *node.Client, contractName, mappingUrlTemplate, nitroPcrValuesMappingName, nitroPcrValuesMappingKey string) ([]string, error) {
+	requestPath := mappingPath(mappingUrlTemplate, contractName, nitroPcrValuesMappingName, nitroPcrValuesMappingKey)
 
-	pcrsStructString, err := requestProgramString(client, requestUrl, true)
+	pcrsStructString, err := requestMappingValue(ctx, client, requestPath)
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +24,28 @@ func Test_parseSgxUniqueIdStruct(t *testing.T) {
 			want:    "446a519b3ff301317d7ab2a6d074051878c23c345b3f85e76dbc69141309abfc",
 			wantErr: false,
 		},
+		{
+			name: "single line, different member order",
+			args: args{
+				uniqueIdStructString: "{ chunk_2: 335853521753947303372057454886636012152u128, chunk_1: 31929802673692760512905395015836068420u128 }",
+			},
+			want:    "446a519b3ff301317d7ab2a6d074051878c23c345b3f85e76dbc69141309abfc",
+			wantErr: false,
+		},
+		{
+			name: "wrong chunk type",
+			args: args{
+				uniqueIdStructString: "{ chunk_1: 31929802673692760512905395015836068420field, chunk_2: 335853521753947303372057454886636012152u128 }",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing chunk",
+			args: args{
+				uniqueIdStructString: "{ chunk_1: 31929802673692760512905395015836068420u128 }",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -61,6 +83,25 @@ func Test_parseNitroPcrValues(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "single line, different member order",
+			args: args{
+				nitroPcrStructString: "{ pcr_2_chunk_3: 200411607119746324753107350992173755975u128, pcr_2_chunk_2: 334747434232414500511461632767813487886u128, pcr_2_chunk_1: 264733590264774658848247826143579120213u128, pcr_1_chunk_3: 227000420934281803670652481542768973666u128, pcr_1_chunk_2: 139766717364114533801335576914874403398u128, pcr_1_chunk_1: 160074764010604965432569395010350367491u128, pcr_0_chunk_3: 319153641741947202476283715452178757539u128, pcr_0_chunk_2: 161208568844425284329478584127483958658u128, pcr_0_chunk_1: 71402194384810807695471133674510927100u128 }",
+			},
+			want: []string{
+				"fcc4ced3f4bba7352e289a27fb8fb7358255d6b35abafdc8b4a398c418a44779a377979baa62fc78ef6d89aa6bc11af0",
+				"0343b056cd8485ca7890ddd833476d78460aed2aa161548e4e26bedf321726696257d623e8805f3f605946b3d8b0c6aa",
+				"55a296be86298ce7d58bf289bad529c70e0d50854b475990d4f8ead2bf02d6fb476e717cc80c057abf7cd0f21cdfc596",
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing chunks",
+			args: args{
+				nitroPcrStructString: "{ pcr_0_chunk_1: 71402194384810807695471133674510927100u128 }",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
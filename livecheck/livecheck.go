@@ -0,0 +1,160 @@
+// Package livecheck compares the configured enclave measurements with the ones asserted in the live Aleo program.
+package livecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+	"github.com/venture23-aleo/oracle-verification-backend/contract"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+)
+
+const (
+	// the measurements match the ones in the live program
+	STATE_CONFIRMED = "confirmed"
+	// the live program could not be queried yet
+	STATE_UNCONFIRMED = "unconfirmed"
+	// the live check is disabled in the configuration
+	STATE_SKIPPED = "skipped"
+)
+
+var ErrMeasurementsMismatch = errors.New("livecheck: the configured enclave measurements don't match the live program")
+
+// Status is the state of the live check of a profile
+type Status struct {
+	State       string `json:"state"`
+	LastError   string `json:"lastError,omitempty"`
+	LastAttempt string `json:"lastAttemptUTC,omitempty"`
+}
+
+// Ready reports whether the configured measurements can be trusted as they are, i.e. they were confirmed or the check is skipped
+func (s Status) Ready() bool {
+	return s.State != STATE_UNCONFIRMED
+}
+
+// Checker checks the measurement targets of a profile against the live program and keeps the result.
+// The checker is safe for concurrent use.
+type Checker struct {
+	profile    config.Profile
+	nodeClient *node.Client
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// New creates the checker of a profile. The profile starts unconfirmed unless its live check is skipped.
+func New(profile config.Profile, nodeClient *node.Client) *Checker {
+	state := STATE_UNCONFIRMED
+	if profile.LiveCheck.Skip {
+		state = STATE_SKIPPED
+	}
+
+	return &Checker{
+		profile:    profile,
+		nodeClient: nodeClient,
+		status: Status{
+			State: state,
+		},
+	}
+}
+
+// Status returns the current state of the live check
+func (c *Checker) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.status
+}
+
+func (c *Checker) setStatus(state string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.State = state
+	c.status.LastError = ""
+	if err != nil {
+		c.status.LastError = err.Error()
+	}
+	c.status.LastAttempt = time.Now().UTC().Format(time.DateTime)
+}
+
+// Check queries the live program and compares its measurements with the profile's targets. A mismatch is reported
+// with ErrMeasurementsMismatch, any other error means the live program could not be queried.
+func (c *Checker) Check(ctx context.Context) error {
+	if c.profile.LiveCheck.Skip {
+		return nil
+	}
+
+	err := c.check(ctx)
+	if err != nil {
+		c.setStatus(STATE_UNCONFIRMED, err)
+		return err
+	}
+
+	c.setStatus(STATE_CONFIRMED, nil)
+
+	return nil
+}
+
+func (c *Checker) check(ctx context.Context) error {
+	liveCheck := &c.profile.LiveCheck
+
+	log.Println("Requesting SGX Unique ID and Nitro PCR values from", liveCheck.ContractName, "using", c.nodeClient.BaseUrl())
+	liveUniqueId, err := contract.GetSgxUniqueIDAssert(ctx, c.nodeClient, liveCheck.ContractName, liveCheck.MappingUrlTemplate, liveCheck.SgxUniqueIdMappingName, liveCheck.SgxUniqueIdMappingKey)
+	if err != nil {
+		return fmt.Errorf("failed to fetch live contract's SGX Unique ID assertion: %w", err)
+	}
+
+	log.Printf("Fetched SGX Unique ID assertion from %s: %s", liveCheck.ContractName, liveUniqueId)
+
+	if liveUniqueId != c.profile.UniqueIdTarget {
+		return fmt.Errorf("%w: reproducible SGX build of the oracle backend produced a different SGX Unique ID than the live contract.\nLive SGX Unique ID: %s\nReproduced SGX Unique ID: %s", ErrMeasurementsMismatch, liveUniqueId, c.profile.UniqueIdTarget)
+	}
+
+	livePcrValues, err := contract.GetNitroPcrValuesAssert(ctx, c.nodeClient, liveCheck.ContractName, liveCheck.MappingUrlTemplate, liveCheck.NitroPcrValuesMappingName, liveCheck.NitroPcrValuesMappingKey)
+	if err != nil {
+		return fmt.Errorf("failed to fetch live contract's Nitro PCR values assertion: %w", err)
+	}
+
+	log.Printf("Fetched Nitro PCR values assertion from %s: %s", liveCheck.ContractName, strings.Join(livePcrValues, ", "))
+
+	if !slices.Equal(livePcrValues, c.profile.PcrValuesTarget) {
+		return fmt.Errorf("%w: reproducible Nitro build of the oracle backend produced different Nitro PCR values than the live contract.\nLive Nitro PCR values: %s\nReproduced Nitro PCR values: %s", ErrMeasurementsMismatch, strings.Join(livePcrValues, ", "), strings.Join(c.profile.PcrValuesTarget, ", "))
+	}
+
+	return nil
+}
+
+// Retry repeats the check every interval until the measurements are confirmed. Returns nil once confirmed,
+// an ErrMeasurementsMismatch error if the measurements don't match, or the context error.
+func (c *Checker) Retry(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		err := c.Check(ctx)
+		if err == nil {
+			log.Printf("livecheck: confirmed the enclave measurements of profile %s\n", c.profile.Name)
+			return nil
+		}
+
+		if errors.Is(err, ErrMeasurementsMismatch) {
+			return err
+		}
+
+		log.Printf("livecheck: profile %s is still unconfirmed: %v, retrying in %s\n", c.profile.Name, err, interval)
+	}
+}
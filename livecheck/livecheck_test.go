@@ -0,0 +1,134 @@
+package livecheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/config"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+)
+
+const (
+	testUniqueId       = "446a519b3ff301317d7ab2a6d074051878c23c345b3f85e76dbc69141309abfc"
+	testUniqueIdStruct = `"{ chunk_1: 31929802673692760512905395015836068420u128, chunk_2: 335853521753947303372057454886636012152u128 }"`
+	testPcrValueStruct = `"{ pcr_0_chunk_1: 71402194384810807695471133674510927100u128, pcr_0_chunk_2: 161208568844425284329478584127483958658u128, pcr_0_chunk_3: 319153641741947202476283715452178757539u128, pcr_1_chunk_1: 160074764010604965432569395010350367491u128, pcr_1_chunk_2: 139766717364114533801335576914874403398u128, pcr_1_chunk_3: 227000420934281803670652481542768973666u128, pcr_2_chunk_1: 264733590264774658848247826143579120213u128, pcr_2_chunk_2: 334747434232414500511461632767813487886u128, pcr_2_chunk_3: 200411607119746324753107350992173755975u128 }"`
+)
+
+var testPcrValues = []string{
+	"fcc4ced3f4bba7352e289a27fb8fb7358255d6b35abafdc8b4a398c418a44779a377979baa62fc78ef6d89aa6bc11af0",
+	"0343b056cd8485ca7890ddd833476d78460aed2aa161548e4e26bedf321726696257d623e8805f3f605946b3d8b0c6aa",
+	"55a296be86298ce7d58bf289bad529c70e0d50854b475990d4f8ead2bf02d6fb476e717cc80c057abf7cd0f21cdfc596",
+}
+
+// serves the mappings after failing the first failures requests
+func newNodeServer(failures int32) *httptest.Server {
+	var requests atomic.Int32
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		switch {
+		case strings.Contains(req.URL.Path, "sgx_unique_id"):
+			w.Write([]byte(testUniqueIdStruct))
+		case strings.Contains(req.URL.Path, "nitro_pcr_values"):
+			w.Write([]byte(testPcrValueStruct))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestChecker(t *testing.T, baseUrl string, uniqueId string, skip bool) *Checker {
+	client, err := node.NewClient([]string{baseUrl}, node.Options{MaxRetries: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return New(config.Profile{
+		Name:            config.DEFAULT_PROFILE_NAME,
+		UniqueIdTarget:  uniqueId,
+		PcrValuesTarget: testPcrValues,
+		LiveCheck: config.LiveCheck{
+			Skip:                      skip,
+			ApiBaseUrl:                baseUrl,
+			ContractName:              "official_oracle.aleo",
+			MappingUrlTemplate:        "{apiBaseUrl}/program/{contractName}/mapping/{mappingName}/{mappingKey}",
+			SgxUniqueIdMappingName:    "sgx_unique_id",
+			SgxUniqueIdMappingKey:     "0u8",
+			NitroPcrValuesMappingName: "nitro_pcr_values",
+			NitroPcrValuesMappingKey:  "0u8",
+		},
+	}, client)
+}
+
+func TestCheckerCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int32
+		uniqueId     string
+		skip         bool
+		wantState    string
+		wantMismatch bool
+		wantErr      bool
+	}{
+		{name: "confirmed", uniqueId: testUniqueId, wantState: STATE_CONFIRMED},
+		{name: "node unreachable", failures: 100, uniqueId: testUniqueId, wantState: STATE_UNCONFIRMED, wantErr: true},
+		{name: "mismatch", uniqueId: strings.Repeat("00", 32), wantState: STATE_UNCONFIRMED, wantMismatch: true, wantErr: true},
+		{name: "skipped", failures: 100, uniqueId: testUniqueId, skip: true, wantState: STATE_SKIPPED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newNodeServer(tt.failures)
+			defer server.Close()
+
+			checker := newTestChecker(t, server.URL, tt.uniqueId, tt.skip)
+
+			err := checker.Check(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrMeasurementsMismatch) != tt.wantMismatch {
+				t.Errorf("Check() error = %v, wantMismatch %v", err, tt.wantMismatch)
+			}
+
+			status := checker.Status()
+			if status.State != tt.wantState {
+				t.Errorf("Status().State = %s, want %s", status.State, tt.wantState)
+			}
+			if status.Ready() != (tt.wantState != STATE_UNCONFIRMED) {
+				t.Errorf("Status().Ready() = %v", status.Ready())
+			}
+		})
+	}
+}
+
+func TestCheckerRetry(t *testing.T) {
+	server := newNodeServer(3)
+	defer server.Close()
+
+	checker := newTestChecker(t, server.URL, testUniqueId, false)
+
+	if err := checker.Check(context.Background()); err == nil {
+		t.Fatal("Check() succeeded with the node unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := checker.Retry(ctx, time.Millisecond); err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+
+	if status := checker.Status(); status.State != STATE_CONFIRMED || status.LastError != "" {
+		t.Errorf("Status() = %+v, want confirmed", status)
+	}
+}
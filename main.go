@@ -1,28 +1,47 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"slices"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
 	"github.com/venture23-aleo/oracle-verification-backend/api"
+	"github.com/venture23-aleo/oracle-verification-backend/auditor"
 	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/snp"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tdx"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/cli"
 	"github.com/venture23-aleo/oracle-verification-backend/config"
-	"github.com/venture23-aleo/oracle-verification-backend/contract"
-
-	aleo_utils "github.com/venture23-aleo/aleo-utils-go"
+	"github.com/venture23-aleo/oracle-verification-backend/livecheck"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+	"github.com/venture23-aleo/oracle-verification-backend/replay"
 )
 
 const (
 	IdleTimeout      = 30
 	ReadWriteTimeout = 20
+	// how long the in-flight requests can take to finish on shutdown
+	ShutdownTimeout = 30
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	confContent, err := os.ReadFile("config.json")
 	if err != nil {
 		log.Fatalln(err)
@@ -33,48 +52,119 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	if !conf.LiveCheck.Skip {
-		log.Println("Requesting SGX Unique ID and Nitro PCR values from", conf.LiveCheck.ContractName, "using", conf.LiveCheck.ApiBaseUrl)
-		liveUniqueId, err := contract.GetSgxUniqueIDAssert(conf.LiveCheck.ApiBaseUrl, conf.LiveCheck.ContractName, conf.LiveCheck.MappingUrlTemplate, conf.LiveCheck.SgxUniqueIdMappingName, conf.LiveCheck.SgxUniqueIdMappingKey)
+	nodeClients := make(map[string]*node.Client)
+	for _, profile := range conf.AllProfiles() {
+		nodeClients[profile.Name], err = node.NewClient(profile.LiveCheck.ApiBaseUrls(), node.Options{
+			Headers:    profile.LiveCheck.ApiHeaders,
+			MaxRetries: profile.LiveCheck.MaxRetries,
+		})
 		if err != nil {
-			log.Fatalln("Failed to fetch live contract's SGX Unique ID assertion:", err)
-		}
-
-		log.Printf("Fetched SGX Unique ID assertion from %s: %s", conf.LiveCheck.ContractName, liveUniqueId)
-
-		if liveUniqueId != conf.UniqueIdTarget {
-			log.Fatalf("Reproducible SGX build of the oracle backend produced a different SGX Unique ID than the live contract.\nLive SGX Unique ID: %s\nReproduced SGX Unique ID: %s\n", liveUniqueId, conf.UniqueIdTarget)
+			log.Fatalln(err)
 		}
+	}
 
-		livePcrValues, err := contract.GetNitroPcrValuesAssert(conf.LiveCheck.ApiBaseUrl, conf.LiveCheck.ContractName, conf.LiveCheck.MappingUrlTemplate, conf.LiveCheck.NitroPcrValuesMappingName, conf.LiveCheck.NitroPcrValuesMappingKey)
+	// the policy blocks are parsed by the verifiers of the TEEs
+	policies := make(map[string]tee.Policies)
+	for _, profile := range conf.AllProfiles() {
+		policies[profile.Name], err = tee.ProfilePolicies(profile.PolicyBlocks())
 		if err != nil {
-			log.Fatalln("Failed to fetch live contract's Nitro PCR values assertion:", err)
+			log.Fatalf("profile %s: %v\n", profile.Name, err)
 		}
+	}
 
-		log.Printf("Fetched Nitro PCR values asserttion from %s: %s", conf.LiveCheck.ContractName, liveUniqueId)
+	// stops the background checks and the server on SIGINT and SIGTERM
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	liveChecks := make(map[string]*livecheck.Checker)
+	for _, profile := range conf.AllProfiles() {
+		liveCheck := livecheck.New(profile, nodeClients[profile.Name])
+		liveChecks[profile.Name] = liveCheck
+
+		if !profile.LiveCheck.Skip {
+			err := liveCheck.Check(ctx)
+			// a mismatch is never tolerated, an unreachable node is tolerated in degraded mode
+			if err != nil && (conf.Startup.Policy == config.STARTUP_POLICY_FAIL || errors.Is(err, livecheck.ErrMeasurementsMismatch)) {
+				log.Fatalln(err)
+			}
+
+			if err != nil {
+				log.Printf("WARNING: starting degraded, Aleo live contract SGX Unique ID and Nitro PCR values for profile %s are unconfirmed: %v\n", profile.Name, err)
+
+				go func() {
+					err := liveCheck.Retry(ctx, time.Duration(conf.Startup.RetryIntervalSeconds)*time.Second)
+					if errors.Is(err, livecheck.ErrMeasurementsMismatch) {
+						log.Fatalln(err)
+					}
+				}()
+			}
+		} else {
+			log.Printf("WARNING: skipping Aleo live contract SGX Unique ID and Nitro PCR values check for profile %s\n", profile.Name)
+		}
 
-		if !slices.Equal(livePcrValues, conf.PcrValuesTarget) {
-			log.Fatalf("Reproducible Nitro build of the oracle backend produced different Nitro PCR values than the live contract.\nLive Nitro PCR values: %s\nReproduced Nitro PCR values: %s\n", strings.Join(livePcrValues, ", "), strings.Join(conf.PcrValuesTarget[:], ", "))
+		log.Printf("Expecting Aleo Oracle backend in profile %s to have SGX Unique ID: %s\n", profile.Name, profile.UniqueIdTarget)
+		log.Printf("Expecting Aleo Oracle backend in profile %s to have Nitro PCR values: %s\n", profile.Name, strings.Join(profile.PcrValuesTarget, ", "))
+		for _, verifier := range tee.All() {
+			if _, ok := profile.Policies[verifier.Type()]; ok {
+				log.Printf("Expecting Aleo Oracle backend in profile %s to have %s measurements: %v\n", profile.Name, verifier.Type(), policies[profile.Name][verifier.Type()].Measurements)
+			}
 		}
-	} else {
-		log.Println("WARNING: skipping Aleo live contract SGX Unique ID and Nitro PCR values check")
 	}
 
-	log.Println("Expecting Aleo Oracle backend to have SGX Unique ID:", conf.UniqueIdTarget)
-	log.Println("Expecting Aleo Oracle backend to have Nitro PCR values:", strings.Join(conf.PcrValuesTarget, ", "))
-
-	err = nitro.Init()
+	err = nitro.Init(conf.Nitro.RootCaPath, conf.Nitro.VerificationTime, time.Duration(conf.Nitro.GraceSeconds)*time.Second)
 	if err != nil {
 		log.Fatalln("Failed to initialize Nitro report verifier:", err)
 	}
 
-	aleo, close, err := aleo_utils.NewWrapper()
+	sgx.Init(conf.SgxCollateral.CacheDir, conf.SgxCollateral.RootCaFingerprint, conf.SgxCollateral.AllowExpired)
+	if sgx.VerifiesOffline() {
+		log.Printf("Verifying SGX reports offline with the collateral in %s\n", conf.SgxCollateral.CacheDir)
+	}
+
+	snp.Init(conf.Snp.CertCacheDir, conf.Snp.ArkFingerprints)
+
+	// TD quotes are verified with the TDX collateral in the SGX collateral cache
+	tdx.Init(conf.SgxCollateral.CacheDir, conf.SgxCollateral.RootCaFingerprint, conf.SgxCollateral.AllowExpired)
+
+	wasmWrapper, closeWrapper, err := aleo.NewWrapper()
 	if err != nil {
 		log.Fatalln("Failed to initialize Aleo wrapper:", err)
 	}
-	defer close()
+	defer closeWrapper()
+
+	// messages are formatted and recovered in Go, only hashed in WASM. The usage of the wrapper is reported in /info.
+	aleoWrapper := aleo.NewMeteredWrapper(aleo.NewNativeWrapper(wasmWrapper))
+
+	var replays *replay.Store
+	if conf.Replay.Enabled {
+		replays, err = replay.NewStore(conf.Replay.Backend, conf.Replay.Path, time.Duration(conf.Replay.TtlSeconds)*time.Second)
+		if err != nil {
+			log.Fatalln("Failed to initialize replay store:", err)
+		}
+		defer replays.Close()
+
+		log.Printf("Recording verified reports using %s store, policy for reports seen before: %s\n", conf.Replay.Backend, conf.Replay.Policy)
+	}
+
+	var audit *auditor.Auditor
+	if conf.Auditor.Enabled {
+		var auditStore *auditor.Store
+		if conf.Auditor.StorePath != "" {
+			auditStore, err = auditor.NewFileStore(conf.Auditor.StorePath, conf.Auditor.HistorySize)
+			if err != nil {
+				log.Fatalln("Failed to initialize auditor store:", err)
+			}
+			defer auditStore.Close()
+		} else {
+			auditStore = auditor.NewMemoryStore(conf.Auditor.HistorySize)
+		}
+
+		audit = auditor.New(aleoWrapper, nodeClients[config.DEFAULT_PROFILE_NAME], conf.LiveCheck.ContractName, policies[config.DEFAULT_PROFILE_NAME], auditStore, conf.Auditor.StartHeight, time.Duration(conf.Auditor.PollIntervalSeconds)*time.Second, conf.Auditor.AlertWebhookUrl)
+
+		go audit.Run(ctx)
+	}
 
-	mux := api.CreateApi(aleo, conf)
+	mux := api.CreateApi(aleoWrapper, conf, policies, nodeClients, liveChecks, replays, audit)
 
 	bindAddr := fmt.Sprintf(":%d", conf.Port)
 
@@ -86,7 +176,26 @@ func main() {
 		Handler:           mux,
 	}
 
+	// the deferred closing of the stores only runs once the in-flight requests are done
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+
+		log.Println("oracle-verification-backend: shutting down http server")
+
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Second*ShutdownTimeout)
+		defer cancelShutdown()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Println("Failed to shut down http server:", err)
+		}
+	}()
+
 	log.Printf("oracle-verification-backend: starting http server on %s\n", bindAddr)
-	log.Fatalln(server.ListenAndServe())
-	
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatalln(err)
+	}
+
+	<-shutdownDone
 }
@@ -0,0 +1,273 @@
+// Package node implements a client for the Aleo node API that is shared by the live check and the chain features.
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// placeholder for the base URL in a request path, e.g. "{apiBaseUrl}/program/{contractName}"
+const BASE_URL_PLACEHOLDER = "{apiBaseUrl}"
+
+const (
+	DEFAULT_MAX_RETRIES       = 4
+	DEFAULT_INITIAL_BACKOFF   = time.Millisecond * 500
+	DEFAULT_MAX_BACKOFF       = time.Second * 30
+	DEFAULT_MAX_RESPONSE_SIZE = 1024 * 1024 * 8 // 8MB
+	DEFAULT_REQUEST_TIMEOUT   = time.Second * 30
+
+	// the longest Retry-After the client waits for before giving up
+	maxRetryAfter = time.Minute * 2
+)
+
+var (
+	ErrNoBaseUrls       = errors.New("node: no Aleo node API base URLs configured")
+	ErrNotFound         = errors.New("node: Aleo node API responded with 404 Not Found")
+	ErrResponseTooLarge = errors.New("node: Aleo node API response exceeds the size limit")
+)
+
+// StatusError is returned when the Aleo node API responds with an unexpected status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("node: Aleo node API responded with %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Options configures a Client. Zero values are replaced with the defaults.
+type Options struct {
+	// Headers are added to every request, e.g. for authenticating with a paid RPC provider
+	Headers map[string]string
+	// MaxRetries is the number of retries after the first attempt. Negative means no retries.
+	MaxRetries      int
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+	MaxResponseSize int64
+	// Timeout of every single request
+	Timeout time.Duration
+}
+
+// Client requests the Aleo node API. Failed requests are retried with exponential backoff with jitter, moving to the
+// next base URL. The client is safe for concurrent use.
+type Client struct {
+	baseUrls   []string
+	options    Options
+	httpClient *http.Client
+
+	// replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewClient creates a client for the Aleo node API. The first base URL is preferred, the rest are used for failover.
+func NewClient(baseUrls []string, options Options) (*Client, error) {
+	urls := make([]string, 0, len(baseUrls))
+	for _, baseUrl := range baseUrls {
+		if baseUrl == "" {
+			continue
+		}
+		urls = append(urls, strings.TrimSuffix(baseUrl, "/"))
+	}
+
+	if len(urls) == 0 {
+		return nil, ErrNoBaseUrls
+	}
+
+	if options.MaxRetries == 0 {
+		options.MaxRetries = DEFAULT_MAX_RETRIES
+	}
+	if options.InitialBackoff <= 0 {
+		options.InitialBackoff = DEFAULT_INITIAL_BACKOFF
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = DEFAULT_MAX_BACKOFF
+	}
+	if options.MaxResponseSize <= 0 {
+		options.MaxResponseSize = DEFAULT_MAX_RESPONSE_SIZE
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DEFAULT_REQUEST_TIMEOUT
+	}
+
+	return &Client{
+		baseUrls: urls,
+		options:  options,
+		httpClient: &http.Client{
+			Timeout: options.Timeout,
+		},
+		sleep: sleep,
+	}, nil
+}
+
+// BaseUrl returns the preferred base URL
+func (c *Client) BaseUrl() string {
+	return c.baseUrls[0]
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// creates the request URL for a path, which is either relative to the base URL or has the base URL placeholder
+func requestUrl(baseUrl, path string) string {
+	if strings.Contains(path, BASE_URL_PLACEHOLDER) {
+		return strings.Replace(path, BASE_URL_PLACEHOLDER, baseUrl, 1)
+	}
+
+	return baseUrl + path
+}
+
+// full jitter backoff for a retry attempt, starting from 0
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := c.options.MaxBackoff
+	if attempt < 32 {
+		backoff = min(c.options.InitialBackoff<<attempt, c.options.MaxBackoff)
+	}
+
+	return time.Duration(rand.Int63n(int64(backoff))) + 1
+}
+
+// parses the Retry-After header, which is either a number of seconds or an HTTP date
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+func retryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// the result of a single request
+type attempt struct {
+	body       []byte
+	err        error
+	retryable  bool
+	retryAfter time.Duration
+}
+
+func (c *Client) do(ctx context.Context, url string) attempt {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return attempt{err: err}
+	}
+
+	for key, value := range c.options.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return attempt{err: err, retryable: ctx.Err() == nil}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// the node or the other nodes may not have caught up with e.g. a new transaction yet
+		return attempt{err: ErrNotFound, retryable: true}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		result := attempt{
+			err:       &StatusError{StatusCode: resp.StatusCode},
+			retryable: retryableStatus(resp.StatusCode),
+		}
+		result.retryAfter, _ = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return result
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.options.MaxResponseSize+1))
+	if err != nil {
+		return attempt{err: err, retryable: ctx.Err() == nil}
+	}
+
+	if int64(len(body)) > c.options.MaxResponseSize {
+		return attempt{err: ErrResponseTooLarge}
+	}
+
+	return attempt{body: body}
+}
+
+// Get requests a path from the Aleo node API. The path is either relative to the base URL, e.g. "/latest/height",
+// or has the base URL placeholder.
+func (c *Client) Get(ctx context.Context, path string) ([]byte, error) {
+	for retry := 0; ; retry++ {
+		baseUrl := c.baseUrls[retry%len(c.baseUrls)]
+		url := requestUrl(baseUrl, path)
+
+		result := c.do(ctx, url)
+		if result.err == nil {
+			return result.body, nil
+		}
+
+		if !result.retryable || retry >= c.options.MaxRetries {
+			return nil, result.err
+		}
+
+		wait := c.backoff(retry)
+		if result.retryAfter > maxRetryAfter {
+			return nil, result.err
+		}
+		wait = max(wait, result.retryAfter)
+
+		// failover to the next node right away unless it's the same one
+		if len(c.baseUrls) > 1 && result.retryAfter == 0 && (retry+1)%len(c.baseUrls) != 0 {
+			wait = 0
+		}
+
+		log.Printf("node: requesting %s failed: %v, retrying in %s\n", url, result.err, wait)
+
+		if wait > 0 {
+			if err := c.sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+		} else if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// GetJSON requests a path from the Aleo node API and decodes the JSON response into result
+func (c *Client) GetJSON(ctx context.Context, path string, result interface{}) error {
+	body, err := c.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, result)
+}
@@ -0,0 +1,216 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// creates a client that records the backoff instead of sleeping
+func newTestClient(t *testing.T, baseUrls []string, options Options) (*Client, *[]time.Duration) {
+	client, err := NewClient(baseUrls, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waits := new([]time.Duration)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return ctx.Err()
+	}
+
+	return client, waits
+}
+
+// creates a server that responds with the statuses in order, then with 200 and the body
+func newStatusServer(statuses []int, header http.Header, body string) (*httptest.Server, *int) {
+	requests := new(int)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if *requests <= len(statuses) {
+			for key := range header {
+				w.Header().Set(key, header.Get(key))
+			}
+			w.WriteHeader(statuses[*requests-1])
+			return
+		}
+		w.Write([]byte(body))
+	})), requests
+}
+
+func TestClientGet(t *testing.T) {
+	t.Run("retries with Retry-After", func(t *testing.T) {
+		server, requests := newStatusServer([]int{http.StatusTooManyRequests, http.StatusServiceUnavailable}, http.Header{"Retry-After": {"7"}}, "42")
+		defer server.Close()
+
+		client, waits := newTestClient(t, []string{server.URL}, Options{InitialBackoff: time.Millisecond})
+
+		var height uint64
+		if err := client.GetJSON(context.Background(), "/latest/height", &height); err != nil || height != 42 {
+			t.Fatalf("GetJSON() = %v, %v, want 42", height, err)
+		}
+		if *requests != 3 {
+			t.Errorf("requests = %d, want 3", *requests)
+		}
+		for _, wait := range *waits {
+			if wait != 7*time.Second {
+				t.Errorf("waited %s, want 7s", wait)
+			}
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		server, requests := newStatusServer([]int{500, 500, 500}, nil, "")
+		defer server.Close()
+
+		client, waits := newTestClient(t, []string{server.URL}, Options{MaxRetries: 2, InitialBackoff: time.Second, MaxBackoff: time.Second * 2})
+
+		_, err := client.Get(context.Background(), "/latest/height")
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != 500 {
+			t.Fatalf("Get() error = %v, want a 500 status error", err)
+		}
+		if *requests != 3 || len(*waits) != 2 {
+			t.Errorf("requests = %d, waits = %d, want 3 and 2", *requests, len(*waits))
+		}
+		for idx, wait := range *waits {
+			if wait <= 0 || wait > time.Second<<idx {
+				t.Errorf("backoff %d = %s, want in (0, %s]", idx, wait, time.Second<<idx)
+			}
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		server, requests := newStatusServer([]int{http.StatusBadRequest}, nil, "")
+		defer server.Close()
+
+		client, _ := newTestClient(t, []string{server.URL}, Options{})
+
+		if _, err := client.Get(context.Background(), "/"); err == nil {
+			t.Fatal("Get() succeeded, want an error")
+		}
+		if *requests != 1 {
+			t.Errorf("requests = %d, want 1", *requests)
+		}
+	})
+
+	t.Run("fails over to the next node", func(t *testing.T) {
+		broken, _ := newStatusServer([]int{502, 502}, nil, "")
+		defer broken.Close()
+		missing, _ := newStatusServer([]int{404, 404}, nil, "")
+		defer missing.Close()
+		healthy, _ := newStatusServer(nil, nil, `"value"`)
+		defer healthy.Close()
+
+		client, waits := newTestClient(t, []string{broken.URL, missing.URL, healthy.URL}, Options{})
+
+		body, err := client.Get(context.Background(), "{apiBaseUrl}/program/test.aleo")
+		if err != nil || string(body) != `"value"` {
+			t.Fatalf("Get() = %s, %v", body, err)
+		}
+		if len(*waits) != 0 {
+			t.Errorf("waited %v before failover, want no waits", *waits)
+		}
+	})
+
+	t.Run("retries not found on the same node", func(t *testing.T) {
+		server, requests := newStatusServer([]int{404}, nil, `"value"`)
+		defer server.Close()
+
+		client, waits := newTestClient(t, []string{server.URL}, Options{InitialBackoff: time.Second})
+
+		body, err := client.Get(context.Background(), "/transaction/at1")
+		if err != nil || string(body) != `"value"` {
+			t.Fatalf("Get() = %s, %v", body, err)
+		}
+		if *requests != 2 || len(*waits) != 1 {
+			t.Errorf("requests = %d, waits = %d, want 2 and 1", *requests, len(*waits))
+		}
+	})
+
+	t.Run("not found on every node", func(t *testing.T) {
+		first, firstRequests := newStatusServer([]int{404, 404}, nil, "")
+		defer first.Close()
+		second, secondRequests := newStatusServer([]int{404, 404}, nil, "")
+		defer second.Close()
+
+		client, _ := newTestClient(t, []string{first.URL, second.URL}, Options{MaxRetries: 3})
+
+		if _, err := client.Get(context.Background(), "/transaction/at1"); err != ErrNotFound {
+			t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+		}
+		if *firstRequests != 2 || *secondRequests != 2 {
+			t.Errorf("requests = %d and %d, want 2 and 2", *firstRequests, *secondRequests)
+		}
+	})
+
+	t.Run("response size limit and headers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Api-Key") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write(make([]byte, 11))
+		}))
+		defer server.Close()
+
+		client, _ := newTestClient(t, []string{server.URL}, Options{MaxResponseSize: 10})
+		if _, err := client.Get(context.Background(), "/"); err == nil || err == ErrResponseTooLarge {
+			t.Errorf("Get() without the header error = %v, want an unauthorized error", err)
+		}
+
+		client, _ = newTestClient(t, []string{server.URL}, Options{MaxResponseSize: 10, Headers: map[string]string{"X-Api-Key": "secret"}})
+		if _, err := client.Get(context.Background(), "/"); err != ErrResponseTooLarge {
+			t.Errorf("Get() error = %v, want %v", err, ErrResponseTooLarge)
+		}
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		server, requests := newStatusServer([]int{500, 500}, nil, "")
+		defer server.Close()
+
+		client, _ := newTestClient(t, []string{server.URL}, Options{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		client.sleep = func(ctx context.Context, d time.Duration) error {
+			cancel()
+			return ctx.Err()
+		}
+
+		if _, err := client.Get(ctx, "/"); err != context.Canceled {
+			t.Errorf("Get() error = %v, want %v", err, context.Canceled)
+		}
+		if *requests != 1 {
+			t.Errorf("requests = %d, want 1", *requests)
+		}
+	})
+}
+
+func Test_retryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "empty", header: ""},
+		{name: "seconds", header: "120", want: time.Minute * 2, wantOk: true},
+		{name: "negative", header: "-1"},
+		{name: "date", header: now.Add(time.Second * 30).Format(http.TimeFormat), want: time.Second * 30, wantOk: true},
+		{name: "past date", header: now.Add(-time.Hour).Format(http.TimeFormat), want: 0, wantOk: true},
+		{name: "invalid", header: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.header, now)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package nonce
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// size of the issued nonces in bytes
+	NONCE_SIZE = 32
+
+	// maximum number of outstanding nonces, protects from exhausting memory
+	MAX_OUTSTANDING_NONCES = 100_000
+
+	// maximum number of outstanding nonces of a single client, so that one client cannot take all of them
+	MAX_OUTSTANDING_NONCES_PER_CLIENT = 100
+)
+
+var (
+	ErrTooManyNonces       = errors.New("nonce: too many outstanding nonces, try again later")
+	ErrTooManyClientNonces = errors.New("nonce: too many outstanding nonces for this client, try again later")
+)
+
+// an outstanding nonce
+type issuedNonce struct {
+	nonce     string
+	client    string
+	expiresAt time.Time
+}
+
+// Store issues short-lived random nonces and keeps track of them until they are consumed or expire.
+// It is safe for concurrent use.
+type Store struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu     sync.Mutex
+	nonces map[string]issuedNonce
+	// the issued nonces in the order they expire in, including the consumed ones
+	queue []issuedNonce
+	// the number of outstanding nonces by client
+	clients map[string]int
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		now:     time.Now,
+		nonces:  make(map[string]issuedNonce),
+		clients: make(map[string]int),
+	}
+}
+
+// removes an outstanding nonce, must be called with the lock held
+func (s *Store) remove(issued issuedNonce) {
+	delete(s.nonces, issued.nonce)
+
+	s.clients[issued.client]--
+	if s.clients[issued.client] <= 0 {
+		delete(s.clients, issued.client)
+	}
+}
+
+// removes the nonces that expired since the last call, must be called with the lock held
+func (s *Store) expire(now time.Time) {
+	for len(s.queue) != 0 && !now.Before(s.queue[0].expiresAt) {
+		// the nonce may have been consumed already
+		if _, ok := s.nonces[s.queue[0].nonce]; ok {
+			s.remove(s.queue[0])
+		}
+		s.queue[0] = issuedNonce{}
+		s.queue = s.queue[1:]
+	}
+}
+
+// Issue creates a new hex-encoded nonce for the client, e.g. its IP address, and returns it with its expiration time
+func (s *Store) Issue(client string) (string, time.Time, error) {
+	buf := make([]byte, NONCE_SIZE)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	nonce := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.expire(now)
+
+	if s.clients[client] >= MAX_OUTSTANDING_NONCES_PER_CLIENT {
+		return "", time.Time{}, ErrTooManyClientNonces
+	}
+	if len(s.nonces) >= MAX_OUTSTANDING_NONCES {
+		return "", time.Time{}, ErrTooManyNonces
+	}
+
+	// all nonces have the same TTL, so the queue stays ordered by the expiration time
+	issued := issuedNonce{nonce: nonce, client: client, expiresAt: now.Add(s.ttl)}
+	s.nonces[nonce] = issued
+	s.queue = append(s.queue, issued)
+	s.clients[client]++
+
+	return nonce, issued.expiresAt, nil
+}
+
+// Consume checks that the hex-encoded nonce was issued and has not expired, then removes it so that it cannot be used again.
+func (s *Store) Consume(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issued, ok := s.nonces[nonce]
+	if !ok {
+		return false
+	}
+
+	s.remove(issued)
+
+	return s.now().Before(issued.expiresAt)
+}
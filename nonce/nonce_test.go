@@ -0,0 +1,96 @@
+package nonce
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Unix(1701851063, 0)
+
+	store := NewStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	first, expiresAt, err := store.Issue("client")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if len(first) != NONCE_SIZE*2 {
+		t.Errorf("Issue() nonce length = %d, want %d", len(first), NONCE_SIZE*2)
+	}
+	if !expiresAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Issue() expiresAt = %v, want %v", expiresAt, now.Add(time.Minute))
+	}
+
+	second, _, err := store.Issue("client")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	if store.Consume("00") {
+		t.Error("Consume() accepted a nonce that was not issued")
+	}
+	if !store.Consume(first) {
+		t.Error("Consume() rejected an issued nonce")
+	}
+	if store.Consume(first) {
+		t.Error("Consume() accepted a nonce twice")
+	}
+
+	now = now.Add(time.Minute)
+	if store.Consume(second) {
+		t.Error("Consume() accepted an expired nonce")
+	}
+
+	// expired nonces are removed when issuing new ones
+	store.Issue("client")
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.nonces) != 1 || len(store.queue) != 1 || store.clients["client"] != 1 {
+		t.Errorf("expected expired nonces to be removed, have %d nonces, %d queued, %d of the client", len(store.nonces), len(store.queue), store.clients["client"])
+	}
+}
+
+func TestStore_clientLimit(t *testing.T) {
+	now := time.Unix(1701851063, 0)
+
+	store := NewStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	var first string
+	for idx := 0; idx < MAX_OUTSTANDING_NONCES_PER_CLIENT; idx++ {
+		nonce, _, err := store.Issue("client")
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+		if idx == 0 {
+			first = nonce
+		}
+		now = now.Add(time.Millisecond)
+	}
+
+	if _, _, err := store.Issue("client"); !errors.Is(err, ErrTooManyClientNonces) {
+		t.Errorf("Issue() error = %v, want %v", err, ErrTooManyClientNonces)
+	}
+	if _, _, err := store.Issue("other client"); err != nil {
+		t.Errorf("Issue() for another client error = %v", err)
+	}
+
+	// consuming a nonce frees a slot of the client
+	if !store.Consume(first) {
+		t.Fatal("Consume() rejected an issued nonce")
+	}
+	if _, _, err := store.Issue("client"); err != nil {
+		t.Errorf("Issue() after consuming error = %v", err)
+	}
+
+	// and so does expiring
+	if _, _, err := store.Issue("client"); !errors.Is(err, ErrTooManyClientNonces) {
+		t.Errorf("Issue() error = %v, want %v", err, ErrTooManyClientNonces)
+	}
+	now = now.Add(time.Minute - time.Duration(MAX_OUTSTANDING_NONCES_PER_CLIENT-2)*time.Millisecond)
+	if _, _, err := store.Issue("client"); err != nil {
+		t.Errorf("Issue() after expiring error = %v", err)
+	}
+}
@@ -0,0 +1,498 @@
+// Package plaintext parses Aleo plaintext values, as returned by the Aleo node API for mapping values and transition inputs,
+// into a tree of typed values.
+package plaintext
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Kind is the kind of a plaintext value
+type Kind int
+
+const (
+	KIND_LITERAL Kind = iota
+	KIND_STRUCT
+	KIND_ARRAY
+)
+
+// Literal types
+const (
+	TYPE_ADDRESS = "address"
+	TYPE_BOOLEAN = "boolean"
+	TYPE_FIELD   = "field"
+	TYPE_GROUP   = "group"
+	TYPE_SCALAR  = "scalar"
+)
+
+// the maximum nesting of structs and arrays, same as in snarkVM
+const maxDepth = 32
+
+const addressPrefix = "aleo1"
+
+// length of a bech32m-encoded address
+const addressLength = 63
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var (
+	ErrUnexpectedKind  = errors.New("plaintext: unexpected kind of value")
+	ErrUnexpectedType  = errors.New("plaintext: unexpected literal type")
+	ErrNoSuchMember    = errors.New("plaintext: struct has no such member")
+	ErrIndexOutOfRange = errors.New("plaintext: array index out of range")
+	ErrOutOfRange      = errors.New("plaintext: value is out of range")
+)
+
+// Member is a named member of a struct
+type Member struct {
+	Name  string
+	Value *Value
+}
+
+// Value is a parsed plaintext value. Literals have the Type and the Literal set, structs have the Members,
+// arrays have the Elements.
+type Value struct {
+	Kind Kind
+	// literal type, e.g. "u128", "field" or "address"
+	Type string
+	// literal without the type suffix, e.g. "-5" for "-5i8" or "aleo1..." for an address
+	Literal  string
+	Members  []Member
+	Elements []*Value
+
+	// parsed numeric literal
+	number *big.Int
+}
+
+// integer type bit sizes
+var integerBits = map[string]uint{
+	"u8": 8, "u16": 16, "u32": 32, "u64": 64, "u128": 128,
+	"i8": 8, "i16": 16, "i32": 32, "i64": 64, "i128": 128,
+}
+
+// numeric literal type suffixes, longest first so that "u128" is matched before "u8"
+var numericSuffixes = []string{
+	"u128", "i128", "u16", "u32", "u64", "i16", "i32", "i64", "u8", "i8",
+	TYPE_FIELD, TYPE_GROUP, TYPE_SCALAR,
+}
+
+// IsInteger returns true if the value is an integer literal
+func (v *Value) IsInteger() bool {
+	_, ok := integerBits[v.Type]
+	return v.Kind == KIND_LITERAL && ok
+}
+
+// Member returns the value of a struct member
+func (v *Value) Member(name string) (*Value, error) {
+	if v.Kind != KIND_STRUCT {
+		return nil, ErrUnexpectedKind
+	}
+
+	for _, member := range v.Members {
+		if member.Name == name {
+			return member.Value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNoSuchMember, name)
+}
+
+// Path returns a nested struct member, e.g. Path("a", "b") returns the member b of the struct in member a
+func (v *Value) Path(names ...string) (*Value, error) {
+	value := v
+	for _, name := range names {
+		var err error
+		value, err = value.Member(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// Index returns an array element
+func (v *Value) Index(idx int) (*Value, error) {
+	if v.Kind != KIND_ARRAY {
+		return nil, ErrUnexpectedKind
+	}
+
+	if idx < 0 || idx >= len(v.Elements) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	return v.Elements[idx], nil
+}
+
+// Len returns the number of struct members or array elements
+func (v *Value) Len() int {
+	switch v.Kind {
+	case KIND_STRUCT:
+		return len(v.Members)
+	case KIND_ARRAY:
+		return len(v.Elements)
+	default:
+		return 0
+	}
+}
+
+func (v *Value) literal(typ string) error {
+	if v.Kind != KIND_LITERAL {
+		return ErrUnexpectedKind
+	}
+
+	if typ != "" && v.Type != typ {
+		return fmt.Errorf("%w: expected %s, got %s", ErrUnexpectedType, typ, v.Type)
+	}
+
+	return nil
+}
+
+// Bool returns the value of a boolean literal
+func (v *Value) Bool() (bool, error) {
+	if err := v.literal(TYPE_BOOLEAN); err != nil {
+		return false, err
+	}
+
+	return v.Literal == "true", nil
+}
+
+// Address returns an address literal
+func (v *Value) Address() (string, error) {
+	if err := v.literal(TYPE_ADDRESS); err != nil {
+		return "", err
+	}
+
+	return v.Literal, nil
+}
+
+// Field returns the value of a field literal
+func (v *Value) Field() (*big.Int, error) {
+	if err := v.literal(TYPE_FIELD); err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Set(v.number), nil
+}
+
+// BigInt returns the value of an integer literal of any type
+func (v *Value) BigInt() (*big.Int, error) {
+	if err := v.literal(""); err != nil {
+		return nil, err
+	}
+
+	if !v.IsInteger() {
+		return nil, fmt.Errorf("%w: expected an integer, got %s", ErrUnexpectedType, v.Type)
+	}
+
+	return new(big.Int).Set(v.number), nil
+}
+
+// Uint128 returns the value of a u128 literal
+func (v *Value) Uint128() (*big.Int, error) {
+	if err := v.literal("u128"); err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Set(v.number), nil
+}
+
+// Uint64 returns the value of an unsigned integer literal that fits into 64 bits
+func (v *Value) Uint64() (uint64, error) {
+	num, err := v.BigInt()
+	if err != nil {
+		return 0, err
+	}
+
+	if !num.IsUint64() {
+		return 0, ErrOutOfRange
+	}
+
+	return num.Uint64(), nil
+}
+
+// Int64 returns the value of an integer literal that fits into 64 bits
+func (v *Value) Int64() (int64, error) {
+	num, err := v.BigInt()
+	if err != nil {
+		return 0, err
+	}
+
+	if !num.IsInt64() {
+		return 0, ErrOutOfRange
+	}
+
+	return num.Int64(), nil
+}
+
+// String formats the value as a single-line plaintext
+func (v *Value) String() string {
+	var sb strings.Builder
+	v.format(&sb)
+	return sb.String()
+}
+
+func (v *Value) format(sb *strings.Builder) {
+	switch v.Kind {
+	case KIND_STRUCT:
+		sb.WriteString("{ ")
+		for idx, member := range v.Members {
+			if idx > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(member.Name)
+			sb.WriteString(": ")
+			member.Value.format(sb)
+		}
+		sb.WriteString(" }")
+	case KIND_ARRAY:
+		sb.WriteString("[ ")
+		for idx, element := range v.Elements {
+			if idx > 0 {
+				sb.WriteString(", ")
+			}
+			element.format(sb)
+		}
+		sb.WriteString(" ]")
+	default:
+		sb.WriteString(v.Literal)
+		if v.Type != TYPE_ADDRESS && v.Type != TYPE_BOOLEAN {
+			sb.WriteString(v.Type)
+		}
+	}
+}
+
+// Parse parses an Aleo plaintext value, e.g. "{ chunk_1: 1u128, chunk_2: [ true, false ] }"
+func Parse(input string) (*Value, error) {
+	p := &parser{input: input}
+
+	value, err := p.value(0)
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+	if p.pos != len(p.input) {
+		return nil, p.errorf("unexpected trailing input")
+	}
+
+	return value, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("plaintext: "+format+" at offset %d", append(args, p.pos)...)
+}
+
+func (p *parser) skipWhitespace() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// skips whitespace and consumes the character c if it's next
+func (p *parser) consume(c byte) bool {
+	p.skipWhitespace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func isIdentifierChar(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return true
+	case c >= '0' && c <= '9', c == '_':
+		return !first
+	default:
+		return false
+	}
+}
+
+func (p *parser) value(depth int) (*Value, error) {
+	if depth > maxDepth {
+		return nil, p.errorf("value is nested too deep")
+	}
+
+	p.skipWhitespace()
+	if p.pos == len(p.input) {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	switch p.input[p.pos] {
+	case '{':
+		p.pos++
+		return p.structValue(depth)
+	case '[':
+		p.pos++
+		return p.arrayValue(depth)
+	default:
+		return p.literalValue()
+	}
+}
+
+func (p *parser) structValue(depth int) (*Value, error) {
+	value := &Value{Kind: KIND_STRUCT}
+
+	for {
+		p.skipWhitespace()
+
+		start := p.pos
+		for p.pos < len(p.input) && isIdentifierChar(p.input[p.pos], p.pos == start) {
+			p.pos++
+		}
+		if p.pos == start {
+			return nil, p.errorf("expected a struct member name")
+		}
+		name := p.input[start:p.pos]
+
+		for _, member := range value.Members {
+			if member.Name == name {
+				return nil, p.errorf("duplicate struct member %q", name)
+			}
+		}
+
+		if !p.consume(':') {
+			return nil, p.errorf("expected ':'")
+		}
+
+		member, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value.Members = append(value.Members, Member{Name: name, Value: member})
+
+		if p.consume('}') {
+			return value, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (p *parser) arrayValue(depth int) (*Value, error) {
+	value := &Value{Kind: KIND_ARRAY}
+
+	for {
+		element, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value.Elements = append(value.Elements, element)
+
+		if p.consume(']') {
+			return value, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *parser) literalValue() (*Value, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (isIdentifierChar(p.input[p.pos], false) || p.input[p.pos] == '-') {
+		p.pos++
+	}
+	token := p.input[start:p.pos]
+
+	if token == "" {
+		return nil, p.errorf("unexpected character %q", p.input[p.pos])
+	}
+
+	switch {
+	case token == "true" || token == "false":
+		return &Value{Kind: KIND_LITERAL, Type: TYPE_BOOLEAN, Literal: token}, nil
+	case strings.HasPrefix(token, addressPrefix):
+		if !isAddress(token) {
+			return nil, p.errorf("invalid address %q", token)
+		}
+		return &Value{Kind: KIND_LITERAL, Type: TYPE_ADDRESS, Literal: token}, nil
+	}
+
+	for _, suffix := range numericSuffixes {
+		if !strings.HasSuffix(token, suffix) {
+			continue
+		}
+
+		digits := token[:len(token)-len(suffix)]
+		number, ok := parseNumber(digits)
+		if !ok {
+			return nil, p.errorf("invalid %s literal %q", suffix, token)
+		}
+
+		if bits, isInteger := integerBits[suffix]; isInteger && !integerInRange(number, bits, suffix[0] == 'i') {
+			return nil, p.errorf("%s literal %q is out of range", suffix, token)
+		}
+
+		// normalize the literal, e.g. drop the digit separators and leading zeros
+		return &Value{Kind: KIND_LITERAL, Type: suffix, Literal: number.String(), number: number}, nil
+	}
+
+	return nil, p.errorf("unknown literal %q", token)
+}
+
+// parses an optionally negative decimal number with optional '_' digit separators
+func parseNumber(digits string) (*big.Int, bool) {
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+
+	if digits == "" || digits[0] < '0' || digits[0] > '9' {
+		return nil, false
+	}
+
+	for idx := 0; idx < len(digits); idx++ {
+		if (digits[idx] < '0' || digits[idx] > '9') && digits[idx] != '_' {
+			return nil, false
+		}
+	}
+
+	number, ok := new(big.Int).SetString(strings.ReplaceAll(digits, "_", ""), 10)
+	if !ok {
+		return nil, false
+	}
+
+	if negative {
+		number.Neg(number)
+	}
+
+	return number, true
+}
+
+func integerInRange(number *big.Int, bits uint, signed bool) bool {
+	if !signed {
+		return number.Sign() >= 0 && number.BitLen() <= int(bits)
+	}
+
+	// -2^(bits-1) <= number < 2^(bits-1)
+	limit := new(big.Int).Lsh(big.NewInt(1), bits-1)
+	return number.Cmp(limit) < 0 && number.Cmp(limit.Neg(limit)) >= 0
+}
+
+func isAddress(token string) bool {
+	if len(token) != addressLength {
+		return false
+	}
+
+	for _, c := range token[len(addressPrefix):] {
+		if !strings.ContainsRune(bech32Charset, c) {
+			return false
+		}
+	}
+
+	return true
+}
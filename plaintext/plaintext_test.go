@@ -0,0 +1,185 @@
+package plaintext
+
+import (
+	"errors"
+	"testing"
+)
+
+const testAddress = "aleo1rhgdu77hgyqd3xjj8ucu3jj9r2krwz6mnzyd80gncr5fxcwlh5rsvzp9px"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "multi-line struct",
+			input: "{\n  chunk_1: 31929802673692760512905395015836068420u128,\n  chunk_2: 335853521753947303372057454886636012152u128\n}",
+			want:  "{ chunk_1: 31929802673692760512905395015836068420u128, chunk_2: 335853521753947303372057454886636012152u128 }",
+		},
+		{
+			name:  "nested struct with arrays",
+			input: "{ a: { b: [ 1u8, 2u8 ], c: true }, owner: " + testAddress + ", f: 0field }",
+			want:  "{ a: { b: [ 1u8, 2u8 ], c: true }, owner: " + testAddress + ", f: 0field }",
+		},
+		{
+			name:  "literals are normalized",
+			input: "[-128i8,1_000u64,007u32,-0i16]",
+			want:  "[ -128i8, 1000u64, 7u32, 0i16 ]",
+		},
+		{
+			name:  "u128 max",
+			input: "340282366920938463463374607431768211455u128",
+			want:  "340282366920938463463374607431768211455u128",
+		},
+		{
+			name:    "u128 overflow",
+			input:   "340282366920938463463374607431768211456u128",
+			wantErr: true,
+		},
+		{
+			name:    "i8 underflow",
+			input:   "-129i8",
+			wantErr: true,
+		},
+		{
+			name:    "negative unsigned",
+			input:   "-1u8",
+			wantErr: true,
+		},
+		{
+			name:    "missing type suffix",
+			input:   "{ a: 1 }",
+			wantErr: true,
+		},
+		{
+			name:    "invalid address",
+			input:   "aleo1abc",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate member",
+			input:   "{ a: 1u8, a: 2u8 }",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated struct",
+			input:   "{ a: 1u8",
+			wantErr: true,
+		},
+		{
+			name:    "empty array",
+			input:   "[]",
+			wantErr: true,
+		},
+		{
+			name:    "trailing input",
+			input:   "1u8 2u8",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("Parse() = %v, want %v", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValueAccessors(t *testing.T) {
+	value, err := Parse("{ a: { b: [ 1u8, 2u8 ], c: true }, owner: " + testAddress + ", f: 5field, n: -3i64, big: 5u128 }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	element, err := value.Path("a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if element.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", element.Len())
+	}
+	if element, err = element.Index(1); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := element.Uint64(); err != nil || n != 2 {
+		t.Errorf("Uint64() = %v, %v, want 2", n, err)
+	}
+
+	if c, err := value.Path("a", "c"); err != nil {
+		t.Error(err)
+	} else if b, err := c.Bool(); err != nil || !b {
+		t.Errorf("Bool() = %v, %v, want true", b, err)
+	}
+
+	owner, _ := value.Member("owner")
+	if address, err := owner.Address(); err != nil || address != testAddress {
+		t.Errorf("Address() = %v, %v", address, err)
+	}
+
+	f, _ := value.Member("f")
+	if num, err := f.Field(); err != nil || num.Int64() != 5 {
+		t.Errorf("Field() = %v, %v, want 5", num, err)
+	}
+	if _, err := f.BigInt(); !errors.Is(err, ErrUnexpectedType) {
+		t.Errorf("BigInt() on a field error = %v, want %v", err, ErrUnexpectedType)
+	}
+
+	n, _ := value.Member("n")
+	if num, err := n.Int64(); err != nil || num != -3 {
+		t.Errorf("Int64() = %v, %v, want -3", num, err)
+	}
+	if _, err := n.Uint64(); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Uint64() on a negative value error = %v, want %v", err, ErrOutOfRange)
+	}
+
+	big, _ := value.Member("big")
+	if num, err := big.Uint128(); err != nil || num.Int64() != 5 {
+		t.Errorf("Uint128() = %v, %v, want 5", num, err)
+	}
+	if _, err := n.Uint128(); !errors.Is(err, ErrUnexpectedType) {
+		t.Errorf("Uint128() on an i64 error = %v, want %v", err, ErrUnexpectedType)
+	}
+
+	if _, err := value.Member("missing"); !errors.Is(err, ErrNoSuchMember) {
+		t.Errorf("Member() error = %v, want %v", err, ErrNoSuchMember)
+	}
+	if _, err := value.Index(0); !errors.Is(err, ErrUnexpectedKind) {
+		t.Errorf("Index() on a struct error = %v, want %v", err, ErrUnexpectedKind)
+	}
+	if _, err := element.Index(0); !errors.Is(err, ErrUnexpectedKind) {
+		t.Errorf("Index() on a literal error = %v, want %v", err, ErrUnexpectedKind)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add("{\n  chunk_1: 31929802673692760512905395015836068420u128,\n  chunk_2: 335853521753947303372057454886636012152u128\n}")
+	f.Add("{ a: { b: [ 1u8, 2u8 ], c: true }, owner: " + testAddress + ", f: 0field }")
+	f.Add("[-128i8,1_000u64,007u32,-0i16]")
+	f.Add("{ a: [ [ 1group, 2scalar ], [ 3group, 4scalar ] ] }")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		value, err := Parse(input)
+		if err != nil {
+			return
+		}
+
+		// the formatted value parses into the same value
+		formatted := value.String()
+		reparsed, err := Parse(formatted)
+		if err != nil {
+			t.Fatalf("Parse(%q) of formatted %q failed: %v", input, formatted, err)
+		}
+		if reparsed.String() != formatted {
+			t.Fatalf("Parse(%q) = %q, want %q", formatted, reparsed.String(), formatted)
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package replay
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Replay policies
+const (
+	// verified reports that were seen before are flagged in the response
+	POLICY_FLAG = "flag"
+	// verified reports that were seen before are rejected
+	POLICY_REJECT = "reject"
+)
+
+// Store backends
+const (
+	BACKEND_MEMORY = "memory"
+	BACKEND_FILE   = "file"
+)
+
+var ErrUnknownBackend = errors.New("replay: unknown store backend")
+
+// Record describes when a report was seen for the first time
+type Record struct {
+	FirstSeen time.Time `json:"firstSeen"`
+	RequestId string    `json:"requestId"`
+}
+
+// persisted entry of the file store
+type fileEntry struct {
+	Key string `json:"key"`
+	Record
+}
+
+// Store keeps track of the reports that were verified. It is safe for concurrent use.
+// With the file backend all records are appended to a file, which is read on startup. The file is rewritten without
+// the expired records on startup and when most of its entries have expired.
+type Store struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	records   map[string]Record
+	lastSweep time.Time
+	file      *os.File
+	path      string
+	// the number of entries in the file
+	fileEntries int
+}
+
+// Key creates the store key for a report from the base64-encoded attestation report and the attested data hash
+func Key(attestationReport string, dataHash []byte) string {
+	h := sha256.New()
+	h.Write([]byte(attestationReport))
+	h.Write(dataHash)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewMemoryStore creates a store that keeps the records in memory. Records expire after ttl, zero ttl means never.
+func NewMemoryStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		now:     time.Now,
+		records: make(map[string]Record),
+	}
+}
+
+// NewFileStore creates a store that persists the records in a file at path. Records expire after ttl, zero ttl means never.
+func NewFileStore(path string, ttl time.Duration) (*Store, error) {
+	s := NewMemoryStore(ttl)
+	s.path = path
+
+	file, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	now := s.now()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry fileEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Println("replay: skipping malformed entry in", path)
+			continue
+		}
+		if s.expired(entry.Record, now) {
+			continue
+		}
+		if _, ok := s.records[entry.Key]; !ok {
+			s.records[entry.Key] = entry.Record
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// rewrites the file with only the records in memory and opens it for appending, must be called with the lock held.
+// If rewriting fails, then the store keeps appending to the current file.
+func (s *Store) compact() error {
+	tmpPath := s.path + ".tmp"
+	if err := s.writeRecords(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file = file
+	s.fileEntries = len(s.records)
+
+	return nil
+}
+
+// writes the records in memory to a new file at path
+func (s *Store) writeRecords(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for key, record := range s.records {
+		line, err := json.Marshal(&fileEntry{Key: key, Record: record})
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// NewStore creates a store with the configured backend
+func NewStore(backend, path string, ttl time.Duration) (*Store, error) {
+	switch backend {
+	case BACKEND_MEMORY, "":
+		return NewMemoryStore(ttl), nil
+	case BACKEND_FILE:
+		return NewFileStore(path, ttl)
+	default:
+		return nil, ErrUnknownBackend
+	}
+}
+
+func (s *Store) expired(record Record, now time.Time) bool {
+	return s.ttl != 0 && !now.Before(record.FirstSeen.Add(s.ttl))
+}
+
+// removes expired records, must be called with the lock held
+func (s *Store) sweep(now time.Time) {
+	if s.ttl == 0 || now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+
+	for key, record := range s.records {
+		if s.expired(record, now) {
+			delete(s.records, key)
+		}
+	}
+	s.lastSweep = now
+
+	// the file keeps the expired records until it's compacted
+	if s.file != nil && s.fileEntries > 2*len(s.records) {
+		if err := s.compact(); err != nil {
+			log.Println("replay: failed to compact", s.path, err)
+		}
+	}
+}
+
+// CheckAndRecord records the key as seen by the request with requestId. If the key was seen before,
+// then returns the record of the first time it was seen and true.
+func (s *Store) CheckAndRecord(key, requestId string) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if record, ok := s.records[key]; ok && !s.expired(record, now) {
+		return record, true, nil
+	}
+
+	record := Record{
+		FirstSeen: now.UTC(),
+		RequestId: requestId,
+	}
+
+	if s.file != nil {
+		line, err := json.Marshal(&fileEntry{Key: key, Record: record})
+		if err != nil {
+			return Record{}, false, err
+		}
+		if _, err := s.file.Write(append(line, '\n')); err != nil {
+			return Record{}, false, err
+		}
+		s.fileEntries++
+	}
+
+	s.records[key] = record
+
+	return record, false, nil
+}
+
+// Close closes the file of the file store
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
@@ -0,0 +1,118 @@
+package replay
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_CheckAndRecord(t *testing.T) {
+	now := time.Unix(1701851063, 0).UTC()
+
+	store := NewMemoryStore(time.Hour)
+	store.now = func() time.Time { return now }
+
+	key := Key("report", []byte{1, 2, 3})
+
+	_, seen, err := store.CheckAndRecord(key, "first")
+	if err != nil || seen {
+		t.Fatalf("CheckAndRecord() seen = %v, err = %v, want a new record", seen, err)
+	}
+
+	now = now.Add(time.Minute)
+	record, seen, err := store.CheckAndRecord(key, "second")
+	if err != nil || !seen {
+		t.Fatalf("CheckAndRecord() seen = %v, err = %v, want a seen record", seen, err)
+	}
+	if record.RequestId != "first" || !record.FirstSeen.Equal(now.Add(-time.Minute)) {
+		t.Errorf("CheckAndRecord() record = %v, want the first record", record)
+	}
+
+	if _, seen, _ := store.CheckAndRecord(Key("report", []byte{1, 2, 4}), "third"); seen {
+		t.Error("CheckAndRecord() reported a different data hash as seen")
+	}
+
+	now = now.Add(time.Hour)
+	if _, seen, _ := store.CheckAndRecord(key, "fourth"); seen {
+		t.Error("CheckAndRecord() reported an expired record as seen")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay.jsonl")
+	key := Key("report", []byte{1, 2, 3})
+
+	store, err := NewFileStore(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if _, seen, err := store.CheckAndRecord(key, "first"); err != nil || seen {
+		t.Fatalf("CheckAndRecord() seen = %v, err = %v, want a new record", seen, err)
+	}
+	store.Close()
+
+	store, err = NewFileStore(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	defer store.Close()
+
+	record, seen, err := store.CheckAndRecord(key, "second")
+	if err != nil || !seen {
+		t.Fatalf("CheckAndRecord() seen = %v, err = %v, want a seen record", seen, err)
+	}
+	if record.RequestId != "first" {
+		t.Errorf("CheckAndRecord() record = %v, want the first record", record)
+	}
+}
+
+func TestFileStore_compact(t *testing.T) {
+	now := time.Unix(1701851063, 0).UTC()
+	path := filepath.Join(t.TempDir(), "replay.jsonl")
+
+	countEntries := func() int {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bytes.Count(data, []byte{'\n'})
+	}
+
+	store, err := NewFileStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	store.now = func() time.Time { return now }
+
+	for idx := byte(0); idx < 4; idx++ {
+		if _, _, err := store.CheckAndRecord(Key("report", []byte{idx}), "first"); err != nil {
+			t.Fatalf("CheckAndRecord() error = %v", err)
+		}
+	}
+	if got := countEntries(); got != 4 {
+		t.Errorf("file has %d entries, want 4", got)
+	}
+
+	// the sweep compacts the file once most of its records expired
+	now = now.Add(time.Hour)
+	if _, _, err := store.CheckAndRecord(Key("report", []byte{4}), "second"); err != nil {
+		t.Fatalf("CheckAndRecord() error = %v", err)
+	}
+	if got := countEntries(); got != 1 {
+		t.Errorf("file has %d entries after expiring, want 1", got)
+	}
+	store.Close()
+
+	// and so does opening the store
+	os.WriteFile(path, []byte(`{"key": "expired", "firstSeen": "2023-01-01T00:00:00Z"}`+"\n"+`malformed`+"\n"), 0600)
+	store, err = NewFileStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	defer store.Close()
+	if got := countEntries(); got != 0 {
+		t.Errorf("file has %d entries after opening, want 0", got)
+	}
+}
@@ -0,0 +1,209 @@
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+)
+
+// NitroDocument is the contents of a synthetic Nitro attestation document
+type NitroDocument struct {
+	// PCR0-2, a document with PCR0 of zeroes is a debug document
+	Pcrs [3][48]byte
+	// the user data, the Nitro verifier expects the 16-byte hash of the attested data, see UserData
+	UserData []byte
+	Nonce    []byte
+	// the time the document is created at, the current time if it's zero. The enclave certificate is valid for three
+	// hours from then, like the ones of the Nitro hypervisor.
+	Timestamp time.Time
+}
+
+// NitroCA is a throwaway root CA with an intermediate CA that issues the enclave certificates of Nitro attestation
+// documents, in place of the AWS Nitro Enclaves root
+type NitroCA struct {
+	Root         *x509.Certificate
+	Intermediate *x509.Certificate
+
+	rootKey         *ecdsa.PrivateKey
+	intermediateKey *ecdsa.PrivateKey
+}
+
+// the COSE_Sign1 structure of an attestation document
+type coseSign1 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected cbor.RawMessage
+	Payload     []byte
+	Signature   []byte
+}
+
+// the attestation document payload
+type documentPayload struct {
+	ModuleID    string          `cbor:"module_id"`
+	Digest      string          `cbor:"digest"`
+	Timestamp   uint64          `cbor:"timestamp"`
+	PCRs        map[uint][]byte `cbor:"pcrs"`
+	Certificate []byte          `cbor:"certificate"`
+	CABundle    [][]byte        `cbor:"cabundle"`
+	PublicKey   []byte          `cbor:"public_key"`
+	UserData    []byte          `cbor:"user_data"`
+	Nonce       []byte          `cbor:"nonce"`
+}
+
+func newKey(t testing.TB) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func newCertificate(t testing.TB, template *x509.Certificate, publicKey any, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) *x509.Certificate {
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = serial
+	// same as the AWS certificates
+	template.SignatureAlgorithm = x509.ECDSAWithSHA384
+
+	if issuer == nil {
+		issuer = template
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, publicKey, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return certificate
+}
+
+// NewNitroCA creates a root CA and an intermediate CA that are valid from a year ago
+func NewNitroCA(t testing.TB) *NitroCA {
+	ca := &NitroCA{rootKey: newKey(t), intermediateKey: newKey(t)}
+
+	ca.Root = newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test.nitro-enclaves"},
+		NotBefore:             time.Now().AddDate(-1, 0, 0),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, &ca.rootKey.PublicKey, nil, ca.rootKey)
+
+	ca.Intermediate = newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test.zonal.nitro-enclaves"},
+		NotBefore:             time.Now().AddDate(-1, 0, 0),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, &ca.intermediateKey.PublicKey, ca.Root, ca.rootKey)
+
+	return ca
+}
+
+// RootPem returns the PEM-encoded root certificate
+func (ca *NitroCA) RootPem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Root.Raw})
+}
+
+// WriteRootPem writes the root certificate to a temporary file and returns its path, see nitro.Init
+func (ca *NitroCA) WriteRootPem(t testing.TB) string {
+	path := filepath.Join(t.TempDir(), "nitro-root.pem")
+	if err := os.WriteFile(path, ca.RootPem(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// Document creates an attestation document, signed with a new enclave certificate
+func (ca *NitroCA) Document(t testing.TB, document NitroDocument) []byte {
+	timestamp := document.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	enclaveKey := newKey(t)
+	enclave := newCertificate(t, &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "i-0123456789abcdef0-enc0123456789abcdef.us-east-1.aws"},
+		NotBefore: timestamp,
+		NotAfter:  timestamp.Add(3 * time.Hour),
+	}, &enclaveKey.PublicKey, ca.Intermediate, ca.intermediateKey)
+
+	pcrs := make(map[uint][]byte, 16)
+	for idx := uint(0); idx < 16; idx++ {
+		pcrs[idx] = make([]byte, 48)
+	}
+	for idx := range document.Pcrs {
+		pcrs[uint(idx)] = document.Pcrs[idx][:]
+	}
+
+	payload, err := cbor.Marshal(&documentPayload{
+		ModuleID:    "i-0123456789abcdef0-enc0123456789abcdef",
+		Digest:      "SHA384",
+		Timestamp:   uint64(timestamp.UnixMilli()),
+		PCRs:        pcrs,
+		Certificate: enclave.Raw,
+		CABundle:    [][]byte{ca.Root.Raw, ca.Intermediate.Raw},
+		UserData:    document.UserData,
+		Nonce:       document.Nonce,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protected, err := cbor.Marshal(map[int]int{1: nitro.COSE_ALGORITHM_ES384})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// see https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
+	sigStructure, err := cbor.Marshal([]any{"Signature1", protected, []byte{}, payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha512.Sum384(sigStructure)
+	r, s, err := ecdsa.Sign(rand.Reader, enclaveKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := make([]byte, 2*sha512.Size384)
+	r.FillBytes(signature[:sha512.Size384])
+	s.FillBytes(signature[sha512.Size384:])
+
+	encoded, err := cbor.Marshal(&coseSign1{
+		Protected:   protected,
+		Unprotected: cbor.RawMessage{0xa0},
+		Payload:     payload,
+		Signature:   signature,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encoded
+}
@@ -0,0 +1,95 @@
+package testutil
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/edgelesssys/ego/attestation"
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+)
+
+var ErrInvalidSgxReport = errors.New("testutil: invalid SGX report signature")
+
+// SgxReport is the contents of a synthetic SGX report
+type SgxReport struct {
+	UniqueId   [32]byte
+	SignerId   [32]byte
+	ProductId  uint16
+	ReportData [REPORT_DATA_SIZE]byte
+	Debug      bool
+	// the TCB status of the platform, the zero value is tcbstatus.UpToDate
+	TcbStatus     tcbstatus.Status
+	TcbAdvisories []string
+}
+
+// SgxQuoteProvider is a fake quote provider, see sgx.SetReportVerifier. Its reports are SgxReport in JSON followed by
+// an HMAC-SHA256 with a throwaway key, so that tampered reports and the reports of other providers are rejected.
+type SgxQuoteProvider struct {
+	key []byte
+}
+
+func NewSgxQuoteProvider(t testing.TB) *SgxQuoteProvider {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	return &SgxQuoteProvider{key: key}
+}
+
+func (p *SgxQuoteProvider) mac(body []byte) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Report creates a report that the provider verifies
+func (p *SgxQuoteProvider) Report(t testing.TB, report SgxReport) []byte {
+	body, err := json.Marshal(&report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return append(body, p.mac(body)...)
+}
+
+// VerifyRemoteReport verifies a report created by the provider. Same as the quote provider, a report of a platform
+// that isn't up to date is returned with attestation.ErrTCBLevelInvalid.
+func (p *SgxQuoteProvider) VerifyRemoteReport(reportBytes []byte) (attestation.Report, error) {
+	if len(reportBytes) < sha256.Size {
+		return attestation.Report{}, ErrInvalidSgxReport
+	}
+
+	body, mac := reportBytes[:len(reportBytes)-sha256.Size], reportBytes[len(reportBytes)-sha256.Size:]
+	if !hmac.Equal(mac, p.mac(body)) {
+		return attestation.Report{}, ErrInvalidSgxReport
+	}
+
+	var synthetic SgxReport
+	if err := json.Unmarshal(body, &synthetic); err != nil {
+		return attestation.Report{}, err
+	}
+
+	productId := make([]byte, 16)
+	productId[0], productId[1] = byte(synthetic.ProductId), byte(synthetic.ProductId>>8)
+
+	report := attestation.Report{
+		Data:          synthetic.ReportData[:],
+		Debug:         synthetic.Debug,
+		UniqueID:      synthetic.UniqueId[:],
+		SignerID:      synthetic.SignerId[:],
+		ProductID:     productId,
+		TCBStatus:     synthetic.TcbStatus,
+		TCBAdvisories: synthetic.TcbAdvisories,
+	}
+
+	if report.TCBStatus != tcbstatus.UpToDate {
+		return report, attestation.ErrTCBLevelInvalid
+	}
+
+	return report, nil
+}
@@ -0,0 +1,70 @@
+// Package testutil creates synthetic attestation reports for tests: Nitro attestation documents signed by a throwaway
+// CA and SGX-like reports verified by a fake quote provider, with the measurements and the user data of choice.
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation"
+	"github.com/venture23-aleo/oracle-verification-backend/nonce"
+
+	encoding "github.com/venture23-aleo/aleo-oracle-encoding"
+)
+
+// the size of the report data of SGX reports
+const REPORT_DATA_SIZE = 64
+
+// Response creates a response with the attested data of an HTTP request, without a report. The report type is one of
+// attestation.TEE_TYPE_*.
+func Response(reportType string, attestationData string) *attestation.AttestationResponse {
+	return &attestation.AttestationResponse{
+		ReportType:         reportType,
+		AttestationData:    attestationData,
+		ResponseBody:       `{"weather":{"temperature":"` + attestationData + `"}}`,
+		ResponseStatusCode: http.StatusOK,
+		Timestamp:          1701851063,
+		AttestationRequest: attestation.AttestationRequest{
+			Url:            "weather.example.com/api",
+			RequestMethod:  http.MethodGet,
+			Selector:       "weather.temperature",
+			ResponseFormat: "json",
+			EncodingOptions: encoding.EncodingOptions{
+				Value:     encoding.ENCODING_OPTION_FLOAT,
+				Precision: 2,
+			},
+		},
+	}
+}
+
+// UserData returns the hash of the attested data of a response, as computed by the session, i.e. the Poseidon8 hash
+// with a WASM session and the fake hash with an aleo.FakeSession
+func UserData(session aleo.Session, resp *attestation.AttestationResponse) ([]byte, error) {
+	dataBytes, err := attestation.ProofData(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return attestation.HashProofData(session, dataBytes)
+}
+
+// SgxReportData returns the report data of an SGX report of a response, the hash of the attested data followed by the
+// nonce, if it's set
+func SgxReportData(session aleo.Session, resp *attestation.AttestationResponse, reportNonce []byte) ([REPORT_DATA_SIZE]byte, error) {
+	var reportData [REPORT_DATA_SIZE]byte
+
+	userData, err := UserData(session, resp)
+	if err != nil {
+		return reportData, err
+	}
+
+	if len(reportNonce) > nonce.NONCE_SIZE {
+		return reportData, fmt.Errorf("testutil: nonce of %d bytes is too long", len(reportNonce))
+	}
+
+	copy(reportData[:], userData)
+	copy(reportData[16:], reportNonce)
+
+	return reportData, nil
+}
@@ -0,0 +1,85 @@
+package testutil
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/edgelesssys/ego/attestation"
+	"github.com/edgelesssys/ego/attestation/tcbstatus"
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/nitro"
+)
+
+func TestNitroCA_Document(t *testing.T) {
+	ca := NewNitroCA(t)
+
+	var pcrs [3][48]byte
+	pcrs[0][0], pcrs[1][0], pcrs[2][0] = 1, 2, 3
+	document := ca.Document(t, NitroDocument{Pcrs: pcrs, UserData: []byte("0123456789abcdef"), Nonce: []byte{0xaa}})
+
+	if err := nitro.Init(ca.WriteRootPem(t), nitro.VERIFICATION_TIME_CURRENT, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer nitro.Init("", nitro.VERIFICATION_TIME_ATTESTATION, 0)
+
+	verified, err := nitro.VerifyDocument(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(verified.PCRs[2], pcrs[2][:]) || string(verified.UserData) != "0123456789abcdef" || !bytes.Equal(verified.Nonce, []byte{0xaa}) {
+		t.Errorf("VerifyDocument() = %+v", verified)
+	}
+
+	if _, err := nitro.VerifyDocument(NewNitroCA(t).Document(t, NitroDocument{Pcrs: pcrs})); err == nil {
+		t.Error("VerifyDocument() verified a document of another CA")
+	}
+}
+
+func TestSgxQuoteProvider(t *testing.T) {
+	provider := NewSgxQuoteProvider(t)
+
+	report, err := provider.VerifyRemoteReport(provider.Report(t, SgxReport{UniqueId: [32]byte{1}, ReportData: [REPORT_DATA_SIZE]byte{2}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.UniqueID[0] != 1 || report.Data[0] != 2 || report.TCBStatus != tcbstatus.UpToDate {
+		t.Errorf("VerifyRemoteReport() = %+v", report)
+	}
+
+	report, err = provider.VerifyRemoteReport(provider.Report(t, SgxReport{TcbStatus: tcbstatus.OutOfDate}))
+	if !errors.Is(err, attestation.ErrTCBLevelInvalid) || report.TCBStatus != tcbstatus.OutOfDate {
+		t.Errorf("VerifyRemoteReport() = %+v, error = %v", report, err)
+	}
+
+	if _, err := provider.VerifyRemoteReport(NewSgxQuoteProvider(t).Report(t, SgxReport{})); !errors.Is(err, ErrInvalidSgxReport) {
+		t.Errorf("VerifyRemoteReport() error = %v, want %v", err, ErrInvalidSgxReport)
+	}
+}
+
+func TestUserData(t *testing.T) {
+	session := aleo.FakeSession{}
+
+	userData, err := UserData(session, Response("nitro", "12.34"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := UserData(session, Response("nitro", "43.21"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(userData) != 16 || bytes.Equal(userData, other) {
+		t.Errorf("UserData() = %x, %x", userData, other)
+	}
+
+	reportData, err := SgxReportData(session, Response("sgx", "12.34"), []byte{0xaa})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reportData[:16], userData) || reportData[16] != 0xaa {
+		t.Errorf("SgxReportData() = %x", reportData)
+	}
+}
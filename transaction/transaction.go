@@ -0,0 +1,252 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/tee"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	// number of inputs of the oracle program functions that set the oracle data:
+	// report data, report, signature, public key
+	ORACLE_UPDATE_INPUTS = 4
+)
+
+var (
+	ErrNoOracleUpdate        = errors.New("transaction doesn't have oracle updates")
+	ErrUnknownReportType     = errors.New("cannot determine the report type of the oracle update")
+	ErrTransactionNotFound   = errors.New("transaction not found")
+	ErrMalformedOracleReport = errors.New("malformed oracle report")
+)
+
+type Input struct {
+	Type  string `json:"type"`
+	Id    string `json:"id"`
+	Value string `json:"value"`
+}
+
+type Transition struct {
+	Id       string  `json:"id"`
+	Program  string  `json:"program"`
+	Function string  `json:"function"`
+	Inputs   []Input `json:"inputs"`
+}
+
+// Transaction is an Aleo transaction as returned by the Aleo node API
+type Transaction struct {
+	Type      string `json:"type"`
+	Id        string `json:"id"`
+	Execution *struct {
+		Transitions []Transition `json:"transitions"`
+	} `json:"execution"`
+}
+
+// OracleUpdate is a transition to the oracle program that sets the oracle data
+type OracleUpdate struct {
+	TransitionId string `json:"transitionId"`
+	Function     string `json:"function"`
+	ReportData   string `json:"reportData"`
+	Report       string `json:"report"`
+	Signature    string `json:"signature"`
+	PublicKey    string `json:"publicKey"`
+}
+
+// VerificationResult is the result of verifying an oracle update. Valid covers the report and the report data, the
+// signature of the report hash is checked by the Aleo program when the transition is executed, so it's returned as is.
+type VerificationResult struct {
+	TransactionId       string                          `json:"transactionId"`
+	TransitionId        string                          `json:"transitionId"`
+	Function            string                          `json:"function"`
+	ReportType          string                          `json:"reportType,omitempty"`
+	UnverifiedSignature string                          `json:"unverifiedSignature"`
+	UnverifiedPublicKey string                          `json:"unverifiedPublicKey"`
+	DecodedData         []*attestation.DecodedProofData `json:"decodedData,omitempty"`
+	Valid               bool                            `json:"valid"`
+	ErrorMessage        string                          `json:"errorMessage,omitempty"`
+}
+
+// ConfirmedTransaction is a transaction included in a block
+type ConfirmedTransaction struct {
+	Status      string      `json:"status"`
+	Type        string      `json:"type"`
+	Index       int         `json:"index"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// Block is an Aleo block as returned by the Aleo node API, only the transactions are decoded
+type Block struct {
+	BlockHash    string                 `json:"block_hash"`
+	Transactions []ConfirmedTransaction `json:"transactions"`
+}
+
+// Fetch requests a transaction from the Aleo node API
+func Fetch(ctx context.Context, client *node.Client, transactionId string) (*Transaction, error) {
+	tx := new(Transaction)
+	err := client.GetJSON(ctx, "/transaction/"+url.PathEscape(transactionId), tx)
+	if err == node.ErrNotFound {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// FetchLatestHeight requests the height of the latest block from the Aleo node API
+func FetchLatestHeight(ctx context.Context, client *node.Client) (uint64, error) {
+	var height uint64
+	if err := client.GetJSON(ctx, "/latest/height", &height); err != nil {
+		return 0, err
+	}
+
+	return height, nil
+}
+
+// FetchBlock requests a block from the Aleo node API
+func FetchBlock(ctx context.Context, client *node.Client, height uint64) (*Block, error) {
+	block := new(Block)
+	if err := client.GetJSON(ctx, fmt.Sprintf("/block/%d", height), block); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// ExtractOracleUpdates finds the transitions to the oracle program that set the oracle data
+func ExtractOracleUpdates(tx *Transaction, contractName string) []OracleUpdate {
+	updates := make([]OracleUpdate, 0)
+
+	if tx == nil || tx.Execution == nil {
+		return updates
+	}
+
+	for _, transition := range tx.Execution.Transitions {
+		if transition.Program != contractName || len(transition.Inputs) != ORACLE_UPDATE_INPUTS {
+			continue
+		}
+
+		signature := transition.Inputs[2].Value
+		publicKey := transition.Inputs[3].Value
+		if !strings.HasPrefix(signature, "sign1") || !strings.HasPrefix(publicKey, "aleo1") {
+			continue
+		}
+
+		updates = append(updates, OracleUpdate{
+			TransitionId: transition.Id,
+			Function:     transition.Function,
+			ReportData:   transition.Inputs[0].Value,
+			Report:       transition.Inputs[1].Value,
+			Signature:    signature,
+			PublicKey:    publicKey,
+		})
+	}
+
+	return updates
+}
+
+// the report is padded with zeroes to fit the Leo struct, returns the report type and the report without the padding
+func trimReport(function string, report []byte) (string, []byte, error) {
+	reportType := ""
+	switch {
+	case strings.Contains(function, attestation.TEE_TYPE_NITRO):
+		reportType = attestation.TEE_TYPE_NITRO
+	case strings.Contains(function, attestation.TEE_TYPE_SGX):
+		reportType = attestation.TEE_TYPE_SGX
+	case len(report) > 0 && (report[0] == 0x84 || report[0] == 0xd2):
+		// COSE_Sign1 is a CBOR array of 4 elements, optionally tagged
+		reportType = attestation.TEE_TYPE_NITRO
+	default:
+		// a bare SGX quote or an EGo remote report
+		if trimmed, err := quote.Trim(report); err == nil {
+			return attestation.TEE_TYPE_SGX, trimmed, nil
+		}
+		return "", nil, ErrUnknownReportType
+	}
+
+	switch reportType {
+	case attestation.TEE_TYPE_SGX:
+		trimmed, err := quote.Trim(report)
+		if err != nil {
+			return reportType, nil, fmt.Errorf("%w: %w", ErrMalformedOracleReport, err)
+		}
+		return reportType, trimmed, nil
+
+	default:
+		var document cbor.RawMessage
+		rest, err := cbor.UnmarshalFirst(report, &document)
+		if err != nil {
+			return reportType, nil, ErrMalformedOracleReport
+		}
+		return reportType, report[:len(report)-len(rest)], nil
+	}
+}
+
+// VerifyOracleUpdate recovers the report and the report data from an oracle update, then verifies them the same way as /verify does.
+// The Aleo signature and public key of the update aren't verified, see VerificationResult.
+func VerifyOracleUpdate(aleoSession aleo.Session, transactionId string, update *OracleUpdate, policies tee.Policies) *VerificationResult {
+	result := &VerificationResult{
+		TransactionId:       transactionId,
+		TransitionId:        update.TransitionId,
+		Function:            update.Function,
+		UnverifiedSignature: update.Signature,
+		UnverifiedPublicKey: update.PublicKey,
+	}
+
+	fail := func(err error) *VerificationResult {
+		result.ErrorMessage = err.Error()
+		return result
+	}
+
+	proofData, err := aleoSession.RecoverMessage([]byte(update.ReportData))
+	if err != nil {
+		log.Println("transaction: failed to recover report data:", err)
+		return fail(err)
+	}
+
+	decodedData, err := attestation.DecodeProofDataChunks(proofData)
+	if err != nil {
+		log.Println("transaction: failed to decode report data:", err)
+		return fail(err)
+	}
+	result.DecodedData = decodedData
+
+	paddedReport, err := aleoSession.RecoverMessage([]byte(update.Report))
+	if err != nil {
+		log.Println("transaction: failed to recover report:", err)
+		return fail(err)
+	}
+
+	reportType, reportBytes, err := trimReport(update.Function, paddedReport)
+	result.ReportType = reportType
+	if err != nil {
+		return fail(err)
+	}
+
+	verified, err := attestation.VerifyReport(reportType, reportBytes, "", policies)
+	if err != nil {
+		log.Printf("transaction: error verifying %s report: %s\n", reportType, err)
+		return fail(err)
+	}
+
+	err = attestation.VerifyProofData(aleoSession, proofData, verified.UserData)
+	if err != nil {
+		log.Printf("transaction: error verifying %s report data: %s\n", reportType, err)
+		return fail(err)
+	}
+
+	result.Valid = true
+
+	return result
+}
@@ -0,0 +1,238 @@
+package transaction
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/venture23-aleo/oracle-verification-backend/aleo"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation"
+	"github.com/venture23-aleo/oracle-verification-backend/attestation/sgx/quote"
+	"github.com/venture23-aleo/oracle-verification-backend/node"
+
+	encoding "github.com/venture23-aleo/aleo-oracle-encoding"
+)
+
+const testContractName = "official_oracle.aleo"
+
+// creates a mock Aleo node API serving a single transaction
+func newMockNode(t *testing.T, tx *Transaction) *httptest.Server {
+	body, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/testnet/transaction/"+tx.Id {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+}
+
+// fakeSession recovers the messages from a fixed set of Leo values
+type fakeSession struct {
+	aleo.Session
+
+	messages map[string][]byte
+}
+
+func (s *fakeSession) RecoverMessage(formattedMessage []byte) ([]byte, error) {
+	message, ok := s.messages[string(formattedMessage)]
+	if !ok {
+		return nil, errors.New("unknown message")
+	}
+	return message, nil
+}
+
+func TestFetchAndVerify(t *testing.T) {
+	proofData, err := attestation.PrepareProofData(200, "42", 1701851063, &attestation.AttestationRequest{
+		Url:            "google.com",
+		RequestMethod:  http.MethodGet,
+		ResponseFormat: "json",
+		Selector:       "value",
+		EncodingOptions: encoding.EncodingOptions{
+			Value: encoding.ENCODING_OPTION_INT,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an EGo remote report with a quote with 16 bytes of signature data
+	sgxReport := newTestRemoteReport(newTestQuote(16))
+
+	reportData := "{ c0: { f0: 1u128 } }"
+	report := "{ c0: { f0: 2u128 } }"
+	session := &fakeSession{
+		messages: map[string][]byte{
+			reportData: append(proofData, make([]byte, 512)...),
+			report:     append(sgxReport, make([]byte, 512)...),
+		},
+	}
+
+	tx := &Transaction{
+		Type: "execute",
+		Id:   "at1test",
+	}
+	tx.Execution = &struct {
+		Transitions []Transition `json:"transitions"`
+	}{
+		Transitions: []Transition{
+			{
+				Id:       "au1other",
+				Program:  "credits.aleo",
+				Function: "transfer_public",
+				Inputs:   []Input{{Type: "public", Value: "aleo1abc"}},
+			},
+			{
+				Id:       "au1oracle",
+				Program:  testContractName,
+				Function: "set_sgx_data",
+				Inputs: []Input{
+					{Type: "public", Value: reportData},
+					{Type: "public", Value: report},
+					{Type: "public", Value: "sign1abc"},
+					{Type: "public", Value: "aleo1abc"},
+				},
+			},
+		},
+	}
+
+	mockNode := newMockNode(t, tx)
+	defer mockNode.Close()
+
+	// a missing transaction is retried
+	client, err := node.NewClient([]string{mockNode.URL + "/v1/testnet/"}, node.Options{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Fetch(context.Background(), client, "at1missing"); err != ErrTransactionNotFound {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrTransactionNotFound)
+	}
+
+	fetched, err := Fetch(context.Background(), client, tx.Id)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	updates := ExtractOracleUpdates(fetched, testContractName)
+	if len(updates) != 1 || updates[0].TransitionId != "au1oracle" {
+		t.Fatalf("ExtractOracleUpdates() = %v, want the oracle transition", updates)
+	}
+
+	result := VerifyOracleUpdate(session, fetched.Id, &updates[0], nil)
+	if result.ReportType != attestation.TEE_TYPE_SGX {
+		t.Errorf("VerifyOracleUpdate() report type = %v, want %v", result.ReportType, attestation.TEE_TYPE_SGX)
+	}
+	if result.UnverifiedSignature != updates[0].Signature || result.UnverifiedPublicKey != updates[0].PublicKey {
+		t.Errorf("VerifyOracleUpdate() signature = %v, public key = %v", result.UnverifiedSignature, result.UnverifiedPublicKey)
+	}
+	if len(result.DecodedData) != 1 || result.DecodedData[0].AttestationData != "42" {
+		t.Errorf("VerifyOracleUpdate() decoded data = %v", result.DecodedData)
+	}
+	// the quote is not signed
+	if result.Valid || result.ErrorMessage == "" {
+		t.Errorf("VerifyOracleUpdate() valid = %v, error = %v, want an error", result.Valid, result.ErrorMessage)
+	}
+}
+
+// creates an SGX quote without a report body and signature
+func newTestQuote(signatureDataLength int) []byte {
+	sgxQuote := make([]byte, quote.HEADER_SIZE+quote.REPORT_BODY_SIZE+4+signatureDataLength)
+	binary.LittleEndian.PutUint16(sgxQuote, quote.QUOTE_VERSION_3)
+	binary.LittleEndian.PutUint32(sgxQuote[quote.HEADER_SIZE+quote.REPORT_BODY_SIZE:], uint32(signatureDataLength))
+
+	return sgxQuote
+}
+
+// wraps the quote in the Open Enclave report header, the same way as the EGo remote reports of the oracle backend
+func newTestRemoteReport(sgxQuote []byte) []byte {
+	header := make([]byte, 16)
+	binary.LittleEndian.PutUint32(header[0:], 1)
+	binary.LittleEndian.PutUint32(header[4:], 2)
+	binary.LittleEndian.PutUint64(header[8:], uint64(len(sgxQuote)))
+
+	return append(header, sgxQuote...)
+}
+
+func Test_trimReport(t *testing.T) {
+	sgxQuote := newTestQuote(8)
+	remoteReport := newTestRemoteReport(sgxQuote)
+
+	// COSE_Sign1 shaped CBOR array
+	cose := []byte{0x84, 0x41, 0x01, 0xa0, 0x41, 0x02, 0x41, 0x03}
+
+	tests := []struct {
+		name     string
+		function string
+		report   []byte
+		wantType string
+		wantLen  int
+		wantErr  bool
+	}{
+		{
+			name:     "sgx by function",
+			function: "set_sgx_data",
+			report:   append(sgxQuote, make([]byte, 100)...),
+			wantType: attestation.TEE_TYPE_SGX,
+			wantLen:  len(sgxQuote),
+		},
+		{
+			name:     "sgx remote report by function",
+			function: "set_sgx_data",
+			report:   append(remoteReport, make([]byte, 100)...),
+			wantType: attestation.TEE_TYPE_SGX,
+			wantLen:  len(remoteReport),
+		},
+		{
+			name:     "sgx remote report by content",
+			function: "set_data",
+			report:   append(remoteReport, make([]byte, 100)...),
+			wantType: attestation.TEE_TYPE_SGX,
+			wantLen:  len(remoteReport),
+		},
+		{
+			name:     "nitro by content",
+			function: "set_data",
+			report:   append(cose, make([]byte, 100)...),
+			wantType: attestation.TEE_TYPE_NITRO,
+			wantLen:  len(cose),
+		},
+		{
+			name:     "truncated sgx report",
+			function: "set_sgx_data",
+			report:   remoteReport[:len(remoteReport)-1],
+			wantType: attestation.TEE_TYPE_SGX,
+			wantErr:  true,
+		},
+		{
+			name:     "unknown",
+			function: "set_data",
+			report:   make([]byte, 100),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, got, err := trimReport(tt.function, tt.report)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("trimReport() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotType != tt.wantType {
+				t.Errorf("trimReport() type = %v, want %v", gotType, tt.wantType)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("trimReport() length = %v, want %v", len(got), tt.wantLen)
+			}
+		})
+	}
+}